@@ -0,0 +1,205 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Keys of the values RequireAuth and RequireDevice put on the gin context
+const (
+	userContextKey    = "user"
+	sessionContextKey = "session"
+	deviceContextKey  = "device"
+)
+
+// apiKeyHeader carries the API key of a station device
+const apiKeyHeader = "X-API-Key"
+
+var errUnauthenticated = errors.New("authentication required")
+
+// RequireAuth rejects requests without a valid bearer access token and puts
+// the authenticated user on the context, see CurrentUser
+func (s *Server) RequireAuth(c *gin.Context) {
+	user, sessionID, err := s.authenticate(c)
+	if err != nil {
+		if errors.Is(err, errUnauthenticated) {
+			c.Header("WWW-Authenticate", "Bearer")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Set(userContextKey, user)
+	c.Set(sessionContextKey, sessionID)
+	c.Next()
+}
+
+// OptionalAuth authenticates requests carrying an access token like RequireAuth
+// and lets requests without one pass anonymously
+func (s *Server) OptionalAuth(c *gin.Context) {
+	if c.GetHeader("Authorization") == "" {
+		c.Next()
+		return
+	}
+	s.RequireAuth(c)
+}
+
+// authenticate resolves the bearer token to the user of a session that is still active
+func (s *Server) authenticate(c *gin.Context) (*database.User, uint, error) {
+	header := c.GetHeader("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || token == "" {
+		return nil, 0, errUnauthenticated
+	}
+	claims, err := s.issuer.Parse(token)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", errUnauthenticated, err)
+	}
+	userID, err := claims.UserID()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: %v", errUnauthenticated, err)
+	}
+
+	session, err := s.tokens.Get(c.Request.Context(), claims.SessionID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && (session.UserID != userID || !session.Active(time.Now()))) {
+		return nil, 0, fmt.Errorf("%w: session has been revoked", errUnauthenticated)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	user, err := s.users.Get(c.Request.Context(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, 0, fmt.Errorf("%w: user does not exist", errUnauthenticated)
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return user, session.ID, nil
+}
+
+// CurrentUser returns the user authenticated by RequireAuth, nil on public routes
+func CurrentUser(c *gin.Context) *database.User {
+	if user, ok := c.Get(userContextKey); ok {
+		return user.(*database.User)
+	}
+	return nil
+}
+
+// RequireDevice rejects requests without an active device API key and puts the
+// device on the context, see CurrentDevice. On routes below /refill_stations/:id
+// the device has to belong to that station.
+func (s *Server) RequireDevice(c *gin.Context) {
+	device, err := s.authenticateDevice(c)
+	if err != nil {
+		if errors.Is(err, errUnauthenticated) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if stationID := c.Param("id"); stationID != "" && stationID != strconv.FormatUint(uint64(device.StationID), 10) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Device belongs to another refill station"})
+		return
+	}
+	c.Set(deviceContextKey, device)
+	c.Next()
+}
+
+// authenticateDevice resolves the API key to a device that is not revoked
+func (s *Server) authenticateDevice(c *gin.Context) (*database.Device, error) {
+	apiKey := c.GetHeader(apiKeyHeader)
+	if apiKey == "" {
+		return nil, fmt.Errorf("%w: missing %s header", errUnauthenticated, apiKeyHeader)
+	}
+	now := time.Now()
+	key, err := s.devices.GetKeyByHash(c.Request.Context(), auth.HashAPIKey(apiKey))
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && !key.Active(now)) {
+		return nil, fmt.Errorf("%w: invalid API key", errUnauthenticated)
+	}
+	if err != nil {
+		return nil, err
+	}
+	device, err := s.devices.Get(c.Request.Context(), key.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && device.RevokedAt != nil) {
+		return nil, fmt.Errorf("%w: device has been revoked", errUnauthenticated)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return device, nil
+}
+
+// CurrentDevice returns the device authenticated by RequireDevice, nil on other routes
+func CurrentDevice(c *gin.Context) *database.Device {
+	if device, ok := c.Get(deviceContextKey); ok {
+		return device.(*database.Device)
+	}
+	return nil
+}
+
+// issueTokens starts a new session for the user and returns its tokens
+func (s *Server) issueTokens(c *gin.Context, user *database.User) (*TokenResponse, error) {
+	refreshToken, hash, err := auth.NewRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := database.RefreshToken{UserID: user.ID, TokenHash: hash, ExpiresAt: now.Add(s.issuer.RefreshTTL())}
+	if err := s.tokens.Create(c.Request.Context(), &session); err != nil {
+		return nil, err
+	}
+	accessToken, err := s.issuer.AccessToken(user.ID, session.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.issuer.AccessTTL().Seconds()),
+		User:         *user,
+	}, nil
+}
+
+// RequirePermission rejects users whose role lacks the permission, it runs after RequireAuth
+func (s *Server) RequirePermission(permission auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auth.Can(CurrentUser(c).Role, permission) {
+			respondForbidden(c)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// isOwnerOr reports whether the current user is the owner or has the permission
+func isOwnerOr(c *gin.Context, ownerID uint, permission auth.Permission) bool {
+	user := CurrentUser(c)
+	return user.ID == ownerID || auth.Can(user.Role, permission)
+}
+
+// canOperate reports whether the current user manages all stations or operates the given one
+func canOperate(c *gin.Context, station *database.RefillStation) bool {
+	user := CurrentUser(c)
+	if auth.Can(user.Role, auth.ManageStations) {
+		return true
+	}
+	return auth.Can(user.Role, auth.OperateStations) && station.OwnerID != nil && *station.OwnerID == user.ID
+}
+
+func respondForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, gin.H{"error": "Permission denied"})
+}
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+type RegisterRequest struct {
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required,min=8"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+type TokenResponse struct {
+	AccessToken  string        `json:"access_token"`
+	RefreshToken string        `json:"refresh_token"`
+	TokenType    string        `json:"token_type"`
+	ExpiresIn    int           `json:"expires_in"`
+	User         database.User `json:"user"`
+}
+
+// @Summary Register a user
+// @Description Create a user with a password and log in
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param user body RegisterRequest true "User"
+// @Success 201 {object} TokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /auth/register [post]
+func (s *Server) Register(c *gin.Context) {
+	var request RegisterRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	if _, err := s.users.GetByEmail(c.Request.Context(), email); err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": database.ErrEmailExists.Error()})
+		return
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hash, err := auth.HashPassword(request.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user := database.User{FirstName: request.FirstName, LastName: request.LastName, Email: &email, PasswordHash: hash}
+	if err := s.users.Create(c.Request.Context(), &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := s.issueTokens(c, &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, tokens)
+}
+
+// @Summary Log in
+// @Description Exchange email and password for an access and a refresh token
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param credentials body LoginRequest true "Credentials"
+// @Success 200 {object} TokenResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/login [post]
+func (s *Server) Login(c *gin.Context) {
+	var request LoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(request.Email))
+	user, err := s.users.GetByEmail(c.Request.Context(), email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil || !auth.CheckPassword(user.PasswordHash, request.Password) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
+
+	tokens, err := s.issueTokens(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Refresh the tokens
+// @Description Exchange a refresh token for new tokens, the refresh token can only be used once
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param token body RefreshRequest true "Refresh token"
+// @Success 200 {object} TokenResponse
+// @Failure 401 {object} map[string]string
+// @Router /auth/refresh [post]
+func (s *Server) RefreshTokens(c *gin.Context) {
+	var request RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := s.tokens.GetByHash(c.Request.Context(), auth.HashRefreshToken(request.RefreshToken))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil || !session.Active(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+	user, err := s.users.Get(c.Request.Context(), session.UserID)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+		return
+	}
+
+	// Rotate the refresh token, the old one and its access tokens stop working. Of concurrent
+	// requests with the same token only the one revoking it gets new tokens.
+	if err := s.tokens.Revoke(c.Request.Context(), session.ID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrTokenRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, err := s.issueTokens(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// @Summary Log out
+// @Description Revoke the refresh token and all access tokens of the current session
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 204
+// @Failure 401 {object} map[string]string
+// @Router /auth/logout [post]
+func (s *Server) Logout(c *gin.Context) {
+	err := s.tokens.Revoke(c.Request.Context(), c.GetUint(sessionContextKey), time.Now())
+	if err != nil && !errors.Is(err, repository.ErrTokenRevoked) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary Show the current user
+// @Description Get the user authenticated by the access token
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} database.User
+// @Failure 401 {object} map[string]string
+// @Router /auth/me [get]
+func (s *Server) GetCurrentUser(c *gin.Context) {
+	c.JSON(http.StatusOK, CurrentUser(c))
+}
@@ -0,0 +1,201 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+func TestAuthRoutes(t *testing.T) {
+	newUser := map[string]string{"first_name": "Erika", "last_name": "Muster", "email": "erika@poseidon.test", "password": "geheim123"}
+	takenEmail := map[string]string{"first_name": "Erika", "last_name": "Muster", "email": testEmail(1), "password": "geheim123"}
+	shortPassword := map[string]string{"first_name": "Erika", "last_name": "Muster", "email": "erika@poseidon.test", "password": "kurz"}
+
+	runRouteCases(t, []routeCase{
+		{"register", http.MethodPost, "/auth/register", newUser, http.StatusCreated, 0},
+		{"register taken email", http.MethodPost, "/auth/register", takenEmail, http.StatusConflict, 0},
+		{"register short password", http.MethodPost, "/auth/register", shortPassword, http.StatusBadRequest, 0},
+		{"login", http.MethodPost, "/auth/login", map[string]string{"email": testEmail(1), "password": testPassword}, http.StatusOK, 0},
+		{"login wrong password", http.MethodPost, "/auth/login", map[string]string{"email": testEmail(1), "password": "falsch"}, http.StatusUnauthorized, 0},
+		{"login unknown email", http.MethodPost, "/auth/login", map[string]string{"email": "nobody@poseidon.test", "password": testPassword}, http.StatusUnauthorized, 0},
+		{"refresh invalid token", http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": "invalid"}, http.StatusUnauthorized, 0},
+		{"me", http.MethodGet, "/auth/me", nil, http.StatusOK, 1},
+		{"me anonymous", http.MethodGet, "/auth/me", nil, http.StatusUnauthorized, 0},
+		{"logout", http.MethodPost, "/auth/logout", nil, http.StatusNoContent, 1},
+		{"logout anonymous", http.MethodPost, "/auth/logout", nil, http.StatusUnauthorized, 0},
+		{"delete user anonymous", http.MethodDelete, "/users?id=101", nil, http.StatusUnauthorized, 0},
+		{"create like anonymous", http.MethodPost, "/likes", map[string]interface{}{"station_id": 3}, http.StatusUnauthorized, 0},
+	})
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	r := newTestRouter(t)
+
+	w := doRequest(t, r, http.MethodPost, "/auth/register",
+		map[string]string{"first_name": "Erika", "last_name": "Muster", "email": "Erika@Poseidon.test", "password": "geheim123"})
+	registered := decodeResponse[api.TokenResponse](t, w)
+	if registered.User.ID != 102 || registered.TokenType != "Bearer" || registered.ExpiresIn != 900 {
+		t.Errorf("unexpected registration response: %+v", registered)
+	}
+
+	w = doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": "erika@poseidon.test", "password": "geheim123"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected login with the normalized email to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	me := decodeResponse[database.User](t, doAuthRequest(t, r, decodeResponse[api.TokenResponse](t, w).AccessToken, http.MethodGet, "/auth/me", nil))
+	if me.ID != 102 || me.FirstName != "Erika" {
+		t.Errorf("unexpected current user: %+v", me)
+	}
+	if strings.Contains(w.Body.String(), "password") {
+		t.Errorf("expected the password hash not to be serialized: %s", w.Body.String())
+	}
+}
+
+func TestRefreshRotatesTokens(t *testing.T) {
+	r := newTestRouter(t)
+	w := doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": testEmail(1), "password": testPassword})
+	first := decodeResponse[api.TokenResponse](t, w)
+
+	w = doRequest(t, r, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": first.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected refresh to succeed, got %d: %s", w.Code, w.Body.String())
+	}
+	second := decodeResponse[api.TokenResponse](t, w)
+	if second.RefreshToken == first.RefreshToken {
+		t.Error("expected a new refresh token")
+	}
+
+	// The used refresh token and the access tokens of its session are revoked
+	if w := doRequest(t, r, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": first.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected reused refresh token to be rejected, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, first.AccessToken, http.MethodGet, "/auth/me", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected access token of the old session to be rejected, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, second.AccessToken, http.MethodGet, "/auth/me", nil); w.Code != http.StatusOK {
+		t.Errorf("expected new access token to be accepted, got %d", w.Code)
+	}
+}
+
+func TestConcurrentRefreshRotatesOnce(t *testing.T) {
+	r := newTestRouter(t)
+	w := doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": testEmail(1), "password": testPassword})
+	tokens := decodeResponse[api.TokenResponse](t, w)
+
+	// Requests replaying the same refresh token at once must not all get new tokens
+	const attempts = 8
+	requests := make([]*http.Request, attempts)
+	for i := range requests {
+		requests[i] = newJSONRequest(t, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": tokens.RefreshToken})
+	}
+	codes := make([]int, attempts)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, requests[i])
+			codes[i] = w.Code
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusOK:
+			succeeded++
+		case http.StatusUnauthorized:
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("expected exactly one refresh to succeed, got %d: %v", succeeded, codes)
+	}
+}
+
+func TestRevokeRefreshTokenOnce(t *testing.T) {
+	repos, _ := seededRepositories(t)
+	ctx := context.Background()
+	token := &database.RefreshToken{UserID: 1, TokenHash: auth.HashRefreshToken("replayed"), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Tokens.Create(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repos.Tokens.Revoke(ctx, token.ID, time.Now()); err != nil {
+		t.Fatalf("expected the first revocation to succeed, got %v", err)
+	}
+	if err := repos.Tokens.Revoke(ctx, token.ID, time.Now()); !errors.Is(err, repository.ErrTokenRevoked) {
+		t.Errorf("expected the second revocation to fail, got %v", err)
+	}
+	if err := repos.Tokens.Revoke(ctx, 999, time.Now()); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected an unknown token not to be found, got %v", err)
+	}
+}
+
+func TestLogoutRevokesSession(t *testing.T) {
+	r := newTestRouter(t)
+	w := doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": testEmail(1), "password": testPassword})
+	tokens := decodeResponse[api.TokenResponse](t, w)
+	other := login(t, r, 1)
+
+	if w := doAuthRequest(t, r, tokens.AccessToken, http.MethodPost, "/auth/logout", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected logout to succeed, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, tokens.AccessToken, http.MethodGet, "/auth/me", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected access token to be revoked, got %d", w.Code)
+	}
+	if w := doRequest(t, r, http.MethodPost, "/auth/refresh", map[string]string{"refresh_token": tokens.RefreshToken}); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected refresh token to be revoked, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, other, http.MethodGet, "/auth/me", nil); w.Code != http.StatusOK {
+		t.Errorf("expected other sessions to stay active, got %d", w.Code)
+	}
+}
+
+func TestRejectsInvalidAccessTokens(t *testing.T) {
+	r := newTestRouter(t)
+	session := login(t, r, 1)
+	claims, err := testIssuer().Parse(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged, _ := auth.NewTokenIssuer("another-secret-with-at-least-32-bytes", time.Minute, time.Hour).AccessToken(1, claims.SessionID, time.Now())
+	expired, _ := testIssuer().AccessToken(1, claims.SessionID, time.Now().Add(-time.Hour))
+	otherUser, _ := testIssuer().AccessToken(2, claims.SessionID, time.Now())
+
+	for name, token := range map[string]string{"malformed": "abc", "forged": forged, "expired": expired, "other user": otherUser} {
+		if w := doAuthRequest(t, r, token, http.MethodGet, "/auth/me", nil); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected status 401, got %d", name, w.Code)
+		}
+	}
+}
+
+func TestHandlersUseAuthenticatedUser(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 2)
+
+	like := decodeResponse[database.Like](t, doAuthRequest(t, r, token, http.MethodPost, "/likes", map[string]interface{}{"station_id": 3, "user_id": 1}))
+	if like.UserID != 2 {
+		t.Errorf("expected like of the authenticated user, got user %d", like.UserID)
+	}
+
+	review := decodeResponse[database.RefillStationReview](t, doAuthRequest(t, r, token, http.MethodPost, "/refill_station_reviews",
+		map[string]interface{}{"station_id": 3, "user_id": 1, "cleanness": 4, "accessibility": 4, "water_quality": 5}))
+	if review.UserID != 2 {
+		t.Errorf("expected review of the authenticated user, got user %d", review.UserID)
+	}
+}
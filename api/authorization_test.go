@@ -0,0 +1,86 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestAuthorizationRoutes(t *testing.T) {
+	newStation := map[string]interface{}{"name": "Neu", "latitude": 49.44, "longitude": 7.76, "type": "smart", "offered_water_types": "tap"}
+
+	runRouteCases(t, []routeCase{
+		{"create user as regular user", http.MethodPost, "/users", map[string]interface{}{"first_name": "Erika"}, http.StatusForbidden, 3},
+		{"update other user", http.MethodPut, "/users", map[string]interface{}{"id": 4, "first_name": "Fremd"}, http.StatusForbidden, 3},
+		{"update own user", http.MethodPut, "/users", map[string]interface{}{"id": 3, "first_name": "Selbst", "email": testEmail(3)}, http.StatusOK, 3},
+		{"delete other user", http.MethodDelete, "/users?id=101", nil, http.StatusForbidden, 3},
+		{"delete own user", http.MethodDelete, "/users?id=101", nil, http.StatusNoContent, 101},
+		{"set role as regular user", http.MethodPut, "/users/3/role", map[string]interface{}{"role": "admin"}, http.StatusForbidden, 3},
+		{"set role as operator", http.MethodPut, "/users/3/role", map[string]interface{}{"role": "admin"}, http.StatusForbidden, 2},
+		{"set role as admin", http.MethodPut, "/users/3/role", map[string]interface{}{"role": "operator"}, http.StatusOK, 1},
+		{"set invalid role", http.MethodPut, "/users/3/role", map[string]interface{}{"role": "king"}, http.StatusBadRequest, 1},
+		{"set role without body", http.MethodPut, "/users/3/role", map[string]interface{}{}, http.StatusBadRequest, 1},
+		{"set role invalid id", http.MethodPut, "/users/abc/role", map[string]interface{}{"role": "user"}, http.StatusBadRequest, 1},
+		{"set role unknown id", http.MethodPut, "/users/999/role", map[string]interface{}{"role": "user"}, http.StatusNotFound, 1},
+
+		{"update other bottle", http.MethodPut, "/bottles", map[string]interface{}{"id": 1, "title": "Fremd"}, http.StatusForbidden, 5},
+		{"update own bottle", http.MethodPut, "/bottles", map[string]interface{}{"id": 1, "title": "Meine"}, http.StatusOK, 4},
+		{"delete other bottle", http.MethodDelete, "/bottles/1", nil, http.StatusForbidden, 5},
+		{"update other review", http.MethodPut, "/refill_station_reviews", map[string]interface{}{"id": 2, "cleanness": 1}, http.StatusForbidden, 3},
+		{"update own review", http.MethodPut, "/refill_station_reviews", map[string]interface{}{"id": 2, "cleanness": 1}, http.StatusOK, 2},
+		{"delete other review", http.MethodDelete, "/refill_station_reviews/2", nil, http.StatusForbidden, 3},
+		{"update other like", http.MethodPut, "/likes", map[string]interface{}{"id": 2, "station_id": 1}, http.StatusForbidden, 3},
+
+		{"create station as regular user", http.MethodPost, "/refill_stations", newStation, http.StatusForbidden, 3},
+		{"create station as operator", http.MethodPost, "/refill_stations", newStation, http.StatusForbidden, 2},
+		{"update station as regular user", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "name": "Fremd"}, http.StatusForbidden, 3},
+		{"update operated station", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "name": "Betrieben"}, http.StatusOK, 2},
+		{"update other station as operator", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 2, "name": "Fremd"}, http.StatusForbidden, 2},
+		{"delete station as operator", http.MethodDelete, "/refill_stations/1", nil, http.StatusForbidden, 2},
+		{"update problem of operated station", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 1, "status": "INPROGRESS"}, http.StatusOK, 2},
+		{"update problem of other station", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 2, "status": "INPROGRESS"}, http.StatusForbidden, 2},
+		{"update problem as regular user", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 1, "status": "INPROGRESS"}, http.StatusForbidden, 3},
+		{"delete problem of other station", http.MethodDelete, "/refill_station_problems/2", nil, http.StatusForbidden, 2},
+
+		{"update transaction as regular user", http.MethodPut, "/water_transactions", map[string]interface{}{"id": 1, "volume": 100}, http.StatusForbidden, 3},
+		{"delete transaction as operator", http.MethodDelete, "/water_transactions?id=1", nil, http.StatusForbidden, 2},
+	})
+}
+
+func TestRoleChanges(t *testing.T) {
+	r := newTestRouter(t)
+	admin := login(t, r, 1)
+	operator := login(t, r, 2)
+
+	// Operators cannot hand their station to someone else
+	doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "owner_id": 3})
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if station.OwnerID == nil || *station.OwnerID != 2 {
+		t.Errorf("expected station 1 to stay with user 2, got owner %v", station.OwnerID)
+	}
+
+	// Admins assign stations and roles, the new role applies to existing tokens
+	user := login(t, r, 3)
+	if w := doAuthRequest(t, r, admin, http.MethodPut, "/refill_stations", map[string]interface{}{"id": 2, "owner_id": 3}); w.Code != http.StatusOK {
+		t.Fatalf("expected admin to assign station 2, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := doAuthRequest(t, r, user, http.MethodPut, "/refill_stations", map[string]interface{}{"id": 2, "name": "Zugewiesen"}); w.Code != http.StatusForbidden {
+		t.Errorf("expected regular user to be forbidden, got %d", w.Code)
+	}
+
+	promoted := decodeResponse[database.User](t, doAuthRequest(t, r, admin, http.MethodPut, "/users/3/role", map[string]interface{}{"role": "operator"}))
+	if promoted.Role != database.RoleOperator {
+		t.Errorf("expected role operator, got %q", promoted.Role)
+	}
+	if w := doAuthRequest(t, r, user, http.MethodPut, "/refill_stations", map[string]interface{}{"id": 2, "name": "Zugewiesen"}); w.Code != http.StatusOK {
+		t.Errorf("expected new operator to update station 2, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Updating a user does not change the role
+	doAuthRequest(t, r, user, http.MethodPut, "/users", map[string]interface{}{"id": 3, "first_name": "Neu", "role": "admin", "email": testEmail(3)})
+	me := decodeResponse[database.User](t, doAuthRequest(t, r, user, http.MethodGet, "/auth/me", nil))
+	if me.Role != database.RoleOperator {
+		t.Errorf("expected role to stay operator, got %q", me.Role)
+	}
+}
@@ -1,10 +1,13 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
@@ -13,20 +16,30 @@ type BottleImage struct {
 }
 
 // @Summary Show all bottles
-// @Description Get all bottles
+// @Description Get a page of bottles
 // @Tags Bottles
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.Bottle
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, user_id, title, fill_volume, water_type" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.Bottle]
+// @Failure 400 {object} map[string]string
 // @Router /bottles [get]
-func GetBottles(c *gin.Context) {
-	var bottles []database.Bottle
-	result := db.Find(&bottles)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func (s *Server) GetBottles(c *gin.Context) {
+	params, err := parseListParams[database.Bottle](c, "user_id", "title", "fill_volume", "water_type")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, bottles)
+	bottles, total, err := s.bottles.List(c.Request.Context(), params.query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	respondList(c, bottles, total, params)
 }
 
 // @Summary Get bottle by bottle ID
@@ -37,7 +50,7 @@ func GetBottles(c *gin.Context) {
 // @Param id path int true "id"
 // @Success 200 {object} database.Bottle
 // @Router /bottles/{id} [get]
-func GetBottleById(c *gin.Context) {
+func (s *Server) GetBottleById(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr != "" {
 		id, err := strconv.Atoi(idStr)
@@ -45,10 +58,9 @@ func GetBottleById(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var bottle database.Bottle
-		result := db.First(&bottle, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		bottle, err := s.bottles.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, bottle)
@@ -59,14 +71,16 @@ func GetBottleById(c *gin.Context) {
 }
 
 // @Summary Get bottle image by bottle ID
-// @Description Get one bottle image with the given ID
+// @Description Get one bottle image with the given ID as base64 in JSON, kept for older apps.
+// @Description New clients load the file from /bottles/{id}/image.
 // @Tags Bottles
 // @Accept json
 // @Produce json
 // @Param id path int true "id"
 // @Success 200 {object} BottleImage
+// @Deprecated
 // @Router /bottles/image/{id} [get]
-func GetBottleImageById(c *gin.Context) {
+func (s *Server) GetBottleImageById(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr != "" {
 		id, err := strconv.Atoi(idStr)
@@ -74,21 +88,20 @@ func GetBottleImageById(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var bottle database.Bottle
-		result := db.First(&bottle, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		bottle, err := s.bottles.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 
-		if bottle.BottleImage == nil {
+		if bottle.ImageID == nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Bottle has no image"})
 			return
 		}
 
-		byteArray, err := DecodeBase64ToBytes(*bottle.BottleImage)
+		byteArray, err := s.images.Read(c.Request.Context(), *bottle.ImageID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding base64 string"})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
@@ -111,7 +124,7 @@ func GetBottleImageById(c *gin.Context) {
 // @Param userId path int true "User ID"
 // @Success 200 {array} database.Bottle
 // @Router /bottles/users/{userId} [get]
-func GetBottlesByUserID(c *gin.Context) {
+func (s *Server) GetBottlesByUserID(c *gin.Context) {
 	userIDStr := c.Param("userId")
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
@@ -119,10 +132,9 @@ func GetBottlesByUserID(c *gin.Context) {
 		return
 	}
 
-	var bottles []database.Bottle
-	result := db.Where("user_id = ?", userID).Find(&bottles)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	bottles, err := s.bottles.ListByUser(c.Request.Context(), uint(userID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, bottles)
@@ -136,16 +148,16 @@ func GetBottlesByUserID(c *gin.Context) {
 // @Param nfc_id path string true "NFC ID"
 // @Success 200 {object} database.Bottle
 // @Router /bottles/preferences/{nfcId} [get]
-func GetBottlePreferencesByNFCId(c *gin.Context) {
+func (s *Server) GetBottlePreferencesByNFCId(c *gin.Context) {
 	nfcID := c.Param("nfcId")
-	var bottle database.Bottle
 
 	if nfcID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "nfcID cannot be empty"})
 		return
 	}
 
-	if err := db.Where("nfc_id = ?", nfcID).First(&bottle).Error; err != nil {
+	bottle, err := s.bottles.GetByNFCID(c.Request.Context(), nfcID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Row not found for NFC ID"})
 		return
 	}
@@ -160,16 +172,22 @@ func GetBottlePreferencesByNFCId(c *gin.Context) {
 // @Produce  json
 // @Param bottle body database.Bottle true "Bottle"
 // @Success 201 {object} database.Bottle
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
 // @Router /bottles [post]
-func CreateBottle(c *gin.Context) {
+func (s *Server) CreateBottle(c *gin.Context) {
 	var bottle database.Bottle
 	if err := c.ShouldBindJSON(&bottle); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result := db.Create(&bottle)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	bottle.UserID = CurrentUser(c).ID
+	if !s.saveBottleImage(c, &bottle) {
+		return
+	}
+	if err := s.bottles.Create(c.Request.Context(), &bottle); err != nil {
+		s.deleteImage(c.Request.Context(), bottle.ImageID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, bottle)
@@ -182,25 +200,51 @@ func CreateBottle(c *gin.Context) {
 // @Produce json
 // @Param bottle body database.Bottle true "Bottle"
 // @Success 200 {object} database.Bottle
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /bottles [put]
-func UpdateBottle(c *gin.Context) {
+func (s *Server) UpdateBottle(c *gin.Context) {
 	var newBottle database.Bottle
 	if err := c.ShouldBindJSON(&newBottle); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var bottle database.Bottle
-	if err := db.Where("id = ?", newBottle.ID).First(&bottle).Error; err != nil {
+	stored, err := s.bottles.Get(c.Request.Context(), newBottle.ID)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, stored.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
+	}
 
-	db.Model(&bottle).Updates(newBottle)
-
-	// Workaround to ensure saving of an empty nfc-id
-	if newBottle.NFCID == "" {
-		db.Model(&bottle).Select("NFCID").Updates(map[string]interface{}{"NFCID": ""})
+	// The bottle stays with its user
+	newBottle.UserID = 0
+	if !s.saveBottleImage(c, &newBottle) {
+		return
+	}
+	bottle, err := s.bottles.Update(c.Request.Context(), &newBottle)
+	if err != nil {
+		s.deleteImage(c.Request.Context(), newBottle.ImageID)
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// A new image replaces the stored one
+	if newBottle.ImageID != nil {
+		s.deleteImage(c.Request.Context(), stored.ImageID)
 	}
 
 	// Respond with the updated bottle data
@@ -214,8 +258,11 @@ func UpdateBottle(c *gin.Context) {
 // @Produce  json
 // @Param id path int true "Bottle ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /bottles/{id} [delete]
-func DeleteBottle(c *gin.Context) {
+func (s *Server) DeleteBottle(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -225,22 +272,55 @@ func DeleteBottle(c *gin.Context) {
 	// Convert to interger
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
 
-	// Check for record not found error
-	var tempBottle database.Bottle
-	result := db.First(&tempBottle, id)
-	if result.Error != nil {
+	bottle, err := s.bottles.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "bottle with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, bottle.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
 	}
 
-	result = db.Delete(&database.Bottle{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.bottles.Delete(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "bottle with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.deleteImage(c.Request.Context(), bottle.ImageID)
 
 	c.Status(http.StatusNoContent)
 }
+
+// saveBottleImage stores the base64 image of a create or update request and references
+// it from the bottle, other image IDs of requests are ignored. On failure it responds
+// with an error and returns false.
+func (s *Server) saveBottleImage(c *gin.Context, bottle *database.Bottle) bool {
+	bottle.ImageID = nil
+	if bottle.BottleImage == nil {
+		return true
+	}
+	data, err := DecodeBase64ToBytes(*bottle.BottleImage)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "bottle_image must be base64 encoded"})
+		return false
+	}
+	image := s.saveImage(c, data)
+	if image == nil {
+		return false
+	}
+	bottle.ImageID = &image.ID
+	bottle.BottleImage = nil
+	return true
+}
@@ -0,0 +1,95 @@
+package api_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestBottleRoutes(t *testing.T) {
+	newBottle := map[string]interface{}{"user_id": 1, "NFCID": "AA:BB:CC:DD", "fill_volume": 500, "water_type": "tap", "title": "Neu"}
+	duplicateNFC := map[string]interface{}{"user_id": 1, "NFCID": "13:8E:BD:0C", "fill_volume": 500, "water_type": "tap", "title": "Kopie"}
+	invalidWaterType := map[string]interface{}{"user_id": 1, "fill_volume": 500, "water_type": "juice", "title": "Saft"}
+
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/bottles", nil, http.StatusOK, 0},
+		{"get by id", http.MethodGet, "/bottles/1", nil, http.StatusOK, 0},
+		{"get invalid id", http.MethodGet, "/bottles/abc", nil, http.StatusBadRequest, 0},
+		{"get unknown id", http.MethodGet, "/bottles/999", nil, http.StatusNotFound, 0},
+		{"image", http.MethodGet, "/bottles/image/1", nil, http.StatusOK, 0},
+		{"image invalid id", http.MethodGet, "/bottles/image/abc", nil, http.StatusBadRequest, 0},
+		{"image unknown id", http.MethodGet, "/bottles/image/999", nil, http.StatusNotFound, 0},
+		{"by user", http.MethodGet, "/bottles/users/4", nil, http.StatusOK, 0},
+		{"by user invalid id", http.MethodGet, "/bottles/users/abc", nil, http.StatusBadRequest, 0},
+		{"preferences", http.MethodGet, "/bottles/preferences/13:8E:BD:0C", nil, http.StatusOK, 0},
+		{"preferences unknown nfc id", http.MethodGet, "/bottles/preferences/FF:FF:FF:FF", nil, http.StatusNotFound, 0},
+		{"create", http.MethodPost, "/bottles", newBottle, http.StatusCreated, 1},
+		{"create duplicate nfc id", http.MethodPost, "/bottles", duplicateNFC, http.StatusInternalServerError, 1},
+		{"create invalid water type", http.MethodPost, "/bottles", invalidWaterType, http.StatusInternalServerError, 1},
+		{"create malformed body", http.MethodPost, "/bottles", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/bottles", map[string]interface{}{"id": 1, "title": "Umbenannt"}, http.StatusOK, 1},
+		{"update unknown id", http.MethodPut, "/bottles", map[string]interface{}{"id": 999, "title": "Fehlt"}, http.StatusBadRequest, 1},
+		{"delete invalid id", http.MethodDelete, "/bottles/abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/bottles/999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestBottlePayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	token := login(t, r, 4)
+
+	bottles := decodeResponse[[]database.Bottle](t, doRequest(t, r, http.MethodGet, "/bottles/users/4", nil))
+	if len(bottles) != 3 {
+		t.Errorf("expected 3 bottles of user 4, got %d", len(bottles))
+	}
+
+	bottle := decodeResponse[database.Bottle](t, doRequest(t, r, http.MethodGet, "/bottles/preferences/13:8E:BD:0C", nil))
+	if bottle.ID != 3 || bottle.WaterType != "mineral" || bottle.FillVolume != 250 {
+		t.Errorf("unexpected bottle for NFC ID: %+v", bottle)
+	}
+
+	image := decodeResponse[api.BottleImage](t, doRequest(t, r, http.MethodGet, "/bottles/image/1", nil))
+	if len(image.BottleImage) == 0 {
+		t.Error("expected bottle image bytes")
+	}
+
+	// A new base64 image replaces the stored one
+	pngFile := testPNG(t, 20, 20)
+	replaced := decodeResponse[database.Bottle](t, doAuthRequest(t, r, token, http.MethodPut, "/bottles",
+		map[string]interface{}{"id": 3, "bottle_image": base64.StdEncoding.EncodeToString(pngFile)}))
+	if replaced.ImageID == nil || replaced.BottleImage != nil {
+		t.Errorf("expected the bottle to reference the new image, got %+v", replaced)
+	}
+	image = decodeResponse[api.BottleImage](t, doRequest(t, r, http.MethodGet, "/bottles/image/3", nil))
+	if !bytes.Equal(image.BottleImage, pngFile) {
+		t.Errorf("expected the new bottle image, got %d bytes", len(image.BottleImage))
+	}
+	if w := doAuthRequest(t, r, token, http.MethodPut, "/bottles", map[string]interface{}{"id": 3, "bottle_image": "no image"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid image, got %d", w.Code)
+	}
+
+	// An empty NFC ID unassigns the chip while the other fields keep their values
+	updated := decodeResponse[database.Bottle](t, doAuthRequest(t, r, token, http.MethodPut, "/bottles", map[string]interface{}{"id": 3, "NFCID": "", "title": "Umbenannt"}))
+	if updated.NFCID != "" || updated.Title != "Umbenannt" || updated.FillVolume != 250 {
+		t.Errorf("unexpected updated bottle: %+v", updated)
+	}
+	if w := doRequest(t, r, http.MethodGet, "/bottles/preferences/13:8E:BD:0C", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected unassigned NFC ID to be unknown, got status %d", w.Code)
+	}
+
+	// Bottles with water transactions are referenced, so delete a new one
+	created := decodeResponse[database.Bottle](t, doAuthRequest(t, r, token, http.MethodPost, "/bottles",
+		map[string]interface{}{"user_id": 1, "NFCID": "AA:BB:CC:DD", "fill_volume": 500, "water_type": "tap", "title": "Neu"}))
+	if w := doAuthRequest(t, r, token, http.MethodDelete, fmt.Sprintf("/bottles/%d", created.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("expected bottle to be deleted, got status %d: %s", w.Code, w.Body.String())
+	}
+	if w := doRequest(t, r, http.MethodGet, fmt.Sprintf("/bottles/%d", created.ID), nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected deleted bottle to be gone, got status %d", w.Code)
+	}
+}
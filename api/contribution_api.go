@@ -49,7 +49,7 @@ func calculateSavings(volume int) (float64, float64) {
 // @Param id path int true "User ID"
 // @Success 200 {object} ContributionUserResponse
 // @Router /contribution/user/{id} [get]
-func GetContributionByUser(c *gin.Context) {
+func (s *Server) GetContributionByUser(c *gin.Context) {
 	userIdStr := c.Param("id")
 	userId, err := strconv.Atoi(userIdStr)
 	if err != nil {
@@ -57,11 +57,12 @@ func GetContributionByUser(c *gin.Context) {
 		return
 	}
 
-	var totalVolume int64
-	var totalFillings int64
-
-	db.Model(&database.WaterTransaction{}).Where("user_id = ?", userId).Count(&totalFillings)
-	db.Model(&database.WaterTransaction{}).Where("user_id = ?", userId).Select("sum(volume)").Row().Scan(&totalVolume)
+	id := uint(userId)
+	totalFillings, totalVolume, err := s.transactions.Totals(c.Request.Context(), &id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	savedMoney, savedTrash := calculateSavings(int(totalVolume))
 
@@ -82,14 +83,17 @@ func GetContributionByUser(c *gin.Context) {
 // @Produce json
 // @Success 200 {object} ContributionCommunityResponse
 // @Router /contribution/community [get]
-func GetContributionCommunity(c *gin.Context) {
-	var totalVolume int64
-	var totalFillings int64
-	var totalUsers int64
-
-	db.Model(&database.WaterTransaction{}).Count(&totalFillings)
-	db.Model(&database.WaterTransaction{}).Select("sum(volume)").Row().Scan(&totalVolume)
-	db.Model(&database.User{}).Count(&totalUsers)
+func (s *Server) GetContributionCommunity(c *gin.Context) {
+	totalFillings, totalVolume, err := s.transactions.Totals(c.Request.Context(), nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	totalUsers, err := s.users.Count(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	savedMoney, savedTrash := calculateSavings(int(totalVolume))
 
@@ -111,16 +115,13 @@ func GetContributionCommunity(c *gin.Context) {
 // @Produce json
 // @Success 200 {object} ContributionKLResponse
 // @Router /contribution/kl [get]
-func GetContributionKL(c *gin.Context) {
-	var smartStations int64
-	var manualStations int64
-
-	err := db.Model(&database.RefillStation{}).Where("type = ?", database.StationTypes[0]).Count(&manualStations).Error
+func (s *Server) GetContributionKL(c *gin.Context) {
+	manualStations, err := s.stations.CountByType(c.Request.Context(), database.StationTypes[0])
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err})
 		return
 	}
-	err = db.Model(&database.RefillStation{}).Where("type = ?", database.StationTypes[1]).Count(&smartStations).Error
+	smartStations, err := s.stations.CountByType(c.Request.Context(), database.StationTypes[1])
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err})
 		return
@@ -0,0 +1,42 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+)
+
+func TestContributionRoutes(t *testing.T) {
+	runRouteCases(t, []routeCase{
+		{"user", http.MethodGet, "/contribution/user/1", nil, http.StatusOK, 0},
+		{"user invalid id", http.MethodGet, "/contribution/user/abc", nil, http.StatusBadRequest, 0},
+		{"community", http.MethodGet, "/contribution/community", nil, http.StatusOK, 0},
+		{"kl", http.MethodGet, "/contribution/kl", nil, http.StatusOK, 0},
+	})
+}
+
+func TestContributionPayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	user := decodeResponse[api.ContributionUserResponse](t, doRequest(t, r, http.MethodGet, "/contribution/user/1", nil))
+	if user.AmountFillings != 13 || user.AmountWater != 13700 {
+		t.Errorf("unexpected contribution of user 1: %+v", user)
+	}
+
+	// A user without transactions has no contribution
+	user = decodeResponse[api.ContributionUserResponse](t, doRequest(t, r, http.MethodGet, "/contribution/user/100", nil))
+	if user != (api.ContributionUserResponse{}) {
+		t.Errorf("expected empty contribution, got %+v", user)
+	}
+
+	community := decodeResponse[api.ContributionCommunityResponse](t, doRequest(t, r, http.MethodGet, "/contribution/community", nil))
+	if community.AmountFillings != 236 || community.AmountWater != 236450 || community.AmountUser != 101 {
+		t.Errorf("unexpected community contribution: %+v", community)
+	}
+
+	kl := decodeResponse[api.ContributionKLResponse](t, doRequest(t, r, http.MethodGet, "/contribution/kl", nil))
+	if kl.AmountRefillStationSmart != 10 || kl.AmountRefillStationManual != 2 {
+		t.Errorf("unexpected station counts: %+v", kl)
+	}
+}
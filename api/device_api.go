@@ -0,0 +1,263 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// deviceKeyGracePeriod is how long the previous keys of a device keep working after a rotation
+const deviceKeyGracePeriod = 24 * time.Hour
+
+type DeviceRegistration struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// DeviceCredentials is a device with a new API key, which is only shown once
+type DeviceCredentials struct {
+	Device database.Device    `json:"device"`
+	Key    database.DeviceKey `json:"key"`
+	APIKey string             `json:"api_key"`
+}
+
+// @Summary Register a device
+// @Description Register the controller of a smart refill station and create its first API key.
+// @Description The key is only returned once, the device sends it in the X-API-Key header.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param device body DeviceRegistration true "Device"
+// @Success 201 {object} DeviceCredentials
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /refill_stations/{id}/devices [post]
+func (s *Server) RegisterDevice(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	var registration DeviceRegistration
+	if err := c.ShouldBindJSON(&registration); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if station.Type != "smart" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only smart stations have devices"})
+		return
+	}
+
+	apiKey, key, err := newDeviceKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	device := database.Device{StationID: station.ID, Name: registration.Name}
+	if err := s.devices.Create(c.Request.Context(), &device, key); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, DeviceCredentials{Device: device, Key: *key, APIKey: apiKey})
+}
+
+// @Summary List the devices of a refill station
+// @Description Get all devices registered for the refill station, including revoked ones
+// @Tags Devices
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Success 200 {array} database.Device
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/devices [get]
+func (s *Server) GetRefillStationDevices(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	devices, err := s.devices.ListByStation(c.Request.Context(), station.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// @Summary Revoke a device
+// @Description Revoke the device, none of its API keys work anymore
+// @Tags Devices
+// @Param id path int true "Device ID"
+// @Success 204
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /devices/{id} [delete]
+func (s *Server) RevokeDevice(c *gin.Context) {
+	device, ok := s.loadOperatedDevice(c)
+	if !ok {
+		return
+	}
+	if err := s.devices.Revoke(c.Request.Context(), device.ID, time.Now()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// @Summary List the API keys of a device
+// @Description Get all API keys of the device, the keys themselves are never shown again
+// @Tags Devices
+// @Produce json
+// @Param id path int true "Device ID"
+// @Success 200 {array} database.DeviceKey
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /devices/{id}/keys [get]
+func (s *Server) GetDeviceKeys(c *gin.Context) {
+	device, ok := s.loadOperatedDevice(c)
+	if !ok {
+		return
+	}
+	keys, err := s.devices.ListKeys(c.Request.Context(), device.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, keys)
+}
+
+// @Summary Rotate the API key of a device
+// @Description Create a new API key for the device. The previous keys keep working for 24 hours,
+// @Description so the device can switch over, unless they are revoked.
+// @Tags Devices
+// @Produce json
+// @Param id path int true "Device ID"
+// @Success 201 {object} DeviceCredentials
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /devices/{id}/keys [post]
+func (s *Server) RotateDeviceKey(c *gin.Context) {
+	device, ok := s.loadOperatedDevice(c)
+	if !ok {
+		return
+	}
+	if device.RevokedAt != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Device is revoked"})
+		return
+	}
+
+	apiKey, key, err := newDeviceKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	key.DeviceID = device.ID
+	if err := s.devices.RotateKey(c.Request.Context(), key, time.Now().Add(deviceKeyGracePeriod)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, DeviceCredentials{Device: *device, Key: *key, APIKey: apiKey})
+}
+
+// @Summary Revoke an API key of a device
+// @Description Revoke the API key immediately, e.g. after it leaked
+// @Tags Devices
+// @Param id path int true "Device ID"
+// @Param keyId path int true "Device Key ID"
+// @Success 204
+// @Security BearerAuth
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /devices/{id}/keys/{keyId} [delete]
+func (s *Server) RevokeDeviceKey(c *gin.Context) {
+	device, ok := s.loadOperatedDevice(c)
+	if !ok {
+		return
+	}
+	keyID, err := strconv.Atoi(c.Param("keyId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid key ID"})
+		return
+	}
+	err = s.devices.RevokeKey(c.Request.Context(), device.ID, uint(keyID), time.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device key with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// loadOperatedStation loads the station of the ID parameter and checks that the current
+// user operates it, otherwise it responds with an error and returns false
+func (s *Server) loadOperatedStation(c *gin.Context) (*database.RefillStation, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return nil, false
+	}
+	return s.loadStationOperatedBy(c, uint(id))
+}
+
+// loadOperatedDevice loads the device of the ID parameter and checks that the current
+// user operates its station, otherwise it responds with an error and returns false
+func (s *Server) loadOperatedDevice(c *gin.Context) (*database.Device, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return nil, false
+	}
+	device, err := s.devices.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Device with ID not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if _, ok := s.loadStationOperatedBy(c, device.StationID); !ok {
+		return nil, false
+	}
+	return device, true
+}
+
+func (s *Server) loadStationOperatedBy(c *gin.Context, stationID uint) (*database.RefillStation, bool) {
+	station, err := s.stations.Get(c.Request.Context(), stationID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !canOperate(c, station) {
+		respondForbidden(c)
+		return nil, false
+	}
+	return station, true
+}
+
+// newDeviceKey creates an API key and the key record to store for it
+func newDeviceKey() (string, *database.DeviceKey, error) {
+	apiKey, hash, err := auth.NewAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	return apiKey, &database.DeviceKey{KeyHash: hash, Prefix: apiKey[:12]}, nil
+}
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestDeviceRoutes(t *testing.T) {
+	device := map[string]string{"name": "controller"}
+
+	runRouteCases(t, []routeCase{
+		{"register anonymously", http.MethodPost, "/refill_stations/1/devices", device, http.StatusUnauthorized, 0},
+		{"register as user", http.MethodPost, "/refill_stations/1/devices", device, http.StatusForbidden, 4},
+		{"register for operated station", http.MethodPost, "/refill_stations/1/devices", device, http.StatusCreated, operatorID},
+		{"register for other station", http.MethodPost, "/refill_stations/3/devices", device, http.StatusForbidden, operatorID},
+		{"register for manual station", http.MethodPost, "/refill_stations/2/devices", device, http.StatusConflict, adminID},
+		{"register for unknown station", http.MethodPost, "/refill_stations/999/devices", device, http.StatusNotFound, adminID},
+		{"register without name", http.MethodPost, "/refill_stations/1/devices", map[string]string{}, http.StatusBadRequest, adminID},
+		{"list", http.MethodGet, "/refill_stations/1/devices", nil, http.StatusOK, operatorID},
+		{"list other station", http.MethodGet, "/refill_stations/3/devices", nil, http.StatusForbidden, operatorID},
+		{"revoke unknown device", http.MethodDelete, "/devices/999", nil, http.StatusNotFound, adminID},
+		{"rotate unknown device", http.MethodPost, "/devices/999/keys", nil, http.StatusNotFound, adminID},
+	})
+}
+
+func TestDeviceKeys(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+	transaction := map[string]interface{}{"volume": 500, "water_type": "tap", "guest": true}
+
+	w := doAuthRequest(t, r, operator, http.MethodPost, "/refill_stations/1/devices", map[string]string{"name": "controller"})
+	credentials := decodeResponse[api.DeviceCredentials](t, w)
+	if credentials.Device.StationID != 1 || credentials.Key.DeviceID != credentials.Device.ID || credentials.APIKey[:12] != credentials.Key.Prefix {
+		t.Fatalf("unexpected credentials %+v", credentials)
+	}
+	devices := decodeResponse[[]database.Device](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/devices", nil))
+	if len(devices) != 1 || devices[0].ID != credentials.Device.ID {
+		t.Errorf("expected the registered device, got %+v", devices)
+	}
+	if w := doDeviceRequest(t, r, credentials.APIKey, http.MethodPost, "/water_transactions", transaction); w.Code != http.StatusCreated {
+		t.Fatalf("expected the key to work, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Both keys work during the grace period of a rotation
+	keysPath := fmt.Sprintf("/devices/%d/keys", credentials.Device.ID)
+	rotated := decodeResponse[api.DeviceCredentials](t, doAuthRequest(t, r, operator, http.MethodPost, keysPath, nil))
+	if rotated.APIKey == credentials.APIKey || rotated.Key.DeviceID != credentials.Device.ID {
+		t.Fatalf("expected a new key, got %+v", rotated)
+	}
+	for _, apiKey := range []string{credentials.APIKey, rotated.APIKey} {
+		if w := doDeviceRequest(t, r, apiKey, http.MethodPost, "/water_transactions", transaction); w.Code != http.StatusCreated {
+			t.Errorf("expected both keys to work, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	keys := decodeResponse[[]database.DeviceKey](t, doAuthRequest(t, r, operator, http.MethodGet, keysPath, nil))
+	if len(keys) != 2 || keys[0].ExpiresAt == nil || keys[1].ExpiresAt != nil {
+		t.Errorf("expected the old key to expire, got %+v", keys)
+	}
+
+	// Revoking a key stops it immediately
+	if w := doAuthRequest(t, r, operator, http.MethodDelete, fmt.Sprintf("%s/%d", keysPath, credentials.Key.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("failed to revoke the key: %d %s", w.Code, w.Body.String())
+	}
+	if w := doDeviceRequest(t, r, credentials.APIKey, http.MethodPost, "/water_transactions", transaction); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the revoked key to be rejected, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, operator, http.MethodDelete, fmt.Sprintf("%s/%d", keysPath, 999), nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected an unknown key to be not found, got %d", w.Code)
+	}
+
+	// Revoking the device stops all its keys
+	if w := doAuthRequest(t, r, operator, http.MethodDelete, fmt.Sprintf("/devices/%d", credentials.Device.ID), nil); w.Code != http.StatusNoContent {
+		t.Fatalf("failed to revoke the device: %d %s", w.Code, w.Body.String())
+	}
+	if w := doDeviceRequest(t, r, rotated.APIKey, http.MethodPost, "/water_transactions", transaction); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected the key of the revoked device to be rejected, got %d", w.Code)
+	}
+	if w := doAuthRequest(t, r, operator, http.MethodPost, keysPath, nil); w.Code != http.StatusConflict {
+		t.Errorf("expected rotating a revoked device to conflict, got %d", w.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// dispenseTTL is how long a station may take to fill the bottle and confirm the dispense
+const dispenseTTL = 5 * time.Minute
+
+type DispenseRequest struct {
+	NFCID string `json:"nfc_id" binding:"required"`
+}
+
+type DispenseConfirmation struct {
+	// Volume is the dispensed volume in milliliters, at most the authorized one
+	Volume int `json:"volume" binding:"required,min=1"`
+}
+
+// @Summary Authorize a dispense
+// @Description A smart station sends the NFC ID of a scanned bottle and gets the volume, the water type
+// @Description and the owner to fill it for. The water type is the one of the bottle if the station offers it,
+// @Description otherwise the one of the station. The dispense has to be confirmed within 5 minutes.
+// @Description Manual or inactive stations and inactive bottles are rejected with 409.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param request body DispenseRequest true "Scanned NFC ID"
+// @Success 201 {object} database.Dispense
+// @Security DeviceKey
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /refill_stations/{id}/dispenses [post]
+func (s *Server) AuthorizeDispense(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var request DispenseRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !station.IsSmart() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only smart stations dispense"})
+		return
+	}
+	if station.Active.Valid && !station.Active.Bool {
+		c.JSON(http.StatusConflict, gin.H{"error": "Refill Station is inactive"})
+		return
+	}
+
+	bottle, err := s.bottles.GetByNFCID(c.Request.Context(), request.NFCID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Row not found for NFC ID"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !bottle.Active {
+		c.JSON(http.StatusConflict, gin.H{"error": "Bottle is inactive"})
+		return
+	}
+
+	now := time.Now()
+	dispense := database.Dispense{
+		StationID: station.ID,
+		BottleID:  bottle.ID,
+		UserID:    bottle.UserID,
+		Volume:    bottle.FillVolume,
+		WaterType: station.DispensedWaterType(bottle.WaterType),
+		ExpiresAt: now.Add(dispenseTTL),
+		CreatedAt: now,
+	}
+	if err := s.dispenses.Create(c.Request.Context(), &dispense); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, dispense)
+}
+
+// @Summary Confirm a dispense
+// @Description A smart station reports the volume it dispensed, which creates the water transaction of the dispense.
+// @Description Every dispense is confirmed once, expired dispenses are rejected with 410.
+// @Tags Devices
+// @Accept json
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param dispenseId path int true "Dispense ID"
+// @Param confirmation body DispenseConfirmation true "Dispensed volume"
+// @Success 201 {object} database.WaterTransaction
+// @Security DeviceKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 410 {object} map[string]string
+// @Router /refill_stations/{id}/dispenses/{dispenseId}/confirm [post]
+func (s *Server) ConfirmDispense(c *gin.Context) {
+	stationID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	dispenseID, err := strconv.Atoi(c.Param("dispenseId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dispense ID"})
+		return
+	}
+	var confirmation DispenseConfirmation
+	if err := c.ShouldBindJSON(&confirmation); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dispense, err := s.dispenses.Get(c.Request.Context(), uint(dispenseID))
+	if err == nil && dispense.StationID != uint(stationID) {
+		err = repository.ErrNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Dispense with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if confirmation.Volume > dispense.Volume {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "volume exceeds the authorized volume of " + strconv.Itoa(dispense.Volume)})
+		return
+	}
+
+	bottleID, userID := dispense.BottleID, dispense.UserID
+	transaction := database.WaterTransaction{
+		StationID: dispense.StationID,
+		BottleID:  &bottleID,
+		UserID:    &userID,
+		Volume:    confirmation.Volume,
+		WaterType: dispense.WaterType,
+		Timestamp: time.Now(),
+	}
+	_, err = s.dispenses.Confirm(c.Request.Context(), dispense.ID, &transaction)
+	if errors.Is(err, repository.ErrDispenseClosed) {
+		if dispense.ConfirmedAt == nil && !transaction.Timestamp.Before(dispense.ExpiresAt) {
+			c.JSON(http.StatusGone, gin.H{"error": "Dispense expired"})
+			return
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": "Dispense is already confirmed"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, transaction)
+}
@@ -0,0 +1,151 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Seeded smart stations, bottle 1 of user 4 is a 250 ml tap water bottle, bottle 3 a mineral water bottle
+const (
+	smartStationBoth = 1
+	smartStationTap  = 3
+	tapBottleNFC     = "04:72:52:1A:94:11:90"
+	mineralBottleNFC = "13:8E:BD:0C"
+)
+
+func TestAuthorizeDispense(t *testing.T) {
+	r := newTestRouter(t)
+	bothKey := registerDevice(t, r, smartStationBoth)
+	tapKey := registerDevice(t, r, smartStationTap)
+
+	// Stations that stopped dispensing after their device was registered
+	const inactiveStation, manualStation = 4, 5
+	inactiveKey := registerDevice(t, r, inactiveStation)
+	manualKey := registerDevice(t, r, manualStation)
+	admin := login(t, r, adminID)
+	doAuthRequest(t, r, admin, http.MethodPut, "/refill_stations", map[string]interface{}{
+		"id": inactiveStation, "active": map[string]bool{"bool": false, "valid": true},
+	})
+	doAuthRequest(t, r, admin, http.MethodPut, "/refill_stations", map[string]interface{}{"id": manualStation, "type": "manual"})
+
+	dispense := decodeResponse[database.Dispense](t, doDeviceRequest(t, r, bothKey, http.MethodPost,
+		fmt.Sprintf("/refill_stations/%d/dispenses", smartStationBoth), map[string]string{"nfc_id": tapBottleNFC}))
+	if dispense.ID == 0 || dispense.BottleID != 1 || dispense.UserID != 4 || dispense.Volume != 250 || dispense.WaterType != "tap" {
+		t.Errorf("unexpected dispense %+v", dispense)
+	}
+	if dispense.ExpiresAt.Before(dispense.CreatedAt) || dispense.TransactionID != nil {
+		t.Errorf("expected an open dispense, got %+v", dispense)
+	}
+
+	// The station only offers tap water
+	clamped := decodeResponse[database.Dispense](t, doDeviceRequest(t, r, tapKey, http.MethodPost,
+		fmt.Sprintf("/refill_stations/%d/dispenses", smartStationTap), map[string]string{"nfc_id": mineralBottleNFC}))
+	if clamped.WaterType != "tap" || clamped.BottleID != 3 {
+		t.Errorf("expected tap water for the mineral water bottle, got %+v", clamped)
+	}
+
+	for name, tc := range map[string]struct {
+		key     string
+		station uint
+		body    interface{}
+		status  int
+	}{
+		"unknown nfc id":   {bothKey, smartStationBoth, map[string]string{"nfc_id": "FF:FF"}, http.StatusNotFound},
+		"missing nfc id":   {bothKey, smartStationBoth, map[string]string{}, http.StatusBadRequest},
+		"missing key":      {"", smartStationBoth, map[string]string{"nfc_id": tapBottleNFC}, http.StatusUnauthorized},
+		"unknown key":      {"pdk_unknown", smartStationBoth, map[string]string{"nfc_id": tapBottleNFC}, http.StatusUnauthorized},
+		"other station":    {tapKey, smartStationBoth, map[string]string{"nfc_id": tapBottleNFC}, http.StatusForbidden},
+		"manual station":   {manualKey, manualStation, map[string]string{"nfc_id": tapBottleNFC}, http.StatusConflict},
+		"inactive station": {inactiveKey, inactiveStation, map[string]string{"nfc_id": tapBottleNFC}, http.StatusConflict},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := doDeviceRequest(t, r, tc.key, http.MethodPost, fmt.Sprintf("/refill_stations/%d/dispenses", tc.station), tc.body)
+			if w.Code != tc.status {
+				t.Errorf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+// inactiveBottles reports every bottle as deactivated, which the API cannot do
+type inactiveBottles struct {
+	repository.BottleRepository
+}
+
+func (b inactiveBottles) GetByNFCID(ctx context.Context, nfcID string) (*database.Bottle, error) {
+	bottle, err := b.BottleRepository.GetByNFCID(ctx, nfcID)
+	if bottle != nil {
+		bottle.Active = false
+	}
+	return bottle, err
+}
+
+func TestAuthorizeDispenseChecks(t *testing.T) {
+	repos, images := seededRepositories(t)
+	r := gin.New()
+	api.NewServer(repos, images, testIssuer()).RegisterRoutes(r)
+	key := registerDevice(t, r, smartStationBoth)
+	path := fmt.Sprintf("/refill_stations/%d/dispenses", smartStationBoth)
+
+	// The type of a station is matched regardless of case
+	doAuthRequest(t, r, login(t, r, adminID), http.MethodPut, "/refill_stations", map[string]interface{}{"id": smartStationBoth, "type": "Smart"})
+	if w := doDeviceRequest(t, r, key, http.MethodPost, path, map[string]string{"nfc_id": tapBottleNFC}); w.Code != http.StatusCreated {
+		t.Errorf("expected a Smart station to dispense, got %d %s", w.Code, w.Body.String())
+	}
+
+	repos.Bottles = inactiveBottles{repos.Bottles}
+	r = gin.New()
+	api.NewServer(repos, images, testIssuer()).RegisterRoutes(r)
+	if w := doDeviceRequest(t, r, key, http.MethodPost, path, map[string]string{"nfc_id": tapBottleNFC}); w.Code != http.StatusConflict {
+		t.Errorf("expected an inactive bottle to be rejected, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestConfirmDispense(t *testing.T) {
+	r := newTestRouter(t)
+	bothKey := registerDevice(t, r, smartStationBoth)
+	tapKey := registerDevice(t, r, smartStationTap)
+	dispense := decodeResponse[database.Dispense](t, doDeviceRequest(t, r, bothKey, http.MethodPost,
+		fmt.Sprintf("/refill_stations/%d/dispenses", smartStationBoth), map[string]string{"nfc_id": tapBottleNFC}))
+	confirmPath := fmt.Sprintf("/refill_stations/%d/dispenses/%d/confirm", smartStationBoth, dispense.ID)
+
+	for name, tc := range map[string]struct {
+		key    string
+		path   string
+		body   interface{}
+		status int
+	}{
+		"more than authorized": {bothKey, confirmPath, map[string]int{"volume": 251}, http.StatusBadRequest},
+		"no volume":            {bothKey, confirmPath, map[string]int{"volume": 0}, http.StatusBadRequest},
+		"other device":         {tapKey, confirmPath, map[string]int{"volume": 100}, http.StatusForbidden},
+		"other station":        {tapKey, fmt.Sprintf("/refill_stations/%d/dispenses/%d/confirm", smartStationTap, dispense.ID), map[string]int{"volume": 100}, http.StatusNotFound},
+		"unknown dispense":     {bothKey, fmt.Sprintf("/refill_stations/%d/dispenses/999/confirm", smartStationBoth), map[string]int{"volume": 100}, http.StatusNotFound},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := doDeviceRequest(t, r, tc.key, http.MethodPost, tc.path, tc.body); w.Code != tc.status {
+				t.Errorf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	transaction := decodeResponse[database.WaterTransaction](t, doDeviceRequest(t, r, bothKey, http.MethodPost, confirmPath, map[string]int{"volume": 200}))
+	if transaction.ID == 0 || transaction.StationID != smartStationBoth || transaction.Volume != 200 || transaction.WaterType != "tap" ||
+		transaction.BottleID == nil || *transaction.BottleID != 1 || transaction.UserID == nil || *transaction.UserID != 4 {
+		t.Errorf("unexpected transaction %+v", transaction)
+	}
+	stored := listPage[database.WaterTransaction](t, r, "/water_transactions?sort=-id&limit=1")
+	if len(stored.Items) != 1 || stored.Items[0].ID != transaction.ID {
+		t.Errorf("expected the transaction to be stored, got %+v", stored.Items)
+	}
+
+	if w := doDeviceRequest(t, r, bothKey, http.MethodPost, confirmPath, map[string]int{"volume": 200}); w.Code != http.StatusConflict {
+		t.Errorf("expected a second confirmation to conflict, got %d", w.Code)
+	}
+}
@@ -0,0 +1,382 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/media"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// maxUploadSize limits multipart image uploads, leaving room for the form around the file
+const maxUploadSize = media.MaxImageSize + 1<<20
+
+// Cache lifetimes of image files. The file of an image ID never changes, while
+// bottles, stations and problems get new images and are revalidated by ETag.
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "public, no-cache"
+)
+
+// @Summary Get an image file
+// @Description Stream an image file, cacheable forever as the file of an ID never changes.
+// @Description size selects a downscaled variant, images smaller than the variant are returned as they are.
+// @Tags Images
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Image ID"
+// @Param size query string false "Variant, the original if omitted" Enums(thumb, marker)
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /images/{id} [get]
+func (s *Server) GetImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	imageID := uint(id)
+	s.serveImage(c, &imageID, immutableCacheControl)
+}
+
+// @Summary Get a bottle image file
+// @Description Stream the current image of a bottle, revalidate cached copies with If-None-Match.
+// @Tags Bottles
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Bottle ID"
+// @Param size query string false "Variant, the original if omitted" Enums(thumb, marker)
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Router /bottles/{id}/image [get]
+func (s *Server) GetBottleImageFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	bottle, err := s.bottles.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.serveImage(c, bottle.ImageID, revalidateCacheControl)
+}
+
+// @Summary Get a refill station image file
+// @Description Stream the current image of a refill station, revalidate cached copies with If-None-Match.
+// @Tags Refill Stations
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Refill Station ID"
+// @Param size query string false "Variant, the original if omitted" Enums(thumb, marker)
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/image [get]
+func (s *Server) GetRefillStationImageFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.serveImage(c, station.ImageID, revalidateCacheControl)
+}
+
+// @Summary Get a refill station problem image file
+// @Description Stream the current image of a problem, revalidate cached copies with If-None-Match.
+// @Tags Refill Station Problems
+// @Produce image/jpeg,image/png,image/gif,image/webp
+// @Param id path int true "Refill Station Problem ID"
+// @Param size query string false "Variant, the original if omitted" Enums(thumb, marker)
+// @Param If-None-Match header string false "ETag of a cached copy"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Router /refill_station_problems/{id}/image [get]
+func (s *Server) GetRefillStationProblemImageFile(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	problem, err := s.problems.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.serveImage(c, problem.ImageID, revalidateCacheControl)
+}
+
+// serveImage streams the image or the variant given by the size parameter. The checksum
+// of the file is its ETag, a matching If-None-Match is answered with 304 Not Modified.
+func (s *Server) serveImage(c *gin.Context, id *uint, cacheControl string) {
+	size := c.Query("size")
+	if size != "" && !slices.ContainsFunc(media.Variants, func(v media.Variant) bool { return v.Name == size }) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "size must be thumb or marker"})
+		return
+	}
+	if id == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No image"})
+		return
+	}
+	image, err := s.images.Variant(c.Request.Context(), *id, size)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	etag := `"` + image.Checksum + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	file, err := s.images.OpenFile(c.Request.Context(), image)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	defer file.Close()
+	c.DataFromReader(http.StatusOK, image.Size, image.ContentType, file, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag, compared weakly as RFC 9110 requires
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// @Summary Upload a bottle image
+// @Description Replace the image of a bottle by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,
+// @Description animations at most 200 frames and 40 megapixels in all.
+// @Description Metadata like EXIF is removed and thumbnails are generated.
+// @Tags Bottles
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Bottle ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} database.Bottle
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /bottles/{id}/image [post]
+func (s *Server) UploadBottleImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	bottle, err := s.bottles.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "bottle with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, bottle.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
+	}
+
+	image := s.saveUploadedImage(c)
+	if image == nil {
+		return
+	}
+	// Updates reset an empty NFC ID, so the stored one is passed along
+	updated, err := s.bottles.Update(c.Request.Context(), &database.Bottle{ID: bottle.ID, NFCID: bottle.NFCID, ImageID: &image.ID})
+	if err != nil {
+		s.deleteImage(c.Request.Context(), &image.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.deleteImage(c.Request.Context(), bottle.ImageID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Upload a refill station image
+// @Description Replace the image of a refill station by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,
+// @Description animations at most 200 frames and 40 megapixels in all.
+// @Description Metadata like EXIF is removed and thumbnails are generated.
+// @Tags Refill Stations
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} database.RefillStation
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /refill_stations/{id}/image [post]
+func (s *Server) UploadRefillStationImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !canOperate(c, station) {
+		respondForbidden(c)
+		return
+	}
+
+	image := s.saveUploadedImage(c)
+	if image == nil {
+		return
+	}
+	updated, err := s.stations.Update(c.Request.Context(), &database.RefillStation{ID: station.ID, ImageID: &image.ID})
+	if err != nil {
+		s.deleteImage(c.Request.Context(), &image.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.deleteImage(c.Request.Context(), station.ImageID)
+	updated.SetOpeningState(time.Now())
+	c.JSON(http.StatusOK, updated)
+}
+
+// @Summary Upload a refill station problem image
+// @Description Replace the image of a problem by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,
+// @Description animations at most 200 frames and 40 megapixels in all.
+// @Description Metadata like EXIF is removed and thumbnails are generated.
+// @Tags Refill Station Problems
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "Refill Station Problem ID"
+// @Param image formData file true "Image file"
+// @Success 200 {object} database.RefillStationProblem
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /refill_station_problems/{id}/image [post]
+func (s *Server) UploadRefillStationProblemImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	problem, ok := s.canOperateProblem(c, uint(id), http.StatusNotFound)
+	if !ok {
+		return
+	}
+
+	image := s.saveUploadedImage(c)
+	if image == nil {
+		return
+	}
+	updated, err := s.problems.Update(c.Request.Context(), &database.RefillStationProblem{ID: problem.ID, ImageID: &image.ID})
+	if err != nil {
+		s.deleteImage(c.Request.Context(), &image.ID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	s.deleteImage(c.Request.Context(), problem.ImageID)
+	c.JSON(http.StatusOK, updated)
+}
+
+// saveUploadedImage stores the file of the multipart field image, on failure
+// it responds with an error and returns nil
+func (s *Server) saveUploadedImage(c *gin.Context) *database.Image {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxUploadSize)
+	header, err := c.FormFile("image")
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": media.ErrImageTooLarge.Error()})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "multipart form with an image file is required"})
+		return nil
+	}
+	if header.Size > media.MaxImageSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": media.ErrImageTooLarge.Error()})
+		return nil
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	return s.saveImage(c, data)
+}
+
+// saveImage stores an uploaded image, on failure it responds with 400 for files
+// that are no acceptable image, 413 for too large ones or 500 and returns nil
+func (s *Server) saveImage(c *gin.Context, data []byte) *database.Image {
+	image, err := s.images.Save(c.Request.Context(), data)
+	if errors.Is(err, media.ErrImageTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return nil
+	}
+	if errors.Is(err, media.ErrNotAnImage) || errors.Is(err, media.ErrImageDimensions) || errors.Is(err, media.ErrTooManyFrames) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil
+	}
+	return image
+}
+
+// deleteImage removes an image that is no longer referenced. A failure only
+// leaves an orphaned file, so it is logged instead of failing the request.
+func (s *Server) deleteImage(ctx context.Context, id *uint) {
+	if id == nil {
+		return
+	}
+	if err := s.images.Delete(ctx, *id); err != nil {
+		slog.Warn("failed to delete image", "id", *id, "error", err)
+	}
+}
@@ -0,0 +1,197 @@
+package api_test
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/media"
+)
+
+// testPNG returns a gray PNG file of the given size
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := png.Encode(&out, image.NewGray(image.Rect(0, 0, width, height))); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// testGIF returns an animation of single pixel frames on a canvas of the given size
+func testGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	animation := &gif.GIF{Config: image.Config{Width: width, Height: height, ColorModel: color.Palette(palette.Plan9)}}
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9))
+		animation.Delay = append(animation.Delay, 10)
+	}
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, animation); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// doUpload posts the file as the multipart field image
+func doUpload(t *testing.T, r http.Handler, token, path string, file []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "upload.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(file)
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImageUploadRoutes(t *testing.T) {
+	file := testPNG(t, 400, 300)
+	cases := []struct {
+		name   string
+		path   string
+		file   []byte
+		user   uint
+		status int
+	}{
+		{"bottle", "/bottles/1/image", file, 4, http.StatusOK},
+		{"bottle without login", "/bottles/1/image", file, 0, http.StatusUnauthorized},
+		{"bottle of another user", "/bottles/1/image", file, 5, http.StatusForbidden},
+		{"bottle by admin", "/bottles/1/image", file, adminID, http.StatusOK},
+		{"unknown bottle", "/bottles/999/image", file, 4, http.StatusNotFound},
+		{"invalid bottle id", "/bottles/abc/image", file, 4, http.StatusBadRequest},
+		{"station by operator", "/refill_stations/1/image", file, operatorID, http.StatusOK},
+		{"station of another operator", "/refill_stations/2/image", file, operatorID, http.StatusForbidden},
+		{"station by user", "/refill_stations/1/image", file, 4, http.StatusForbidden},
+		{"unknown station", "/refill_stations/999/image", file, adminID, http.StatusNotFound},
+		{"problem by operator", "/refill_station_problems/1/image", file, operatorID, http.StatusOK},
+		{"unknown problem", "/refill_station_problems/999/image", file, adminID, http.StatusNotFound},
+		{"no image", "/bottles/1/image", []byte("plain text"), 4, http.StatusBadRequest},
+		{"too many pixels", "/bottles/1/image", testGIF(t, 2000, 2000, media.MaxImagePixels/(2000*2000)+1), 4, http.StatusBadRequest},
+		{"too many frames", "/bottles/1/image", testGIF(t, 1, 1, media.MaxGIFFrames+1), 4, http.StatusBadRequest},
+		{"too large", "/bottles/1/image", make([]byte, media.MaxImageSize+1), 4, http.StatusRequestEntityTooLarge},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t)
+			token := ""
+			if tc.user != 0 {
+				token = login(t, r, tc.user)
+			}
+			if w := doUpload(t, r, token, tc.path, tc.file); w.Code != tc.status {
+				t.Errorf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		r := newTestRouter(t)
+		w := doAuthRequest(t, r, login(t, r, 4), http.MethodPost, "/bottles/1/image", map[string]string{"image": "none"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 without a multipart form, got %d", w.Code)
+		}
+	})
+}
+
+func TestImageUploadReplacesImage(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 4)
+
+	before := decodeResponse[database.Bottle](t, doRequest(t, r, http.MethodGet, "/bottles/1", nil))
+	file := testPNG(t, 40, 30)
+	bottle := decodeResponse[database.Bottle](t, doUpload(t, r, token, "/bottles/1/image", file))
+	if bottle.ImageID == nil || before.ImageID == nil || *bottle.ImageID == *before.ImageID {
+		t.Fatalf("expected a new image, got %v after %v", bottle.ImageID, before.ImageID)
+	}
+	if bottle.NFCID != before.NFCID || bottle.Title != before.Title {
+		t.Errorf("expected the other fields to stay, got %+v", bottle)
+	}
+
+	image := decodeResponse[api.BottleImage](t, doRequest(t, r, http.MethodGet, fmt.Sprintf("/bottles/image/%d", bottle.ID), nil))
+	if !bytes.Equal(image.BottleImage, file) {
+		t.Errorf("expected the uploaded image, got %d bytes", len(image.BottleImage))
+	}
+}
+
+func TestServeImage(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 4)
+	file := testPNG(t, 400, 300)
+	bottle := decodeResponse[database.Bottle](t, doUpload(t, r, token, "/bottles/1/image", file))
+
+	w := doRequest(t, r, http.MethodGet, "/bottles/1/image", nil)
+	if w.Code != http.StatusOK || !bytes.Equal(w.Body.Bytes(), file) {
+		t.Fatalf("expected the uploaded file, got status %d and %d bytes", w.Code, w.Body.Len())
+	}
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("Content-Type") != "image/png" || etag == "" || w.Header().Get("Cache-Control") != "public, no-cache" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	t.Run("not modified", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{etag, `"other", W/` + etag, "*"} {
+			req := httptest.NewRequest(http.MethodGet, "/bottles/1/image", nil)
+			req.Header.Set("If-None-Match", ifNoneMatch)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+				t.Errorf("expected 304 for If-None-Match %s, got %d", ifNoneMatch, w.Code)
+			}
+		}
+	})
+
+	t.Run("by image id", func(t *testing.T) {
+		w := doRequest(t, r, http.MethodGet, fmt.Sprintf("/images/%d", *bottle.ImageID), nil)
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != etag || !strings.Contains(w.Header().Get("Cache-Control"), "immutable") {
+			t.Errorf("unexpected response %d with headers %v", w.Code, w.Header())
+		}
+	})
+
+	t.Run("thumbnail", func(t *testing.T) {
+		w := doRequest(t, r, http.MethodGet, "/bottles/1/image?size=thumb", nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/jpeg" || w.Header().Get("ETag") == etag {
+			t.Fatalf("expected a JPEG thumbnail, got %d with headers %v", w.Code, w.Header())
+		}
+		config, err := jpeg.DecodeConfig(w.Body)
+		if err != nil || config.Width != 320 || config.Height != 240 {
+			t.Errorf("expected a 320x240 thumbnail, got %+v, %v", config, err)
+		}
+	})
+
+	t.Run("status", func(t *testing.T) {
+		for path, status := range map[string]int{
+			"/bottles/1/image?size=huge":                   http.StatusBadRequest,
+			"/bottles/999/image":                           http.StatusNotFound,
+			"/images/abc":                                  http.StatusBadRequest,
+			"/images/99999":                                http.StatusNotFound,
+			"/refill_stations/1/image":                     http.StatusOK,
+			"/refill_station_problems/1/image?size=marker": http.StatusOK,
+		} {
+			if w := doRequest(t, r, http.MethodGet, path, nil); w.Code != status {
+				t.Errorf("expected %d for %s, got %d", status, path, w.Code)
+			}
+		}
+	})
+}
@@ -1,12 +1,14 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
 type StationLikeCounter struct {
@@ -15,20 +17,30 @@ type StationLikeCounter struct {
 }
 
 // @Summary Show all likes
-// @Description Get all likes
+// @Description Get a page of likes
 // @Tags Likes
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.Like
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, station_id, user_id" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.Like]
+// @Failure 400 {object} map[string]string
 // @Router /likes [get]
-func GetLikes(c *gin.Context) {
-	var likes []database.Like
-	result := db.Find(&likes)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func (s *Server) GetLikes(c *gin.Context) {
+	params, err := parseListParams[database.Like](c, "station_id", "user_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	likes, total, err := s.likes.List(c.Request.Context(), params.query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, likes)
+	respondList(c, likes, total, params)
 }
 
 // @Summary Return a like counter fo a given station id
@@ -39,7 +51,7 @@ func GetLikes(c *gin.Context) {
 // @Param refillstationId path int true "Refill Station ID"
 // @Success 200 {object} StationLikeCounter
 // @Router /likes/{refillstationId}/count [get]
-func GetLikesCounterForStation(c *gin.Context) {
+func (s *Server) GetLikesCounterForStation(c *gin.Context) {
 	refillstationIdStr := c.Param("refillstationId")
 
 	if refillstationIdStr == "" {
@@ -54,14 +66,16 @@ func GetLikesCounterForStation(c *gin.Context) {
 	}
 
 	// Check for if record exists
-	var tempStation database.RefillStation
-	if result := db.First(&tempStation, refillstationId); result.Error != nil {
+	if _, err := s.stations.Get(c.Request.Context(), uint(refillstationId)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
 		return
 	}
 
-	var likeCounter int64
-	db.Model(&database.Like{}).Where("station_id = ?", refillstationId).Count(&likeCounter)
+	likeCounter, err := s.likes.CountByStation(c.Request.Context(), uint(refillstationId))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	response := StationLikeCounter{
 		StationID:   refillstationId,
@@ -80,7 +94,7 @@ func GetLikesCounterForStation(c *gin.Context) {
 // @Param userId path int true "User ID"
 // @Success 200 {object} map[string]bool
 // @Router /likes/{refillstationId}/{usedId} [get]
-func GetLikeByUserIdAndStationID(c *gin.Context) {
+func (s *Server) GetLikeByUserIdAndStationID(c *gin.Context) {
 	refillstationIdStr := c.Param("refillstationId")
 	userIdStr := c.Param("userId")
 
@@ -102,27 +116,24 @@ func GetLikeByUserIdAndStationID(c *gin.Context) {
 	}
 
 	// Check for if record exists
-	var tempStation database.RefillStation
-	if result := db.First(&tempStation, refillstationId); result.Error != nil {
+	if _, err := s.stations.Get(c.Request.Context(), uint(refillstationId)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
 		return
 	}
 
 	// Check for if record exists
-	var tempUser database.User
-	if result := db.First(&tempUser, userId); result.Error != nil {
+	if _, err := s.users.Get(c.Request.Context(), uint(userId)); err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User with ID not found"})
 		return
 	}
 
-	var like database.Like
-	result := db.Where("station_id = ? AND user_id = ?", refillstationId, userId).First(&like)
-	if result.Error != nil && result.Error != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	_, err = s.likes.GetByUserAndStation(c.Request.Context(), uint(userId), uint(refillstationId))
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	isLiked := result.RowsAffected > 0
+	isLiked := err == nil
 	c.JSON(http.StatusOK, gin.H{"isLiked": isLiked})
 }
 
@@ -133,16 +144,18 @@ func GetLikeByUserIdAndStationID(c *gin.Context) {
 // @Produce json
 // @Param like body database.Like true "Like"
 // @Success 201 {object} database.Like
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
 // @Router /likes [post]
-func CreateLike(c *gin.Context) {
+func (s *Server) CreateLike(c *gin.Context) {
 	var like database.Like
 	if err := c.ShouldBindJSON(&like); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result := db.Create(&like)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	like.UserID = CurrentUser(c).ID
+	if err := s.likes.Create(c.Request.Context(), &like); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, like)
@@ -155,19 +168,40 @@ func CreateLike(c *gin.Context) {
 // @Produce json
 // @Param like body database.Like true "Like"
 // @Success 200 {object} database.Like
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /likes [put]
-func UpdateLike(c *gin.Context) {
+func (s *Server) UpdateLike(c *gin.Context) {
 	var requestLike database.Like
 	if err := c.ShouldBindJSON(&requestLike); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var helpLike database.Like
-	if result := db.First(&helpLike, requestLike.ID); result.Error != nil {
+	stored, err := s.likes.Get(c.Request.Context(), requestLike.ID)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Like with ID not found"})
 		return
 	}
-	db.Model(&helpLike).Updates(requestLike)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, stored.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
+	}
+
+	// The like stays with its user
+	requestLike.UserID = 0
+	if _, err := s.likes.Update(c.Request.Context(), &requestLike); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Like with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, requestLike)
 }
@@ -179,24 +213,24 @@ func UpdateLike(c *gin.Context) {
 // @Produce json
 // @Param like body database.Like true "Like"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
 // @Router /likes [delete]
-func DeleteLike(c *gin.Context) {
+func (s *Server) DeleteLike(c *gin.Context) {
 	var requestLike database.Like
 	if err := c.ShouldBindJSON(&requestLike); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var like database.Like
-	result := db.Where("station_id = ? AND user_id = ?", requestLike.StationID, requestLike.UserID).First(&like)
-	if result.Error != nil {
+	like, err := s.likes.GetByUserAndStation(c.Request.Context(), CurrentUser(c).ID, requestLike.StationID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Like with given ids not existant"})
 		return
 	}
 
-	result = db.Delete(&database.Like{}, like.ID)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.likes.Delete(c.Request.Context(), like.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -0,0 +1,65 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestLikeRoutes(t *testing.T) {
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/likes", nil, http.StatusOK, 0},
+		{"count", http.MethodGet, "/likes/1/count", nil, http.StatusOK, 0},
+		{"count invalid station id", http.MethodGet, "/likes/abc/count", nil, http.StatusBadRequest, 0},
+		{"count unknown station", http.MethodGet, "/likes/999/count", nil, http.StatusNotFound, 0},
+		{"by station and user", http.MethodGet, "/likes/1/1", nil, http.StatusOK, 0},
+		{"by invalid station id", http.MethodGet, "/likes/abc/1", nil, http.StatusBadRequest, 0},
+		{"by invalid user id", http.MethodGet, "/likes/1/abc", nil, http.StatusBadRequest, 0},
+		{"by unknown station", http.MethodGet, "/likes/999/1", nil, http.StatusNotFound, 0},
+		{"by unknown user", http.MethodGet, "/likes/1/999", nil, http.StatusNotFound, 0},
+		{"create", http.MethodPost, "/likes", map[string]interface{}{"station_id": 3, "user_id": 1}, http.StatusCreated, 1},
+		{"create duplicate", http.MethodPost, "/likes", map[string]interface{}{"station_id": 1, "user_id": 1}, http.StatusInternalServerError, 1},
+		{"create malformed body", http.MethodPost, "/likes", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/likes", map[string]interface{}{"id": 1, "station_id": 3}, http.StatusOK, 1},
+		{"update unknown id", http.MethodPut, "/likes", map[string]interface{}{"id": 999, "station_id": 3}, http.StatusNotFound, 1},
+		{"delete", http.MethodDelete, "/likes", map[string]interface{}{"station_id": 1, "user_id": 1}, http.StatusNoContent, 1},
+		{"delete unknown like", http.MethodDelete, "/likes", map[string]interface{}{"station_id": 3, "user_id": 1}, http.StatusNotFound, 1},
+		{"delete malformed body", http.MethodDelete, "/likes", "{", http.StatusBadRequest, 1},
+	})
+}
+
+func TestLikePayloads(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 1)
+
+	counter := decodeResponse[api.StationLikeCounter](t, doRequest(t, r, http.MethodGet, "/likes/1/count", nil))
+	if counter.StationID != 1 || counter.LikeCounter != 4 {
+		t.Errorf("unexpected like counter: %+v", counter)
+	}
+
+	liked := decodeResponse[map[string]bool](t, doRequest(t, r, http.MethodGet, "/likes/1/1", nil))
+	if !liked["isLiked"] {
+		t.Error("expected user 1 to like station 1")
+	}
+	liked = decodeResponse[map[string]bool](t, doRequest(t, r, http.MethodGet, "/likes/3/1", nil))
+	if liked["isLiked"] {
+		t.Error("expected user 1 not to like station 3")
+	}
+
+	// A duplicate like is rejected and does not change the counter
+	if w := doAuthRequest(t, r, token, http.MethodPost, "/likes", map[string]interface{}{"station_id": 1, "user_id": 1}); w.Code == http.StatusCreated {
+		t.Fatal("expected duplicate like to be rejected")
+	}
+	counter = decodeResponse[api.StationLikeCounter](t, doRequest(t, r, http.MethodGet, "/likes/1/count", nil))
+	if counter.LikeCounter != 4 {
+		t.Errorf("expected 4 likes after duplicate, got %d", counter.LikeCounter)
+	}
+
+	doAuthRequest(t, r, token, http.MethodDelete, "/likes", database.Like{StationID: 1, UserID: 1})
+	liked = decodeResponse[map[string]bool](t, doRequest(t, r, http.MethodGet, "/likes/1/1", nil))
+	if liked["isLiked"] {
+		t.Error("expected like to be removed")
+	}
+}
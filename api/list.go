@@ -0,0 +1,188 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Page sizes of the list routes
+const (
+	defaultListLimit = 50
+	maxListLimit     = 200
+)
+
+// ListPage is the envelope of every list route. Total counts all items, NextCursor
+// continues after the last item in the same order and is left out on the last page.
+type ListPage[T any] struct {
+	Items      []T    `json:"items"`
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// listParams are the paging, sorting and field selection parameters of a list route
+type listParams struct {
+	query  repository.ListQuery
+	sort   string
+	limit  int
+	fields []string
+}
+
+// listCursor is a repository cursor bound to the sort order it was created for
+type listCursor struct {
+	Sort string `json:"s"`
+	repository.Cursor
+}
+
+// parseListParams reads limit, offset, cursor, sort and fields. Items can be sorted by
+// the ID and the sortable fields, descending with a leading minus, and reduced to any
+// of their JSON fields, the ID is always kept.
+func parseListParams[T any](c *gin.Context, sortable ...string) (listParams, error) {
+	var params listParams
+	var err error
+
+	params.limit, err = strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultListLimit)))
+	if err != nil || params.limit < 1 || params.limit > maxListLimit {
+		return params, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+	}
+	// One more item tells whether there is a next page
+	params.query.Limit = params.limit + 1
+
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		params.query.Offset, err = strconv.Atoi(offsetStr)
+		if err != nil || params.query.Offset < 0 {
+			return params, errors.New("offset must be a non-negative number")
+		}
+	}
+
+	params.sort = c.Query("sort")
+	column, desc := strings.CutPrefix(params.sort, "-")
+	if column != "" && column != "id" && !slices.Contains(sortable, column) {
+		return params, fmt.Errorf("sort must be one of %s, with a leading - for descending order", strings.Join(append([]string{"id"}, sortable...), ", "))
+	}
+	params.query.Sort, params.query.Desc = column, desc
+
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if params.query.Offset > 0 {
+			return params, errors.New("offset and cursor cannot be combined")
+		}
+		cursor, err := decodeListCursor(cursorStr)
+		if err != nil {
+			return params, err
+		}
+		if cursor.Sort != params.sort {
+			return params, errors.New("cursor belongs to another sort order")
+		}
+		params.query.After = &cursor.Cursor
+		if err := repository.CheckCursor[T](params.query); err != nil {
+			return params, errors.New("invalid cursor")
+		}
+	}
+
+	if fieldsStr := c.Query("fields"); fieldsStr != "" {
+		known := jsonFields(reflect.TypeFor[T]())
+		for _, field := range strings.Split(fieldsStr, ",") {
+			field = strings.TrimSpace(field)
+			if !slices.Contains(known, field) {
+				return params, fmt.Errorf("fields must be a comma separated list of %s", strings.Join(known, ", "))
+			}
+			params.fields = append(params.fields, field)
+		}
+	}
+	return params, nil
+}
+
+// respondList writes the items loaded with the list parameters as a ListPage
+func respondList[T any](c *gin.Context, items []T, total int64, params listParams) {
+	page := ListPage[any]{Items: []any{}, Total: total, Limit: params.limit, Offset: params.query.Offset}
+	if len(items) > params.limit {
+		items = items[:params.limit]
+		cursor, err := repository.CursorOf(&items[len(items)-1], params.query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		page.NextCursor = encodeListCursor(listCursor{Sort: params.sort, Cursor: *cursor})
+	}
+
+	for _, item := range items {
+		if len(params.fields) == 0 {
+			page.Items = append(page.Items, item)
+			continue
+		}
+		selected, err := selectFields(item, params.fields)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		page.Items = append(page.Items, selected)
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// selectFields reduces the JSON object of the item to the ID and the given fields
+func selectFields(item any, fields []string) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return nil, err
+	}
+	selected := map[string]json.RawMessage{"id": all["id"]}
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			selected[field] = value
+		}
+	}
+	return selected, nil
+}
+
+// jsonFields returns the JSON names of the exported fields of a struct type,
+// including those of embedded structs
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func encodeListCursor(cursor listCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(value string) (*listCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor listCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Cursor.Value == nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,128 @@
+package api_test
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestListRoutes(t *testing.T) {
+	otherSort := listPage[database.User](t, newTestRouter(t), "/users?limit=5&sort=-first_name").NextCursor
+
+	runRouteCases(t, []routeCase{
+		{"list limit too small", http.MethodGet, "/users?limit=0", nil, http.StatusBadRequest, 0},
+		{"list limit too large", http.MethodGet, "/users?limit=201", nil, http.StatusBadRequest, 0},
+		{"list negative offset", http.MethodGet, "/bottles?offset=-1", nil, http.StatusBadRequest, 0},
+		{"list unknown sort", http.MethodGet, "/likes?sort=password", nil, http.StatusBadRequest, 0},
+		{"list unknown field", http.MethodGet, "/refill_stations?fields=name,refill_station_image", nil, http.StatusBadRequest, 0},
+		{"list invalid cursor", http.MethodGet, "/users?cursor=nope", nil, http.StatusBadRequest, 0},
+		{"list cursor of other sort", http.MethodGet, "/users?cursor=" + otherSort, nil, http.StatusBadRequest, 0},
+		{"list cursor with offset", http.MethodGet, "/users?sort=-first_name&offset=5&cursor=" + otherSort, nil, http.StatusBadRequest, 0},
+		{"list sort descending", http.MethodGet, "/refill_station_reviews?sort=-timestamp", nil, http.StatusOK, 0},
+		{"list problems by status", http.MethodGet, "/refill_station_problems?sort=status&fields=status", nil, http.StatusOK, 0},
+	})
+}
+
+// listPage requests a list route and decodes the page
+func listPage[T any](t *testing.T, r http.Handler, path string) api.ListPage[T] {
+	t.Helper()
+	w := doRequest(t, r, http.MethodGet, path, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("%s: expected status 200, got %d: %s", path, w.Code, w.Body.String())
+	}
+	return decodeResponse[api.ListPage[T]](t, w)
+}
+
+func TestListPagination(t *testing.T) {
+	r := newTestRouter(t)
+
+	t.Run("cursor walks every item once", func(t *testing.T) {
+		var ids []uint
+		path := "/users?limit=40"
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatal("expected the cursor to end after 3 pages")
+			}
+			page := listPage[database.User](t, r, path)
+			if page.Total != 101 || page.Limit != 40 {
+				t.Fatalf("expected total 101 and limit 40, got %d and %d", page.Total, page.Limit)
+			}
+			for _, user := range page.Items {
+				ids = append(ids, user.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			path = "/users?limit=40&cursor=" + page.NextCursor
+		}
+		if len(ids) != 101 || !slices.IsSorted(ids) || len(slices.Compact(slices.Clone(ids))) != 101 {
+			t.Errorf("expected users 1 to 101 in order, got %v", ids)
+		}
+	})
+
+	t.Run("offset", func(t *testing.T) {
+		page := listPage[database.User](t, r, "/users?limit=10&offset=95")
+		if len(page.Items) != 6 || page.Items[0].ID != 96 || page.Offset != 95 || page.NextCursor != "" {
+			t.Errorf("expected the last 6 users without a next cursor, got %d items from %+v", len(page.Items), page.Items[0])
+		}
+	})
+
+	t.Run("cursor on descending time", func(t *testing.T) {
+		var transactions []database.WaterTransaction
+		path := "/water_transactions?limit=100&sort=-timestamp"
+		for {
+			page := listPage[database.WaterTransaction](t, r, path)
+			transactions = append(transactions, page.Items...)
+			if page.NextCursor == "" {
+				break
+			}
+			path = "/water_transactions?limit=100&sort=-timestamp&cursor=" + url.QueryEscape(page.NextCursor)
+		}
+		if len(transactions) != 236 {
+			t.Fatalf("expected 236 transactions, got %d", len(transactions))
+		}
+		if !sort.SliceIsSorted(transactions, func(i, j int) bool { return transactions[i].Timestamp.After(transactions[j].Timestamp) }) {
+			t.Error("expected transactions newest first")
+		}
+		seen := map[uint]bool{}
+		for _, transaction := range transactions {
+			if seen[transaction.ID] {
+				t.Errorf("transaction %d listed twice", transaction.ID)
+			}
+			seen[transaction.ID] = true
+		}
+	})
+
+	t.Run("sort and fields", func(t *testing.T) {
+		page := listPage[map[string]any](t, r, "/refill_stations?sort=-name&fields=name&limit=3")
+		if page.Total != 12 || len(page.Items) != 3 {
+			t.Fatalf("expected 3 of 12 stations, got %d of %d", len(page.Items), page.Total)
+		}
+		var names []string
+		for _, item := range page.Items {
+			if len(item) != 2 || item["id"] == nil {
+				t.Errorf("expected only id and name, got %v", item)
+			}
+			names = append(names, item["name"].(string))
+		}
+		if want := []string{"Wochenmarkt", "Warenhaus Schatzkiste", "Tourist Information"}; !slices.Equal(names, want) {
+			t.Errorf("expected %v, got %v", want, names)
+		}
+	})
+
+	t.Run("filtered stations", func(t *testing.T) {
+		all := listPage[database.RefillStation](t, r, "/refill_stations?open_now=true")
+		page := listPage[database.RefillStation](t, r, "/refill_stations?open_now=true&limit=1&offset=1")
+		if page.Total != all.Total || int(all.Total) != len(all.Items) {
+			t.Errorf("expected the total of open stations %d, got %d", len(all.Items), page.Total)
+		}
+		if len(all.Items) > 1 && (len(page.Items) != 1 || page.Items[0].ID != all.Items[1].ID) {
+			t.Errorf("expected the second open station, got %+v", page.Items)
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package api_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/blob"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/media"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Seeded users log in with user<ID>@poseidon.test and testPassword
+const (
+	testPassword  = "correct horse battery staple"
+	testJWTSecret = "test-secret-with-at-least-32-bytes"
+)
+
+var (
+	testDataOnce     sync.Once
+	testData         *database.TestData
+	testDataErr      error
+	testPasswordHash string
+
+	// Decoding and scaling the images is slow, so they are saved once into the
+	// template store and copied into the store of every router
+	testImageStore    *blob.MemoryStore
+	testImageRepo     repository.ImageRepository
+	testBottleImages  []uint
+	testStationImages []uint
+	testProblemImages []uint
+)
+
+func TestMain(m *testing.M) {
+	// Image paths in the test data are relative to the repository root
+	if err := os.Chdir(".."); err != nil {
+		panic(err)
+	}
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// newTestRouter returns the API router on in-memory repositories seeded with testdata/*.json
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	repos, images := seededRepositories(t)
+	r := gin.New()
+	api.NewServer(repos, images, testIssuer()).RegisterRoutes(r)
+	return r
+}
+
+func testIssuer() *auth.TokenIssuer {
+	return auth.NewTokenIssuer(testJWTSecret, 15*time.Minute, time.Hour)
+}
+
+// seededRepositories creates repositories filled with a fresh copy of the test data and
+// the image storage holding its images. The repositories are in-memory unless
+// TEST_DATABASE_URL names a database, e.g. sqlite::memory:
+// The seeded user 1 is an admin and user 2 operates station 1, everyone else is a regular user
+var (
+	adminID           uint = 1
+	operatorID        uint = 2
+	operatedStationID uint = 1
+	seededRoles            = map[uint]string{adminID: database.RoleAdmin, operatorID: database.RoleOperator}
+)
+
+func seededRepositories(t *testing.T) (repository.Repositories, *media.Images) {
+	t.Helper()
+	testDataOnce.Do(func() {
+		testData, testDataErr = database.LoadTestData("testdata")
+		// The minimum cost keeps the many logins of the tests fast
+		hash, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.MinCost)
+		testPasswordHash = string(hash)
+		if testDataErr == nil {
+			testDataErr = saveTemplateImages()
+		}
+	})
+	if testDataErr != nil {
+		t.Fatalf("failed to load test data: %v", testDataErr)
+	}
+
+	repos := repository.NewMemoryRepositories()
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		repos = repository.NewGormRepositories(emptyDatabase(t, dsn))
+	}
+	store := blob.NewMemoryStore()
+	images := media.NewImages(store, repos.Images)
+
+	ctx := context.Background()
+	// seedImage copies the i-th of the template images and returns its ID
+	seedImage := func(templateIDs []uint, i int) *uint {
+		id, err := copyTestImage(ctx, templateIDs[i], repos.Images, store)
+		mustSeed(t, err)
+		return id
+	}
+	for i, user := range testData.Users {
+		email := testEmail(uint(i + 1))
+		user.Email = &email
+		user.PasswordHash = testPasswordHash
+		user.Role = seededRoles[uint(i+1)]
+		mustSeed(t, repos.Users.Create(ctx, &user))
+	}
+	for i, bottle := range testData.Bottles {
+		bottle.ImageID = seedImage(testBottleImages, i)
+		mustSeed(t, repos.Bottles.Create(ctx, &bottle))
+	}
+	for i, station := range testData.RefillStations {
+		if uint(i+1) == operatedStationID {
+			station.OwnerID = &operatorID
+		}
+		station.ImageID = seedImage(testStationImages, i)
+		mustSeed(t, repos.Stations.Create(ctx, &station))
+	}
+	for _, review := range testData.RefillStationReviews {
+		mustSeed(t, repos.Reviews.Create(ctx, &review))
+	}
+	for i, problem := range testData.RefillStationProblems {
+		problem.ImageID = seedImage(testProblemImages, i)
+		mustSeed(t, repos.Problems.Create(ctx, &problem))
+	}
+	for _, transaction := range testData.WaterTransactions {
+		mustSeed(t, repos.Transactions.Create(ctx, &transaction))
+	}
+	for _, like := range testData.Likes {
+		mustSeed(t, repos.Likes.Create(ctx, &like))
+	}
+	return repos, images
+}
+
+// saveTemplateImages saves the images of the test data into the template store
+func saveTemplateImages() error {
+	ctx := context.Background()
+	testImageStore = blob.NewMemoryStore()
+	testImageRepo = repository.NewMemoryRepositories().Images
+	images := media.NewImages(testImageStore, testImageRepo)
+	save := func(files [][]byte, ids *[]uint) error {
+		for _, file := range files {
+			image, err := images.Save(ctx, file)
+			if err != nil {
+				return err
+			}
+			*ids = append(*ids, image.ID)
+		}
+		return nil
+	}
+	return errors.Join(
+		save(testData.BottleImages, &testBottleImages),
+		save(testData.RefillStationImages, &testStationImages),
+		save(testData.RefillStationProblemImages, &testProblemImages),
+	)
+}
+
+// copyTestImage copies a template image including its variants into the given
+// repository and store and returns its new ID
+func copyTestImage(ctx context.Context, templateID uint, repo repository.ImageRepository, store blob.Store) (*uint, error) {
+	original, err := testImageRepo.Get(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	variants, err := testImageRepo.Variants(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	var id *uint
+	for _, image := range append([]database.Image{*original}, variants...) {
+		content, err := testImageStore.Get(ctx, image.Key)
+		if err != nil {
+			return nil, err
+		}
+		err = store.Put(ctx, image.Key, content, image.Size, image.ContentType)
+		content.Close()
+		if err != nil {
+			return nil, err
+		}
+		image.ID, image.OriginalID = 0, id
+		if err := repo.Create(ctx, &image); err != nil {
+			return nil, err
+		}
+		if id == nil {
+			id = &image.ID
+		}
+	}
+	return id, nil
+}
+
+// emptyDatabase connects to the database and migrates it from scratch
+func emptyDatabase(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	db, err := database.ConnectDatabase(dsn, nil)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("failed to load migrations: %v", err)
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatalf("failed to read migration status: %v", err)
+	}
+	if _, err := migrator.Down(len(status)); err != nil {
+		t.Fatalf("failed to reset test database: %v", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func mustSeed(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("failed to seed test data: %v", err)
+	}
+}
+
+func testEmail(userID uint) string {
+	return fmt.Sprintf("user%d@poseidon.test", userID)
+}
+
+// login logs in as a seeded user and returns the access token
+func login(t *testing.T, r http.Handler, userID uint) string {
+	t.Helper()
+	w := doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": testEmail(userID), "password": testPassword})
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to log in as user %d: %d %s", userID, w.Code, w.Body.String())
+	}
+	return decodeResponse[api.TokenResponse](t, w).AccessToken
+}
+
+// doRequest sends an anonymous request with an optional JSON body through the router
+func doRequest(t *testing.T, r http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return doAuthRequest(t, r, "", method, path, body)
+}
+
+// doAuthRequest sends a request with the access token unless it is empty
+func doAuthRequest(t *testing.T, r http.Handler, token, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := newJSONRequest(t, method, path, body)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// doDeviceRequest sends a request with the API key of a device
+func doDeviceRequest(t *testing.T, r http.Handler, apiKey, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	req := newJSONRequest(t, method, path, body)
+	req.Header.Set("X-API-Key", apiKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// registerDevice registers a device for the station as the admin and returns its API key
+func registerDevice(t *testing.T, r http.Handler, stationID uint) string {
+	t.Helper()
+	w := doAuthRequest(t, r, login(t, r, adminID), http.MethodPost, fmt.Sprintf("/refill_stations/%d/devices", stationID), map[string]string{"name": "controller"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to register a device for station %d: %d %s", stationID, w.Code, w.Body.String())
+	}
+	return decodeResponse[api.DeviceCredentials](t, w).APIKey
+}
+
+// newJSONRequest creates a request with an optional JSON body, strings are sent as they are
+func newJSONRequest(t *testing.T, method, path string, body interface{}) *http.Request {
+	t.Helper()
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		encoded, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("failed to encode request body: %v", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+// decodeResponse decodes the JSON response body into T
+func decodeResponse[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	if err := json.Unmarshal(w.Body.Bytes(), &value); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return value
+}
+
+// routeCase describes one request and the expected status code.
+// The request is sent as the given user or anonymously if user is zero.
+type routeCase struct {
+	name   string
+	method string
+	path   string
+	body   interface{}
+	status int
+	user   uint
+}
+
+// runRouteCases runs every case against a freshly seeded router
+func runRouteCases(t *testing.T, cases []routeCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestRouter(t)
+			token := ""
+			if tc.user != 0 {
+				token = login(t, r, tc.user)
+			}
+			w := doAuthRequest(t, r, token, tc.method, tc.path, tc.body)
+			if w.Code != tc.status {
+				t.Errorf("%s %s: expected status %d, got %d: %s", tc.method, tc.path, tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,10 +1,17 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
@@ -19,50 +26,231 @@ type StationImage struct {
 }
 
 // @Summary Show all refill stations
-// @Description Get all refill stations
+// @Description Get a page of refill stations with their opening state, optionally only those currently open or closed
 // @Tags Refill Stations
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.RefillStation
+// @Param open_now query bool false "Only stations that are open (true) or closed (false) now, stations without opening hours are left out"
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, name, type, address, latitude, longitude" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.RefillStation]
+// @Failure 400 {object} map[string]string
 // @Router /refill_stations [get]
-func GetRefillStations(c *gin.Context) {
-	var stations []database.RefillStation
-	result := db.Find(&stations)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func (s *Server) GetRefillStations(c *gin.Context) {
+	openNow, err := queryBool(c, "open_now")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, stations)
+	params, err := parseListParams[database.RefillStation](c, "name", "type", "address", "latitude", "longitude")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The opening state depends on the current time, so filtering by it pages in memory
+	query := params.query
+	if openNow != nil {
+		query = repository.ListQuery{}
+	}
+	stations, total, err := s.stations.List(c.Request.Context(), query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	filtered := []database.RefillStation{}
+	for _, station := range stations {
+		station.SetOpeningState(now)
+		if openNow == nil || (station.IsOpenNow != nil && *station.IsOpenNow == *openNow) {
+			filtered = append(filtered, station)
+		}
+	}
+	if openNow != nil {
+		filtered, total, err = repository.PageRows(filtered, params.query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	respondList(c, filtered, total, params)
 }
 
-// @Summary Get all refill station markers
-// @Description Get all refill station markers with specific attributes
+// Marker is a station on the map or, when Cluster is set, Count stations
+// around their mean position. Clusters have neither an ID nor a status.
+type Marker struct {
+	ID        uint               `json:"id,omitempty"`
+	Latitude  float64            `json:"latitude"`
+	Longitude float64            `json:"longitude"`
+	Status    *database.NullBool `json:"status,omitempty"`
+	Cluster   bool               `json:"cluster"`
+	Count     int                `json:"count"`
+}
+
+// Markers are clustered below maxClusterZoom when their positions share a grid cell of markerCellSize pixels
+const (
+	maxClusterZoom = 17
+	maxZoom        = 22
+	markerCellSize = 60
+)
+
+// @Summary Get refill station markers
+// @Description Get the markers of the stations in view. With a zoom level below 17, stations close to each other on the map are merged into clusters with their count.
 // @Tags Refill Stations
 // @Accept json
 // @Produce json
-// @Success 200 {array} map[string]interface{}
+// @Param bbox query string false "Visible area as minLon,minLat,maxLon,maxLat"
+// @Param zoom query int false "Map zoom level from 0 to 22, enables clustering"
+// @Success 200 {array} Marker
+// @Failure 400 {object} map[string]string
 // @Router /refill_stations/markers [get]
-func GetAllRefillstationMarker(c *gin.Context) {
-	var stations []database.RefillStation
-	result := db.Select("id, longitude, latitude, active").Find(&stations)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
-		return
+func (s *Server) GetAllRefillstationMarker(c *gin.Context) {
+	var box *geo.Box
+	if bboxStr := c.Query("bbox"); bboxStr != "" {
+		parsed, err := parseBoundingBox(bboxStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		box = &parsed
+	}
+	zoom := maxZoom
+	if zoomStr := c.Query("zoom"); zoomStr != "" {
+		var err error
+		zoom, err = strconv.Atoi(zoomStr)
+		if err != nil || zoom < 0 || zoom > maxZoom {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("zoom must be between 0 and %d", maxZoom)})
+			return
+		}
 	}
 
-	var markers []map[string]interface{}
-	for _, station := range stations {
-		markers = append(markers, map[string]interface{}{
-			"id":        station.ID,
-			"longitude": station.Longitude,
-			"latitude":  station.Latitude,
-			"status":    station.Active,
-		})
+	stations, err := s.stations.ListMarkers(c.Request.Context(), box)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
 	}
 
+	markers := []Marker{}
+	if zoom >= maxClusterZoom {
+		for _, station := range stations {
+			markers = append(markers, stationMarker(station))
+		}
+		c.JSON(http.StatusOK, markers)
+		return
+	}
+	position := func(station *database.RefillStation) (float64, float64) { return station.Latitude, station.Longitude }
+	for _, group := range geo.GridCluster(stations, position, zoom, markerCellSize) {
+		if len(group) == 1 {
+			markers = append(markers, stationMarker(group[0]))
+			continue
+		}
+		cluster := Marker{Cluster: true, Count: len(group)}
+		for _, station := range group {
+			cluster.Latitude += station.Latitude / float64(len(group))
+			cluster.Longitude += station.Longitude / float64(len(group))
+		}
+		markers = append(markers, cluster)
+	}
 	c.JSON(http.StatusOK, markers)
 }
 
+func stationMarker(station database.RefillStation) Marker {
+	return Marker{
+		ID:        station.ID,
+		Latitude:  station.Latitude,
+		Longitude: station.Longitude,
+		Status:    &station.Active,
+		Count:     1,
+	}
+}
+
+// parseBoundingBox parses minLon,minLat,maxLon,maxLat in degrees
+func parseBoundingBox(bbox string) (geo.Box, error) {
+	parts := strings.Split(bbox, ",")
+	if len(parts) != 4 {
+		return geo.Box{}, errors.New("bbox must be minLon,minLat,maxLon,maxLat")
+	}
+	var values [4]float64
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return geo.Box{}, fmt.Errorf("invalid bbox coordinate %q", part)
+		}
+		values[i] = value
+	}
+	box := geo.Box{MinLon: values[0], MinLat: values[1], MaxLon: values[2], MaxLat: values[3]}
+	if box.MinLat < -90 || box.MaxLat > 90 || box.MinLon < -180 || box.MaxLon > 180 {
+		return geo.Box{}, errors.New("bbox coordinates out of range")
+	}
+	if box.MinLat > box.MaxLat || box.MinLon > box.MaxLon {
+		return geo.Box{}, errors.New("bbox minimum must not exceed the maximum")
+	}
+	return box, nil
+}
+
+// Limits of the nearby search, the radius keeps the bounding box prefilter selective
+const (
+	defaultNearbyRadius = 5000
+	maxNearbyRadius     = 50000
+	defaultNearbyLimit  = 20
+	maxNearbyLimit      = 100
+)
+
+// @Summary Find refill stations nearby
+// @Description Get the stations within a radius around a position ordered by great-circle distance, nearest first
+// @Tags Refill Stations
+// @Accept json
+// @Produce json
+// @Param lat query number true "Latitude in degrees"
+// @Param lon query number true "Longitude in degrees"
+// @Param radius_m query number false "Search radius in meters, at most 50000" default(5000)
+// @Param limit query int false "Maximum number of stations, at most 100" default(20)
+// @Success 200 {array} repository.NearbyStation
+// @Failure 400 {object} map[string]string
+// @Router /refill_stations/nearby [get]
+func (s *Server) GetNearbyRefillStations(c *gin.Context) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat must be a latitude between -90 and 90"})
+		return
+	}
+	lon, err := strconv.ParseFloat(c.Query("lon"), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lon must be a longitude between -180 and 180"})
+		return
+	}
+	radius, err := strconv.ParseFloat(c.DefaultQuery("radius_m", strconv.Itoa(defaultNearbyRadius)), 64)
+	if err != nil || radius <= 0 || radius > maxNearbyRadius {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("radius_m must be between 0 and %d", maxNearbyRadius)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultNearbyLimit)))
+	if err != nil || limit < 1 || limit > maxNearbyLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxNearbyLimit)})
+		return
+	}
+
+	stations, err := s.stations.ListNearby(c.Request.Context(), repository.NearbyQuery{
+		Latitude:     lat,
+		Longitude:    lon,
+		RadiusMeters: radius,
+		Limit:        limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	for i := range stations {
+		stations[i].SetOpeningState(now)
+	}
+	c.JSON(http.StatusOK, stations)
+}
+
 // @Summary Get a refill station by ID
 // @Description Get a refill station by its ID
 // @Tags Refill Stations
@@ -71,52 +259,53 @@ func GetAllRefillstationMarker(c *gin.Context) {
 // @Param id path int true "Refill Station ID"
 // @Success 200 {object} database.RefillStation
 // @Router /refill_stations/{id} [get]
-func GetRefillStationById(c *gin.Context) {
+func (s *Server) GetRefillStationById(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	var station database.RefillStation
-	result := db.First(&station, id)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
+	station.SetOpeningState(time.Now())
 	c.JSON(http.StatusOK, station)
 }
 
 // @Summary Get the image from a refill station by ID
-// @Description Get the image from a refill station by ID
+// @Description Get the image from a refill station by ID as base64 in JSON, kept for older apps.
+// @Description New clients load the file from /refill_stations/{id}/image.
 // @Tags Refill Stations
 // @Accept json
 // @Produce json
 // @Param id path int true "Refill Station ID"
 // @Success 200 {object} StationImage
+// @Deprecated
 // @Router /refill_stations/image/{id} [get]
-func GetRefillStationImageById(c *gin.Context) {
+func (s *Server) GetRefillStationImageById(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	var station database.RefillStation
-	result := db.First(&station, id)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	if station.RefillStationImage == nil {
+	if station.ImageID == nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Refill Station has no image"})
 		return
 	}
 
-	byteArray, err := DecodeBase64ToBytes(*station.RefillStationImage)
+	byteArray, err := s.images.Read(c.Request.Context(), *station.ImageID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Error decoding base64 string"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -135,9 +324,7 @@ func GetRefillStationImageById(c *gin.Context) {
 // @Param id path int true "Refill Station ID"
 // @Success 200 {object} StationReviewAverage
 // @Router /refill_stations/{id}/reviews [get]
-func GetRefillStationReviewsAverageByID(c *gin.Context) {
-	var station database.RefillStation
-	var stationReviews []database.RefillStationReview
+func (s *Server) GetRefillStationReviewsAverageByID(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.Atoi(idStr)
 
@@ -150,15 +337,14 @@ func GetRefillStationReviewsAverageByID(c *gin.Context) {
 		return
 	}
 
-	stationQueryResult := db.First(&station, id)
-	if stationQueryResult.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": stationQueryResult.Error.Error()})
+	if _, err := s.stations.Get(c.Request.Context(), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
 	}
 
-	reviewsQueryResult := db.Where("station_id = ?", id).Find(&stationReviews)
-	if reviewsQueryResult.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": reviewsQueryResult.Error.Error()})
+	stationReviews, err := s.reviews.ListByStation(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -190,48 +376,99 @@ func GetRefillStationReviewsAverageByID(c *gin.Context) {
 }
 
 // @Summary Create a refill station
-// @Description Create a new refill station
+// @Description Create a new refill station, its initial active state starts its status history
 // @Tags Refill Stations
 // @Accept json
 // @Produce json
 // @Param station body database.RefillStation true "Refill Station"
 // @Success 201 {object} database.RefillStation
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_stations [post]
-func CreateRefillStation(c *gin.Context) {
+func (s *Server) CreateRefillStation(c *gin.Context) {
 	var station database.RefillStation
 	if err := c.ShouldBindJSON(&station); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result := db.Create(&station)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	station.ImageID = nil
+	created := database.StationStatusChange{Source: database.StatusSourceCreation, Reason: "Created", ActorID: &CurrentUser(c).ID, ChangedAt: time.Now()}
+	if err := s.stations.Install(c.Request.Context(), &station, &created); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	station.SetOpeningState(time.Now())
 	c.JSON(http.StatusCreated, station)
 }
 
 // @Summary Update a refill station
-// @Description Update an existing refill station
+// @Description Update an existing refill station, a change of the active state is recorded in its status history
 // @Tags Refill Stations
 // @Accept  json
 // @Produce  json
 // @Param station body database.RefillStation true "Refill Station"
 // @Success 200 {object} database.RefillStation
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_stations [put]
-func UpdateRefillStation(c *gin.Context) {
+func (s *Server) UpdateRefillStation(c *gin.Context) {
 	var requestStation database.RefillStation
 	if err := c.ShouldBindJSON(&requestStation); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	var helpStation database.RefillStation
-	if result := db.First(&helpStation, requestStation.ID); result.Error != nil {
+	if err := requestStation.ResolveOpeningHours(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stored, err := s.stations.Get(c.Request.Context(), requestStation.ID)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
 		return
 	}
-	db.Model(&helpStation).Updates(requestStation)
-	c.JSON(http.StatusOK, requestStation)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !canOperate(c, stored) {
+		respondForbidden(c)
+		return
+	}
+	// Only admins hand stations over to another operator
+	if !auth.Can(CurrentUser(c).Role, auth.ManageStations) {
+		requestStation.OwnerID = nil
+	}
+	requestStation.ImageID = nil
+	// Set by the heartbeat monitor only
+	requestStation.OfflineSince = nil
+	// Changes of the active state are recorded in the status history
+	var status *database.StationStatusChange
+	if requestStation.Active.Valid {
+		status = &database.StationStatusChange{
+			StationID: requestStation.ID,
+			Active:    requestStation.Active.Bool,
+			Source:    database.StatusSourceManual,
+			Reason:    "Updated with the refill station",
+			ActorID:   &CurrentUser(c).ID,
+			ChangedAt: time.Now(),
+		}
+	}
+	requestStation.Active = database.NullBool{}
+
+	station, err := s.stations.UpdateWithStatus(c.Request.Context(), &requestStation, status)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	station.SetOpeningState(time.Now())
+	c.JSON(http.StatusOK, station)
 }
 
 // @Summary Delete a refill station
@@ -241,8 +478,11 @@ func UpdateRefillStation(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Refill Station ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_stations/{id} [delete]
-func DeleteRefillStation(c *gin.Context) {
+func (s *Server) DeleteRefillStation(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -254,16 +494,18 @@ func DeleteRefillStation(c *gin.Context) {
 		return
 	}
 
-	var station database.RefillStation
-	if result := db.First(&station, id); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
-		return
+	station, err := s.stations.Get(c.Request.Context(), uint(id))
+	if err == nil {
+		err = s.stations.Delete(c.Request.Context(), uint(id))
 	}
-
-	result := db.Delete(&database.RefillStation{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.deleteImage(c.Request.Context(), station.ImageID)
 	c.Status(http.StatusNoContent)
 }
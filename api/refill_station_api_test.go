@@ -0,0 +1,242 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+func TestRefillStationRoutes(t *testing.T) {
+	newStation := map[string]interface{}{
+		"name": "Neue Station", "description": "Test", "latitude": 49.44, "longitude": 7.76,
+		"address": "Marktstraße 1", "water_source": "Stadtwerke", "opening_times": "Mo - So / 00:00 - 23:59",
+		"type": "manual", "offered_water_types": "tap",
+	}
+	invalidStation := map[string]interface{}{"name": "Kaputt", "type": "robot", "offered_water_types": "tap"}
+
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/refill_stations", nil, http.StatusOK, 0},
+		{"list open now", http.MethodGet, "/refill_stations?open_now=true", nil, http.StatusOK, 0},
+		{"list invalid open now", http.MethodGet, "/refill_stations?open_now=maybe", nil, http.StatusBadRequest, 0},
+		{"markers", http.MethodGet, "/refill_stations/markers", nil, http.StatusOK, 0},
+		{"markers in view", http.MethodGet, "/refill_stations/markers?bbox=7.70,49.40,7.80,49.50&zoom=12", nil, http.StatusOK, 0},
+		{"markers invalid bbox", http.MethodGet, "/refill_stations/markers?bbox=7.70,49.40,7.80", nil, http.StatusBadRequest, 0},
+		{"markers bbox not a number", http.MethodGet, "/refill_stations/markers?bbox=7.70,49.40,east,49.50", nil, http.StatusBadRequest, 0},
+		{"markers bbox out of range", http.MethodGet, "/refill_stations/markers?bbox=7.70,49.40,7.80,91", nil, http.StatusBadRequest, 0},
+		{"markers bbox inverted", http.MethodGet, "/refill_stations/markers?bbox=7.80,49.40,7.70,49.50", nil, http.StatusBadRequest, 0},
+		{"markers invalid zoom", http.MethodGet, "/refill_stations/markers?zoom=23", nil, http.StatusBadRequest, 0},
+		{"nearby", http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=7.7690&radius_m=1000&limit=5", nil, http.StatusOK, 0},
+		{"nearby with defaults", http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=7.7690", nil, http.StatusOK, 0},
+		{"nearby missing position", http.MethodGet, "/refill_stations/nearby?lat=49.4445", nil, http.StatusBadRequest, 0},
+		{"nearby invalid latitude", http.MethodGet, "/refill_stations/nearby?lat=91&lon=7.7690", nil, http.StatusBadRequest, 0},
+		{"nearby invalid longitude", http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=east", nil, http.StatusBadRequest, 0},
+		{"nearby radius too large", http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=7.7690&radius_m=100000", nil, http.StatusBadRequest, 0},
+		{"nearby invalid limit", http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=7.7690&limit=0", nil, http.StatusBadRequest, 0},
+		{"get by id", http.MethodGet, "/refill_stations/1", nil, http.StatusOK, 0},
+		{"get invalid id", http.MethodGet, "/refill_stations/abc", nil, http.StatusBadRequest, 0},
+		{"get unknown id", http.MethodGet, "/refill_stations/999", nil, http.StatusNotFound, 0},
+		{"image", http.MethodGet, "/refill_stations/image/1", nil, http.StatusOK, 0},
+		{"image invalid id", http.MethodGet, "/refill_stations/image/abc", nil, http.StatusBadRequest, 0},
+		{"image unknown id", http.MethodGet, "/refill_stations/image/999", nil, http.StatusNotFound, 0},
+		{"review average", http.MethodGet, "/refill_stations/1/reviews", nil, http.StatusOK, 0},
+		{"review average invalid id", http.MethodGet, "/refill_stations/abc/reviews", nil, http.StatusBadRequest, 0},
+		{"review average unknown id", http.MethodGet, "/refill_stations/999/reviews", nil, http.StatusNotFound, 0},
+		{"create", http.MethodPost, "/refill_stations", newStation, http.StatusCreated, 1},
+		{"create invalid type", http.MethodPost, "/refill_stations", invalidStation, http.StatusInternalServerError, 1},
+		{"create malformed body", http.MethodPost, "/refill_stations", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "name": "Stadtpark"}, http.StatusOK, 1},
+		{"update unknown id", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 999, "name": "Fehlt"}, http.StatusNotFound, 1},
+		{"update free text opening times", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "opening_times": "nach Vereinbarung"}, http.StatusOK, 1},
+		{"update invalid opening hours", http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "opening_hours": map[string]interface{}{"time_zone": "Mars/Olympus"}}, http.StatusBadRequest, 1},
+		{"delete", http.MethodDelete, "/refill_stations/12", nil, http.StatusNoContent, 1},
+		{"delete invalid id", http.MethodDelete, "/refill_stations/abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/refill_stations/999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestRefillStationPayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	stations := decodeResponse[api.ListPage[database.RefillStation]](t, doRequest(t, r, http.MethodGet, "/refill_stations", nil)).Items
+	if len(stations) != 12 {
+		t.Fatalf("expected 12 seeded stations, got %d", len(stations))
+	}
+	if stations[0].Name != "Stadtpark KL" || !stations[0].Active.Bool {
+		t.Errorf("unexpected first station: %+v", stations[0])
+	}
+
+	markers := decodeResponse[[]api.Marker](t, doRequest(t, r, http.MethodGet, "/refill_stations/markers", nil))
+	if len(markers) != 12 || markers[0].ID != 1 || markers[0].Latitude == 0 || markers[0].Status == nil || markers[0].Cluster {
+		t.Errorf("unexpected markers: %+v", markers)
+	}
+
+	average := decodeResponse[api.StationReviewAverage](t, doRequest(t, r, http.MethodGet, "/refill_stations/1/reviews", nil))
+	if average.Cleanness != 4.5 || average.Accesibility != 4.5 || average.WaterQuality != 4 {
+		t.Errorf("unexpected review average: %+v", average)
+	}
+
+	// A station without reviews reports zero averages
+	average = decodeResponse[api.StationReviewAverage](t, doRequest(t, r, http.MethodGet, "/refill_stations/12/reviews", nil))
+	if average != (api.StationReviewAverage{}) {
+		t.Errorf("expected zero averages, got %+v", average)
+	}
+
+	image := decodeResponse[api.StationImage](t, doRequest(t, r, http.MethodGet, "/refill_stations/image/1", nil))
+	if len(image.StationImage) == 0 {
+		t.Error("expected station image bytes")
+	}
+
+	doAuthRequest(t, r, login(t, r, 1), http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "name": "Stadtpark"})
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if station.Name != "Stadtpark" || station.Type != "smart" {
+		t.Errorf("expected only the name to change, got %+v", station)
+	}
+}
+
+func TestNearbyRefillStations(t *testing.T) {
+	r := newTestRouter(t)
+
+	// Within 600 m of the Stadtpark are only the Wochenmarkt and the Blaue Blume
+	stations := decodeResponse[[]repository.NearbyStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/nearby?lat=49.437551349217266&lon=7.761465081072085&radius_m=600", nil))
+	if len(stations) != 3 {
+		t.Fatalf("expected 3 stations within 600 m, got %d: %+v", len(stations), stations)
+	}
+	if stations[0].ID != 1 || stations[0].DistanceMeters != 0 || stations[1].ID != 4 || stations[2].ID != 10 {
+		t.Errorf("expected the Stadtpark, Wochenmarkt and Blaue Blume in that order, got %+v", stations)
+	}
+	for i, station := range stations {
+		if station.DistanceMeters > 600 {
+			t.Errorf("station %d is %.0f m away, outside the radius", station.ID, station.DistanceMeters)
+		}
+		if i > 0 && station.DistanceMeters < stations[i-1].DistanceMeters {
+			t.Errorf("stations are not ordered by distance: %+v", stations)
+		}
+		if station.Name == "" {
+			t.Errorf("expected station details, got %+v", station)
+		}
+	}
+
+	limited := decodeResponse[[]repository.NearbyStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/nearby?lat=49.4445&lon=7.7690&radius_m=50000&limit=4", nil))
+	if len(limited) != 4 {
+		t.Errorf("expected the limit to apply, got %d stations", len(limited))
+	}
+
+	none := decodeResponse[[]repository.NearbyStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/nearby?lat=52.52&lon=13.40", nil))
+	if len(none) != 0 {
+		t.Errorf("expected no stations in Berlin, got %+v", none)
+	}
+}
+
+func TestRefillStationMarkers(t *testing.T) {
+	r := newTestRouter(t)
+
+	// Only the Fraunhofer IESE lies south west of the city center
+	markers := decodeResponse[[]api.Marker](t, doRequest(t, r, http.MethodGet, "/refill_stations/markers?bbox=7.75,49.43,7.755,49.435", nil))
+	if len(markers) != 1 || markers[0].ID != 12 || markers[0].Count != 1 {
+		t.Errorf("expected only the marker of station 12, got %+v", markers)
+	}
+
+	// Zoomed in far enough every station has its own marker
+	markers = decodeResponse[[]api.Marker](t, doRequest(t, r, http.MethodGet, "/refill_stations/markers?bbox=7.70,49.40,7.80,49.50&zoom=18", nil))
+	if len(markers) != 12 {
+		t.Errorf("expected 12 station markers on zoom 18, got %+v", markers)
+	}
+
+	// Zoomed out the stations of Kaiserslautern merge into clusters
+	markers = decodeResponse[[]api.Marker](t, doRequest(t, r, http.MethodGet, "/refill_stations/markers?zoom=8", nil))
+	total := 0
+	for _, marker := range markers {
+		total += marker.Count
+		if marker.Cluster && (marker.ID != 0 || marker.Status != nil || marker.Count < 2) {
+			t.Errorf("unexpected cluster: %+v", marker)
+		}
+		if marker.Cluster && (marker.Latitude < 49.43 || marker.Latitude > 49.45 || marker.Longitude < 7.75 || marker.Longitude > 7.78) {
+			t.Errorf("expected the cluster within Kaiserslautern, got %+v", marker)
+		}
+	}
+	if len(markers) >= 12 || total != 12 {
+		t.Errorf("expected clusters counting 12 stations on zoom 8, got %+v", markers)
+	}
+
+	// An empty view has no markers
+	markers = decodeResponse[[]api.Marker](t, doRequest(t, r, http.MethodGet, "/refill_stations/markers?bbox=13.3,52.4,13.5,52.6&zoom=5", nil))
+	if len(markers) != 0 {
+		t.Errorf("expected no markers in Berlin, got %+v", markers)
+	}
+}
+
+func TestRefillStationOpeningHours(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 1)
+
+	// The seeded opening times are all parsed, the Stadtpark and the Gartenschau never close
+	stations := decodeResponse[api.ListPage[database.RefillStation]](t, doRequest(t, r, http.MethodGet, "/refill_stations", nil)).Items
+	for _, station := range stations {
+		if station.OpeningHours == nil || station.IsOpenNow == nil {
+			t.Errorf("expected opening hours and state of station %d, got %+v", station.ID, station)
+		}
+	}
+	if station := stations[0]; station.IsOpenNow == nil || !*station.IsOpenNow || station.NextChangeAt != nil || station.OpeningHours.String() != "24/7" {
+		t.Errorf("expected station 1 to be always open, got %+v", station)
+	}
+
+	open := decodeResponse[api.ListPage[database.RefillStation]](t, doRequest(t, r, http.MethodGet, "/refill_stations?open_now=true", nil)).Items
+	closed := decodeResponse[api.ListPage[database.RefillStation]](t, doRequest(t, r, http.MethodGet, "/refill_stations?open_now=false", nil)).Items
+	if len(open)+len(closed) != len(stations) {
+		t.Errorf("expected %d stations to be either open or closed, got %d open and %d closed", len(stations), len(open), len(closed))
+	}
+	alwaysOpen := 0
+	for _, station := range open {
+		if !*station.IsOpenNow {
+			t.Errorf("expected station %d to be open", station.ID)
+		}
+		if station.ID == 1 || station.ID == 3 {
+			alwaysOpen++
+		}
+	}
+	if alwaysOpen != 2 {
+		t.Errorf("expected stations 1 and 3 to be open, got %+v", open)
+	}
+	for _, station := range closed {
+		if *station.IsOpenNow || station.NextChangeAt == nil {
+			t.Errorf("expected station %d to be closed until its next opening, got %+v", station.ID, station)
+		}
+	}
+
+	// Structured opening hours also fill in the opening times
+	created := decodeResponse[database.RefillStation](t, doAuthRequest(t, r, token, http.MethodPost, "/refill_stations", map[string]interface{}{
+		"name": "Geschlossen", "latitude": 49.44, "longitude": 7.76, "type": "manual", "offered_water_types": "tap",
+		"opening_hours": map[string]interface{}{
+			"weekly":     []map[string]string{{"day": "Mo", "open": "08:00", "close": "18:00"}},
+			"exceptions": []map[string]interface{}{{"date": "2026-12-21", "hours": []string{}}},
+		},
+	}))
+	if created.OpeningTimes != "Mo 08:00-18:00; 2026 Dec 21 off" || created.OpeningHours.TimeZone != "Europe/Berlin" || created.IsOpenNow == nil {
+		t.Errorf("unexpected created station: %+v", created)
+	}
+
+	// Opening times are free text, the opening state is unknown if they do not parse
+	w := doAuthRequest(t, r, token, http.MethodPost, "/refill_stations", map[string]interface{}{
+		"name": "Frei", "type": "manual", "offered_water_types": "tap", "opening_times": "Mo-Fr 7-18, Sa 9-13",
+	})
+	if freeText := decodeResponse[database.RefillStation](t, w); w.Code != http.StatusCreated || freeText.OpeningTimes != "Mo-Fr 7-18, Sa 9-13" ||
+		freeText.OpeningHours != nil || freeText.IsOpenNow != nil {
+		t.Errorf("expected the free text to be kept without opening hours, got %d %s", w.Code, w.Body.String())
+	}
+	doAuthRequest(t, r, token, http.MethodPut, "/refill_stations", map[string]interface{}{"id": created.ID, "opening_times": "Rund um die Uhr"})
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, fmt.Sprintf("/refill_stations/%d", created.ID), nil))
+	if station.OpeningTimes != "Rund um die Uhr" || station.OpeningHours != nil || station.IsOpenNow != nil {
+		t.Errorf("expected the old opening hours to be dropped with the free text, got %+v", station)
+	}
+
+	// Changing the opening times replaces the opening hours
+	doAuthRequest(t, r, token, http.MethodPut, "/refill_stations", map[string]interface{}{"id": created.ID, "opening_times": "Mo-Su off"})
+	station = decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, fmt.Sprintf("/refill_stations/%d", created.ID), nil))
+	if station.IsOpenNow == nil || *station.IsOpenNow || station.NextChangeAt != nil || len(station.OpeningHours.Weekly) != 0 {
+		t.Errorf("expected the station to be closed for good, got %+v", station)
+	}
+}
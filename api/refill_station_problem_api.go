@@ -1,11 +1,15 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,23 +19,43 @@ type PostRequestRefillStationProblem struct {
 	Description               string  `gorm:"size:255;not null" json:"description"`
 	RefillStationProblemImage *[]byte `gorm:"type:TEXT;default:null" json:"problem_image"`
 	Status                    string  `gorm:"size:16;not null" json:"status"`
+	DeactivateStation         bool    `json:"deactivate_station"`
+}
+
+// ProblemTransition moves a problem to another status. DeactivateStation deactivates the
+// station of an open or in progress problem, which is activated again when the problem is
+// solved or closed and no other open problem keeps it deactivated.
+type ProblemTransition struct {
+	Status            string `json:"status" binding:"required"`
+	Comment           string `json:"comment" binding:"max=255"`
+	DeactivateStation bool   `json:"deactivate_station"`
 }
 
 // @Summary Show all refill station problems
-// @Description Get all refill station problems
+// @Description Get a page of refill station problems
 // @Tags Refill Station Problems
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.RefillStationProblem
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, station_id, title, status, timestamp" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.RefillStationProblem]
+// @Failure 400 {object} map[string]string
 // @Router /refill_station_problems [get]
-func GetRefillStationProblems(c *gin.Context) {
-	var problems []database.RefillStationProblem
-	result := db.Find(&problems)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+func (s *Server) GetRefillStationProblems(c *gin.Context) {
+	params, err := parseListParams[database.RefillStationProblem](c, "station_id", "title", "status", "timestamp")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	problems, total, err := s.problems.List(c.Request.Context(), params.query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, problems)
+	respondList(c, problems, total, params)
 }
 
 // @Summary Show refill station problem by id
@@ -42,7 +66,7 @@ func GetRefillStationProblems(c *gin.Context) {
 // @Param id path int true "id"
 // @Success 200 {object} database.RefillStationProblem
 // @Router /refill_station_problems/{id} [get]
-func GetRefillStationProblemById(c *gin.Context) {
+func (s *Server) GetRefillStationProblemById(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing ID"})
@@ -52,10 +76,9 @@ func GetRefillStationProblemById(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var problem database.RefillStationProblem
-		result := db.First(&problem, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		problem, err := s.problems.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, problem)
@@ -63,77 +86,209 @@ func GetRefillStationProblemById(c *gin.Context) {
 }
 
 // @Summary Create a refill station problem
-// @Description Create a new refill station problem
+// @Description Report a new refill station problem, problems are reported with the status OPEN.
+// @Description Operators of the station can deactivate it until the problem is solved or closed.
 // @Tags Refill Station Problems
 // @Accept json
 // @Produce json
 // @Param problem body PostRequestRefillStationProblem true "Refill Station Problem"
 // @Success 201 {object} database.RefillStationProblem
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_station_problems [post]
-func CreateRefillStationProblem(c *gin.Context) {
+func (s *Server) CreateRefillStationProblem(c *gin.Context) {
 	var requestProblem PostRequestRefillStationProblem
 	if err := c.ShouldBindJSON(&requestProblem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if requestProblem.Status == "" {
+		requestProblem.Status = database.ProblemOpen
+	}
+	if requestProblem.Status != database.ProblemOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Problems are reported with the status OPEN"})
+		return
+	}
+	if requestProblem.DeactivateStation {
+		station, err := s.stations.Get(c.Request.Context(), requestProblem.StationID)
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Refill Station with ID not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !canOperate(c, station) {
+			respondForbidden(c)
+			return
+		}
+	}
 
 	var problemToInsert = database.RefillStationProblem{
-		StationID:   requestProblem.StationID,
-		Status:      requestProblem.Status,
-		Title:       requestProblem.Title,
-		Description: requestProblem.Description,
-		Timestamp:   time.Now(),
+		StationID:         requestProblem.StationID,
+		Status:            requestProblem.Status,
+		Title:             requestProblem.Title,
+		Description:       requestProblem.Description,
+		DeactivateStation: requestProblem.DeactivateStation,
+		Timestamp:         time.Now(),
 	}
 
 	if requestProblem.RefillStationProblemImage != nil {
-		base64image := EncodeBytesToBase64(*requestProblem.RefillStationProblemImage)
-		problemToInsert.RefillStationProblemImage = &base64image
+		image := s.saveImage(c, *requestProblem.RefillStationProblemImage)
+		if image == nil {
+			return
+		}
+		problemToInsert.ImageID = &image.ID
 	}
 
-	result := db.Create(&problemToInsert)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.problems.Report(c.Request.Context(), &problemToInsert, &CurrentUser(c).ID); err != nil {
+		s.deleteImage(c.Request.Context(), problemToInsert.ImageID)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, requestProblem)
 }
 
 // @Summary Update a refill station problem
-// @Description Update an existing refill station problem
+// @Description Update an existing refill station problem, a change of the status has to be an allowed transition.
+// @Description deactivate_station only takes effect along with a change of the status.
 // @Tags Refill Station Problems
 // @Accept  json
 // @Produce  json
 // @Param problem body database.RefillStationProblem true "Refill Station Problem"
 // @Success 200 {object} database.RefillStationProblem
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /refill_station_problems [put]
-func UpdateRefillStationProblem(c *gin.Context) {
+func (s *Server) UpdateRefillStationProblem(c *gin.Context) {
 	var requestProblem database.RefillStationProblem
 	if err := c.ShouldBindJSON(&requestProblem); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var problem database.RefillStationProblem
-	if err := db.Where("id = ?", requestProblem.ID).First(&problem).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+	stored, ok := s.canOperateProblem(c, requestProblem.ID, http.StatusBadRequest)
+	if !ok {
 		return
 	}
+	// Only admins move problems to another station
+	if !auth.Can(CurrentUser(c).Role, auth.ManageStations) {
+		requestProblem.StationID = 0
+	}
+	requestProblem.ImageID = nil
 
 	requestProblem.Timestamp = time.Now()
-	db.Model(&problem).Updates(requestProblem)
+	if requestProblem.Status != "" && requestProblem.Status != stored.Status {
+		transition := ProblemTransition{Status: requestProblem.Status, DeactivateStation: requestProblem.DeactivateStation}
+		problem, ok := s.transitionProblem(c, stored, transition, &requestProblem)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, problem)
+		return
+	}
+	// The station is only deactivated along with a change of the status
+	requestProblem.Status, requestProblem.DeactivateStation = "", false
+	problem, err := s.problems.Update(c.Request.Context(), &requestProblem)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, problem)
+}
 
-	c.JSON(http.StatusOK, requestProblem)
+// @Summary Change the status of a refill station problem
+// @Description Move a problem along its workflow with an optional comment: OPEN to INPROGRESS or CLOSED,
+// @Description INPROGRESS to OPEN, SOLVED or CLOSED, and SOLVED or CLOSED back to OPEN. The station can be
+// @Description deactivated because of an open problem, it is activated again when the problem is solved or closed
+// @Description and no other open problem keeps it deactivated.
+// @Tags Refill Station Problems
+// @Accept json
+// @Produce json
+// @Param id path int true "Problem ID"
+// @Param transition body ProblemTransition true "Transition"
+// @Success 200 {object} database.RefillStationProblem
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /refill_station_problems/{id}/transitions [post]
+func (s *Server) TransitionRefillStationProblem(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var transition ProblemTransition
+	if err := c.ShouldBindJSON(&transition); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	problem, ok := s.canOperateProblem(c, uint(id), http.StatusNotFound)
+	if !ok {
+		return
+	}
+	problem, ok = s.transitionProblem(c, problem, transition, nil)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, problem)
+}
+
+// @Summary Get the timeline of a refill station problem
+// @Description Get the status changes of a problem ordered by time, starting with its report
+// @Tags Refill Station Problems
+// @Produce json
+// @Param id path int true "Problem ID"
+// @Success 200 {array} database.ProblemStatusChange
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_station_problems/{id}/timeline [get]
+func (s *Server) GetRefillStationProblemTimeline(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if _, err := s.problems.Get(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := s.problems.ListStatusChanges(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
 }
 
 // @Summary Delete a refill station problem
-// @Description Delete an existing refill station problem
+// @Description Delete an existing refill station problem, the station it kept deactivated is activated again
+// @Description if no other open problem keeps it deactivated
 // @Tags Refill Station Problems
 // @Accept  json
 // @Produce  json
 // @Param id path int true "Refill Station Problem ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_station_problems/{id} [delete]
-func DeleteRefillStationProblem(c *gin.Context) {
+func (s *Server) DeleteRefillStationProblem(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -144,17 +299,89 @@ func DeleteRefillStationProblem(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	// Check for record not found error
-	var tempProblem database.RefillStationProblem
-	result := db.First(&tempProblem, id)
-	if result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Problem with ID not found"})
+
+	problem, ok := s.canOperateProblem(c, uint(id), http.StatusNotFound)
+	if !ok {
+		return
 	}
 
-	result = db.Delete(&database.RefillStationProblem{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.problems.Delete(c.Request.Context(), uint(id), &CurrentUser(c).ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Problem with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	s.deleteImage(c.Request.Context(), problem.ImageID)
 	c.Status(http.StatusNoContent)
 }
+
+// canOperateProblem loads the problem and checks that the current user operates its station,
+// otherwise it responds with notFoundStatus, 403 or 500 and returns false
+func (s *Server) canOperateProblem(c *gin.Context, problemID uint, notFoundStatus int) (*database.RefillStationProblem, bool) {
+	problem, err := s.problems.Get(c.Request.Context(), problemID)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(notFoundStatus, gin.H{"error": "Problem with ID not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	station, err := s.stations.Get(c.Request.Context(), problem.StationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !canOperate(c, station) {
+		respondForbidden(c)
+		return nil, false
+	}
+	return problem, true
+}
+
+// transitionProblem moves the problem to the status of the transition as the current user along
+// with the non-zero fields of changes, which may be nil, and deactivates or activates its station
+// accordingly, otherwise it responds with an error and returns false
+func (s *Server) transitionProblem(c *gin.Context, problem *database.RefillStationProblem, transition ProblemTransition, changes *database.RefillStationProblem) (*database.RefillStationProblem, bool) {
+	target := &database.RefillStationProblem{Status: transition.Status}
+	if err := target.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if !problem.CanTransition(transition.Status) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A %s problem cannot move to %s", problem.Status, transition.Status)})
+		return nil, false
+	}
+	if transition.DeactivateStation && !target.IsOpen() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only open problems can deactivate the station"})
+		return nil, false
+	}
+	if changes == nil {
+		changes = &database.RefillStationProblem{}
+	}
+	changes.DeactivateStation = transition.DeactivateStation
+
+	updated, err := s.problems.Transition(c.Request.Context(), &database.ProblemStatusChange{
+		ProblemID:  problem.ID,
+		FromStatus: problem.Status,
+		ToStatus:   transition.Status,
+		Comment:    transition.Comment,
+		ActorID:    &CurrentUser(c).ID,
+		ChangedAt:  time.Now(),
+	}, changes)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Problem with ID not found"})
+		return nil, false
+	}
+	if errors.Is(err, repository.ErrProblemStatusChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return updated, true
+}
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestRefillStationProblemRoutes(t *testing.T) {
+	newProblem := map[string]interface{}{"station_id": 1, "title": "Verstopft", "description": "Kein Wasser", "status": "OPEN"}
+	invalidStatus := map[string]interface{}{"station_id": 1, "title": "Verstopft", "description": "Kein Wasser", "status": "BROKEN"}
+
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/refill_station_problems", nil, http.StatusOK, 0},
+		{"get by id", http.MethodGet, "/refill_station_problems/1", nil, http.StatusOK, 0},
+		{"get invalid id", http.MethodGet, "/refill_station_problems/abc", nil, http.StatusBadRequest, 0},
+		{"get unknown id", http.MethodGet, "/refill_station_problems/999", nil, http.StatusNotFound, 0},
+		{"create", http.MethodPost, "/refill_station_problems", newProblem, http.StatusCreated, 1},
+		{"create invalid status", http.MethodPost, "/refill_station_problems", invalidStatus, http.StatusBadRequest, 1},
+		{"create solved", http.MethodPost, "/refill_station_problems", map[string]interface{}{"station_id": 1, "title": "Verstopft", "description": "Kein Wasser", "status": "SOLVED"}, http.StatusBadRequest, 1},
+		{"create malformed body", http.MethodPost, "/refill_station_problems", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 1, "status": "INPROGRESS"}, http.StatusOK, 1},
+		{"update unknown id", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 999, "status": "SOLVED"}, http.StatusBadRequest, 1},
+		{"update skipping a status", http.MethodPut, "/refill_station_problems", map[string]interface{}{"id": 1, "status": "SOLVED"}, http.StatusConflict, 1},
+		{"delete", http.MethodDelete, "/refill_station_problems/1", nil, http.StatusNoContent, 1},
+		{"delete invalid id", http.MethodDelete, "/refill_station_problems/abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/refill_station_problems/999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestRefillStationProblemPayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	problems := decodeResponse[api.ListPage[database.RefillStationProblem]](t, doRequest(t, r, http.MethodGet, "/refill_station_problems", nil))
+	if problems.Total != 3 || len(problems.Items) != 3 {
+		t.Fatalf("expected 3 seeded problems, got %d", len(problems.Items))
+	}
+
+	problem := decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, "/refill_station_problems/2", nil))
+	if problem.StationID != 2 || problem.Status != "INPROGRESS" {
+		t.Errorf("unexpected problem 2: %+v", problem)
+	}
+
+	token := login(t, r, 1)
+	w := doAuthRequest(t, r, token, http.MethodPost, "/refill_station_problems",
+		map[string]interface{}{"station_id": 4, "title": "Kaputt", "description": "Kein Bild", "status": "OPEN", "problem_image": []byte{1, 2, 3}})
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an image that is no image, got %d", w.Code)
+	}
+
+	doAuthRequest(t, r, token, http.MethodPost, "/refill_station_problems",
+		map[string]interface{}{"station_id": 4, "title": "Verstopft", "description": "Kein Wasser", "status": "OPEN", "problem_image": testPNG(t, 20, 20)})
+	problem = decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, "/refill_station_problems/4", nil))
+	if problem.StationID != 4 || problem.Title != "Verstopft" || problem.Timestamp.IsZero() || problem.ImageID == nil {
+		t.Errorf("unexpected created problem: %+v", problem)
+	}
+}
@@ -0,0 +1,204 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestProblemWorkflowRoutes(t *testing.T) {
+	start := map[string]interface{}{"status": "INPROGRESS", "comment": "Technician on the way"}
+
+	runRouteCases(t, []routeCase{
+		{"timeline", http.MethodGet, "/refill_station_problems/1/timeline", nil, http.StatusOK, 0},
+		{"timeline of unknown problem", http.MethodGet, "/refill_station_problems/999/timeline", nil, http.StatusNotFound, 0},
+		{"timeline invalid id", http.MethodGet, "/refill_station_problems/abc/timeline", nil, http.StatusBadRequest, 0},
+		{"transition anonymously", http.MethodPost, "/refill_station_problems/1/transitions", start, http.StatusUnauthorized, 0},
+		{"transition as user", http.MethodPost, "/refill_station_problems/1/transitions", start, http.StatusForbidden, 4},
+		{"transition of operated station", http.MethodPost, "/refill_station_problems/1/transitions", start, http.StatusOK, operatorID},
+		{"transition of other station", http.MethodPost, "/refill_station_problems/3/transitions", map[string]interface{}{"status": "OPEN"}, http.StatusForbidden, operatorID},
+		{"transition of unknown problem", http.MethodPost, "/refill_station_problems/999/transitions", start, http.StatusNotFound, adminID},
+		{"transition to unknown status", http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "BROKEN"}, http.StatusBadRequest, adminID},
+		{"transition without status", http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"comment": "Nothing"}, http.StatusBadRequest, adminID},
+		{"skip in progress", http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "SOLVED"}, http.StatusConflict, adminID},
+		{"close solved problem", http.MethodPost, "/refill_station_problems/3/transitions", map[string]interface{}{"status": "CLOSED"}, http.StatusConflict, adminID},
+		{"reopen solved problem", http.MethodPost, "/refill_station_problems/3/transitions", map[string]interface{}{"status": "OPEN"}, http.StatusOK, adminID},
+		{"deactivate when solving", http.MethodPost, "/refill_station_problems/2/transitions", map[string]interface{}{"status": "SOLVED", "deactivate_station": true}, http.StatusBadRequest, adminID},
+	})
+}
+
+func TestProblemTimeline(t *testing.T) {
+	r := newTestRouter(t)
+	admin := login(t, r, adminID)
+
+	if w := doAuthRequest(t, r, admin, http.MethodPost, "/refill_station_problems",
+		map[string]interface{}{"station_id": 1, "title": "Verstopft", "description": "Kein Wasser"}); w.Code != http.StatusCreated {
+		t.Fatalf("failed to report the problem: %d %s", w.Code, w.Body.String())
+	}
+	path := "/refill_station_problems/4"
+	problem := decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, path, nil))
+	if problem.Status != database.ProblemOpen {
+		t.Fatalf("expected the problem to be reported open, got %+v", problem)
+	}
+
+	for _, status := range []string{"INPROGRESS", "SOLVED", "OPEN"} {
+		if w := doAuthRequest(t, r, admin, http.MethodPost, path+"/transitions", map[string]interface{}{"status": status, "comment": "To " + status}); w.Code != http.StatusOK {
+			t.Fatalf("failed to move the problem to %s: %d %s", status, w.Code, w.Body.String())
+		}
+	}
+	updated := decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, path, nil))
+	if updated.Status != database.ProblemOpen {
+		t.Errorf("expected the problem to be reopened, got %s", updated.Status)
+	}
+
+	timeline := decodeResponse[[]database.ProblemStatusChange](t, doRequest(t, r, http.MethodGet, path+"/timeline", nil))
+	if len(timeline) != 4 {
+		t.Fatalf("expected the report and 3 transitions, got %+v", timeline)
+	}
+	if timeline[0].FromStatus != "" || timeline[0].ToStatus != database.ProblemOpen {
+		t.Errorf("expected the timeline to start with the report, got %+v", timeline[0])
+	}
+	last := timeline[3]
+	if last.FromStatus != database.ProblemSolved || last.ToStatus != database.ProblemOpen || last.Comment != "To OPEN" ||
+		last.ActorID == nil || *last.ActorID != adminID || last.ChangedAt.Before(timeline[0].ChangedAt) {
+		t.Errorf("unexpected reopening: %+v", last)
+	}
+
+	// A status change through the update is recorded without a comment
+	updated.Status = database.ProblemInProgress
+	if w := doAuthRequest(t, r, admin, http.MethodPut, "/refill_station_problems", updated); w.Code != http.StatusOK {
+		t.Fatalf("failed to update the problem: %d %s", w.Code, w.Body.String())
+	}
+	timeline = decodeResponse[[]database.ProblemStatusChange](t, doRequest(t, r, http.MethodGet, path+"/timeline", nil))
+	if len(timeline) != 5 || timeline[4].ToStatus != database.ProblemInProgress || timeline[4].Comment != "" {
+		t.Errorf("expected the update in the timeline, got %+v", timeline)
+	}
+}
+
+func TestProblemDeactivatesStation(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+
+	w := doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions",
+		map[string]interface{}{"status": "INPROGRESS", "deactivate_station": true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to start the problem: %d %s", w.Code, w.Body.String())
+	}
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if station.Active.Bool {
+		t.Fatalf("expected the station to be deactivated, got %+v", station)
+	}
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 1 || changes[0].Source != database.StatusSourceProblem || changes[0].ProblemID == nil || *changes[0].ProblemID != 1 {
+		t.Fatalf("expected the deactivation by the problem, got %+v", changes)
+	}
+
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "SOLVED", "comment": "Filter replaced"})
+	station = decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if !station.Active.Bool {
+		t.Errorf("expected the station to be activated again, got %+v", station)
+	}
+
+	// A station deactivated by hand stays inactive when the problem is solved
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "OPEN", "deactivate_station": true})
+	doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": true, "reason": "Works for now"})
+	doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": false, "reason": "Maintenance"})
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "CLOSED"})
+	station = decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if station.Active.Bool {
+		t.Errorf("expected the station to stay inactive, got %+v", station)
+	}
+}
+
+func TestProblemsKeepStationDeactivated(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+
+	// Problem 1 deactivates the station, the second problem asks for it while it is inactive
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "INPROGRESS", "deactivate_station": true})
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems", map[string]interface{}{"station_id": 1, "title": "Leak", "description": "Water on the floor"})
+	w := doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/4/transitions", map[string]interface{}{"status": "INPROGRESS", "deactivate_station": true})
+	if problem := decodeResponse[database.RefillStationProblem](t, w); !problem.DeactivateStation {
+		t.Fatalf("expected the second problem to keep the station deactivated, got %d %s", w.Code, w.Body.String())
+	}
+
+	active := func() bool {
+		return decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil)).Active.Bool
+	}
+	doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/1/transitions", map[string]interface{}{"status": "SOLVED"})
+	if active() {
+		t.Fatal("expected the open second problem to keep the station deactivated")
+	}
+	w = doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems/4/transitions", map[string]interface{}{"status": "CLOSED"})
+	if problem := decodeResponse[database.RefillStationProblem](t, w); problem.DeactivateStation {
+		t.Errorf("expected the closed problem to release the station, got %+v", problem)
+	}
+	if !active() {
+		t.Error("expected the station to be activated once both problems are resolved")
+	}
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 2 || *changes[0].ProblemID != 1 || *changes[1].ProblemID != 4 || changes[1].Reason != "Problem 4 closed" {
+		t.Errorf("expected the deactivation by the first and the activation by the second problem, got %+v", changes)
+	}
+}
+
+func TestProblemUpdateWithTransition(t *testing.T) {
+	r := newTestRouter(t)
+	admin := login(t, r, adminID)
+	path := "/refill_station_problems/1"
+	stored := decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, path, nil))
+
+	// A failing step leaves neither the status nor the timeline changed
+	w := doAuthRequest(t, r, admin, http.MethodPut, "/refill_station_problems",
+		map[string]interface{}{"id": 1, "station_id": 999, "status": "INPROGRESS", "deactivate_station": true})
+	if w.Code == http.StatusOK {
+		t.Fatalf("expected the move to an unknown station to fail, got %s", w.Body.String())
+	}
+	if problem := decodeResponse[database.RefillStationProblem](t, doRequest(t, r, http.MethodGet, path, nil)); problem.Status != database.ProblemOpen || problem.StationID != 1 {
+		t.Errorf("expected the problem to be unchanged, got %+v", problem)
+	}
+	if timeline := decodeResponse[[]database.ProblemStatusChange](t, doRequest(t, r, http.MethodGet, path+"/timeline", nil)); len(timeline) != 0 {
+		t.Errorf("expected no transition in the timeline, got %+v", timeline)
+	}
+
+	// The response is the stored problem, not the request
+	w = doAuthRequest(t, r, admin, http.MethodPut, "/refill_station_problems",
+		map[string]interface{}{"id": 1, "title": "Updated", "status": "INPROGRESS", "deactivate_station": true})
+	problem := decodeResponse[database.RefillStationProblem](t, w)
+	if w.Code != http.StatusOK || problem.Title != "Updated" || problem.Description != stored.Description ||
+		problem.Status != database.ProblemInProgress || !problem.DeactivateStation {
+		t.Fatalf("expected the stored problem, got %d %s", w.Code, w.Body.String())
+	}
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, fmt.Sprintf("/refill_stations/%d", stored.StationID), nil))
+	if station.Active.Bool {
+		t.Errorf("expected the station to be deactivated along with the update, got %+v", station)
+	}
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, admin, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 1 || changes[0].Reason != "Problem 1: Updated" {
+		t.Errorf("expected the deactivation with the new title, got %+v", changes)
+	}
+}
+
+func TestDeletedProblemReleasesStation(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+
+	w := doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems",
+		map[string]interface{}{"station_id": 1, "title": "Leak", "description": "Water on the floor", "deactivate_station": true})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to report the problem: %d %s", w.Code, w.Body.String())
+	}
+	if w := doAuthRequest(t, r, operator, http.MethodDelete, "/refill_station_problems/4", nil); w.Code != http.StatusNoContent {
+		t.Fatalf("failed to delete the problem: %d %s", w.Code, w.Body.String())
+	}
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if !station.Active.Bool {
+		t.Errorf("expected the station to be activated again, got %+v", station)
+	}
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 2 || !changes[1].Active || changes[1].Source != database.StatusSourceProblem || changes[1].Reason != "Problem 4 deleted" {
+		t.Errorf("expected the activation by the deleted problem, got %+v", changes)
+	}
+}
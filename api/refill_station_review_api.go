@@ -1,41 +1,53 @@
 package api
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Show all refill station reviews
-// @Description Get all refill station reviews
+// @Description Get a page of refill station reviews
 // @Tags Refill Station Reviews
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.RefillStationReview
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, station_id, user_id, cleanness, accessibility, water_quality, timestamp" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.RefillStationReview]
+// @Failure 400 {object} map[string]string
 // @Router /refill_station_reviews [get]
-func GetRefillStationReviews(c *gin.Context) {
+func (s *Server) GetRefillStationReviews(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
-		var reviews []database.RefillStationReview
-		result := db.Find(&reviews)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		params, err := parseListParams[database.RefillStationReview](c, "station_id", "user_id", "cleanness", "accessibility", "water_quality", "timestamp")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		reviews, total, err := s.reviews.List(c.Request.Context(), params.query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, reviews)
+		respondList(c, reviews, total, params)
 	} else {
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var review database.RefillStationReview
-		result := db.First(&review, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		review, err := s.reviews.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, review)
@@ -51,7 +63,7 @@ func GetRefillStationReviews(c *gin.Context) {
 // @Param stationId path int true "Station ID"
 // @Success 200 {object} database.RefillStationReview
 // @Router /refill_station_reviews/{userId}/{stationId} [get]
-func GetRefillStationReviewsByUserId(c *gin.Context) {
+func (s *Server) GetRefillStationReviewsByUserId(c *gin.Context) {
 	userIdStr := c.Param("userId")
 	userId, err := strconv.Atoi(userIdStr)
 	if err != nil {
@@ -66,10 +78,13 @@ func GetRefillStationReviewsByUserId(c *gin.Context) {
 		return
 	}
 
-	var review database.RefillStationReview
-	result := db.Where("user_id = ? AND station_id = ?", userId, stationId).Find(&review)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	review, err := s.reviews.GetByUserAndStation(c.Request.Context(), uint(userId), uint(stationId))
+	if errors.Is(err, repository.ErrNotFound) {
+		// An empty review tells the app that the user has not rated the station yet
+		review, err = &database.RefillStationReview{}, nil
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -83,25 +98,27 @@ func GetRefillStationReviewsByUserId(c *gin.Context) {
 // @Produce json
 // @Param review body database.RefillStationReview true "Refill Station Review"
 // @Success 201 {object} database.RefillStationReview
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
 // @Router /refill_station_reviews [post]
-func CreateRefillStationReview(c *gin.Context) {
+func (s *Server) CreateRefillStationReview(c *gin.Context) {
 	var review database.RefillStationReview
 	if err := c.ShouldBindJSON(&review); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	review.UserID = CurrentUser(c).ID
 
 	// Check if the user has already reviewed this station
-	var existingReview database.RefillStationReview
-	result := db.Where("user_id = ? AND station_id = ?", review.UserID, review.StationID).First(&existingReview)
-	if result.Error == nil {
+	existingReview, err := s.reviews.GetByUserAndStation(c.Request.Context(), review.UserID, review.StationID)
+	if err == nil {
 		// User has already reviewed this station, update the existing review
 		existingReview.Cleanness = review.Cleanness
 		existingReview.Accessibility = review.Accessibility
 		existingReview.WaterQuality = review.WaterQuality
 		existingReview.Timestamp = time.Now()
 
-		if err := db.Save(&existingReview).Error; err != nil {
+		if err := s.reviews.Save(c.Request.Context(), existingReview); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -109,7 +126,7 @@ func CreateRefillStationReview(c *gin.Context) {
 	} else {
 		// No existing review found, create a new one
 		review.Timestamp = time.Now()
-		if err := db.Create(&review).Error; err != nil {
+		if err := s.reviews.Create(c.Request.Context(), &review); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -124,22 +141,42 @@ func CreateRefillStationReview(c *gin.Context) {
 // @Produce json
 // @Param review body database.RefillStationReview true "Refill Station Review"
 // @Success 200 {object} database.RefillStationReview
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_station_reviews [put]
-func UpdateRefillStationReview(c *gin.Context) {
+func (s *Server) UpdateRefillStationReview(c *gin.Context) {
 	var requestReview database.RefillStationReview
 	if err := c.ShouldBindJSON(&requestReview); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	var review database.RefillStationReview
-	if err := db.Where("id = ?", requestReview.ID).First(&review).Error; err != nil {
+	stored, err := s.reviews.Get(c.Request.Context(), requestReview.ID)
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
 		return
 	}
-	requestReview.Timestamp = time.Now()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, stored.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
+	}
 
-	db.Model(&review).Updates(requestReview)
+	// The review stays with its user
+	requestReview.UserID = 0
+	requestReview.Timestamp = time.Now()
+	if _, err := s.reviews.Update(c.Request.Context(), &requestReview); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Record not found!"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, requestReview)
 }
@@ -151,8 +188,11 @@ func UpdateRefillStationReview(c *gin.Context) {
 // @Produce json
 // @Param id path int true "Refill Station Review ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /refill_station_reviews/:id [delete]
-func DeleteRefillStationReview(c *gin.Context) {
+func (s *Server) DeleteRefillStationReview(c *gin.Context) {
 	idStr := c.Param("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -164,16 +204,26 @@ func DeleteRefillStationReview(c *gin.Context) {
 		return
 	}
 
-	// Check for record not found error
-	var tempReview database.RefillStationReview
-	result := db.First(&tempReview, id)
-	if result.Error != nil {
+	review, err := s.reviews.Get(c.Request.Context(), uint(id))
+	if errors.Is(err, repository.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Review with ID not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !isOwnerOr(c, review.UserID, auth.ModerateContent) {
+		respondForbidden(c)
+		return
 	}
 
-	result = db.Delete(&database.RefillStationReview{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.reviews.Delete(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Review with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
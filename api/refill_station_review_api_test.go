@@ -0,0 +1,63 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestRefillStationReviewRoutes(t *testing.T) {
+	newReview := map[string]interface{}{"station_id": 3, "user_id": 2, "cleanness": 4, "accessibility": 4, "water_quality": 5}
+	existingReview := map[string]interface{}{"station_id": 1, "user_id": 1, "cleanness": 1, "accessibility": 1, "water_quality": 1}
+	invalidRating := map[string]interface{}{"station_id": 3, "user_id": 2, "cleanness": 6, "accessibility": 4, "water_quality": 5}
+
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/refill_station_reviews", nil, http.StatusOK, 0},
+		{"by user and station", http.MethodGet, "/refill_station_reviews/1/1", nil, http.StatusOK, 0},
+		{"by user and station without review", http.MethodGet, "/refill_station_reviews/99/1", nil, http.StatusOK, 0},
+		{"by invalid user id", http.MethodGet, "/refill_station_reviews/abc/1", nil, http.StatusBadRequest, 0},
+		{"by invalid station id", http.MethodGet, "/refill_station_reviews/1/abc", nil, http.StatusBadRequest, 0},
+		{"create", http.MethodPost, "/refill_station_reviews", newReview, http.StatusCreated, 2},
+		{"create replaces existing review", http.MethodPost, "/refill_station_reviews", existingReview, http.StatusOK, 1},
+		{"create invalid rating", http.MethodPost, "/refill_station_reviews", invalidRating, http.StatusInternalServerError, 2},
+		{"create malformed body", http.MethodPost, "/refill_station_reviews", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/refill_station_reviews", map[string]interface{}{"id": 1, "cleanness": 2}, http.StatusOK, 1},
+		{"update invalid rating", http.MethodPut, "/refill_station_reviews", map[string]interface{}{"id": 1, "cleanness": 9}, http.StatusInternalServerError, 1},
+		{"update unknown id", http.MethodPut, "/refill_station_reviews", map[string]interface{}{"id": 999, "cleanness": 2}, http.StatusBadRequest, 1},
+		{"delete", http.MethodDelete, "/refill_station_reviews/1", nil, http.StatusNoContent, 1},
+		{"delete invalid id", http.MethodDelete, "/refill_station_reviews/abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/refill_station_reviews/999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestRefillStationReviewPayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	reviews := decodeResponse[api.ListPage[database.RefillStationReview]](t, doRequest(t, r, http.MethodGet, "/refill_station_reviews", nil))
+	if reviews.Total != 7 || len(reviews.Items) != 7 {
+		t.Fatalf("expected 7 seeded reviews, got %d", len(reviews.Items))
+	}
+
+	review := decodeResponse[database.RefillStationReview](t, doRequest(t, r, http.MethodGet, "/refill_station_reviews/1/1", nil))
+	if review.ID != 1 || review.Cleanness != 4 || review.Accessibility != 5 || review.WaterQuality != 3 {
+		t.Errorf("unexpected review of user 1 for station 1: %+v", review)
+	}
+
+	empty := decodeResponse[database.RefillStationReview](t, doRequest(t, r, http.MethodGet, "/refill_station_reviews/99/1", nil))
+	if empty.ID != 0 {
+		t.Errorf("expected empty review for a user without review, got %+v", empty)
+	}
+
+	// A second review of the same user for the same station replaces the first one
+	replaced := decodeResponse[database.RefillStationReview](t, doAuthRequest(t, r, login(t, r, 1), http.MethodPost, "/refill_station_reviews",
+		map[string]interface{}{"station_id": 1, "user_id": 1, "cleanness": 1, "accessibility": 2, "water_quality": 3}))
+	if replaced.ID != 1 || replaced.Cleanness != 1 || replaced.Accessibility != 2 {
+		t.Errorf("expected review 1 to be replaced, got %+v", replaced)
+	}
+	reviews = decodeResponse[api.ListPage[database.RefillStationReview]](t, doRequest(t, r, http.MethodGet, "/refill_station_reviews", nil))
+	if reviews.Total != 7 {
+		t.Errorf("expected replacing a review to keep 7 reviews, got %d", reviews.Total)
+	}
+}
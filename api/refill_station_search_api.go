@@ -0,0 +1,373 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// StationSearchResult is a station with its review averages, like count and,
+// when searching around a position, its distance. AverageRating is the mean of
+// the three review averages.
+type StationSearchResult struct {
+	database.RefillStation
+	DistanceMeters       *float64 `json:"distance_m,omitempty"`
+	ReviewCount          int64    `json:"review_count"`
+	AverageCleanness     float64  `json:"average_cleanness"`
+	AverageAccessibility float64  `json:"average_accessibility"`
+	AverageWaterQuality  float64  `json:"average_water_quality"`
+	AverageRating        float64  `json:"average_rating"`
+	LikeCount            int64    `json:"like_count"`
+	LikedByMe            *bool    `json:"liked_by_me,omitempty"`
+}
+
+// StationSearchPage is a page of search results, NextCursor requests the following page
+type StationSearchPage struct {
+	Items      []StationSearchResult `json:"items"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// Page sizes of the search
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// stationSortFields are the sort orders of the search, distance needs a position
+var stationSortFields = []string{"id", "name", "distance", "rating", "likes"}
+
+// stationSearch are the parsed query parameters of a search
+type stationSearch struct {
+	filter           repository.StationFilter
+	openNow          *bool
+	liked            *bool
+	lat, lon         float64
+	hasPosition      bool
+	radius           float64
+	minCleanness     float64
+	minAccessibility float64
+	minWaterQuality  float64
+	minRating        float64
+	minLikes         int64
+	sort             string
+	descending       bool
+	limit            int
+	after            *searchCursor
+}
+
+// searchCursor marks the last result of a page by its sort value and ID
+type searchCursor struct {
+	Sort   string  `json:"s"`
+	Number float64 `json:"n,omitempty"`
+	Text   string  `json:"t,omitempty"`
+	ID     uint    `json:"id"`
+}
+
+// @Summary Search refill stations
+// @Description Filter stations by type, offered water, state, opening state, ratings, likes, distance and text, sorted and paginated with a cursor. liked needs an access token.
+// @Tags Refill Stations
+// @Accept json
+// @Produce json
+// @Param q query string false "Text in the name, description or address"
+// @Param type query string false "Station type" Enums(manual, smart)
+// @Param water query string false "Offered water, stations offering both match mineral and tap" Enums(mineral, tap, both)
+// @Param active query bool false "Only active or inactive stations"
+// @Param open_now query bool false "Only stations that are open or closed now"
+// @Param lat query number false "Latitude of the position to measure distances from"
+// @Param lon query number false "Longitude of the position to measure distances from"
+// @Param radius_m query number false "Only stations within the radius in meters, needs lat and lon"
+// @Param min_cleanness query number false "Minimum average cleanness"
+// @Param min_accessibility query number false "Minimum average accessibility"
+// @Param min_water_quality query number false "Minimum average water quality"
+// @Param min_rating query number false "Minimum mean of the three averages"
+// @Param min_likes query int false "Minimum number of likes"
+// @Param liked query bool false "Only stations the authenticated user liked or did not like"
+// @Param sort query string false "id, name, distance, rating or likes, prefixed with - to sort descending, distance by default with a position and id otherwise"
+// @Param limit query int false "Page size, at most 100" default(20)
+// @Param cursor query string false "next_cursor of the previous page"
+// @Success 200 {object} StationSearchPage
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /refill_stations/search [get]
+func (s *Server) SearchRefillStations(c *gin.Context) {
+	search, err := parseStationSearch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user := CurrentUser(c)
+	if search.liked != nil && user == nil {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "liked requires an access token"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	stations, err := s.stations.Search(ctx, search.filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	averages, err := s.reviews.AveragesByStation(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	likeCounts, err := s.likes.CountsByStation(ctx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var likedIDs []uint
+	if user != nil {
+		if likedIDs, err = s.likes.ListStationIDsByUser(ctx, user.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	now := time.Now()
+	results := []StationSearchResult{}
+	for _, station := range stations {
+		station.SetOpeningState(now)
+		average := averages[station.ID]
+		result := StationSearchResult{
+			RefillStation:        station,
+			ReviewCount:          average.Count,
+			AverageCleanness:     average.Cleanness,
+			AverageAccessibility: average.Accessibility,
+			AverageWaterQuality:  average.WaterQuality,
+			AverageRating:        (average.Cleanness + average.Accessibility + average.WaterQuality) / 3,
+			LikeCount:            likeCounts[station.ID],
+		}
+		if user != nil {
+			liked := slices.Contains(likedIDs, station.ID)
+			result.LikedByMe = &liked
+		}
+		if search.hasPosition {
+			distance := geo.Distance(search.lat, search.lon, station.Latitude, station.Longitude)
+			result.DistanceMeters = &distance
+		}
+		if search.matches(result) {
+			results = append(results, result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool { return search.compare(search.key(results[i]), search.key(results[j])) < 0 })
+	if search.after != nil {
+		start := sort.Search(len(results), func(i int) bool { return search.compare(search.key(results[i]), *search.after) > 0 })
+		results = results[start:]
+	}
+
+	page := StationSearchPage{Items: results}
+	if len(results) > search.limit {
+		page.Items = results[:search.limit]
+		page.NextCursor = encodeSearchCursor(search.key(page.Items[search.limit-1]))
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// matches applies the filters that need the opening state, the distance, the reviews or the likes
+func (search *stationSearch) matches(result StationSearchResult) bool {
+	if search.openNow != nil && (result.IsOpenNow == nil || *result.IsOpenNow != *search.openNow) {
+		return false
+	}
+	if search.liked != nil && *result.LikedByMe != *search.liked {
+		return false
+	}
+	if search.radius > 0 && *result.DistanceMeters > search.radius {
+		return false
+	}
+	return result.AverageCleanness >= search.minCleanness &&
+		result.AverageAccessibility >= search.minAccessibility &&
+		result.AverageWaterQuality >= search.minWaterQuality &&
+		result.AverageRating >= search.minRating &&
+		result.LikeCount >= search.minLikes
+}
+
+// key returns the sort value of the result as a cursor
+func (search *stationSearch) key(result StationSearchResult) searchCursor {
+	key := searchCursor{Sort: search.sortParam(), ID: result.ID}
+	switch search.sort {
+	case "name":
+		key.Text = strings.ToLower(result.Name)
+	case "distance":
+		key.Number = *result.DistanceMeters
+	case "rating":
+		key.Number = result.AverageRating
+	case "likes":
+		key.Number = float64(result.LikeCount)
+	}
+	return key
+}
+
+// compare orders by the sort value and then by ID, both reversed when descending
+func (search *stationSearch) compare(a, b searchCursor) int {
+	order := strings.Compare(a.Text, b.Text)
+	if order == 0 && a.Number != b.Number {
+		order = 1
+		if a.Number < b.Number {
+			order = -1
+		}
+	}
+	if order == 0 && a.ID != b.ID {
+		order = 1
+		if a.ID < b.ID {
+			order = -1
+		}
+	}
+	if search.descending {
+		return -order
+	}
+	return order
+}
+
+func (search *stationSearch) sortParam() string {
+	if search.descending {
+		return "-" + search.sort
+	}
+	return search.sort
+}
+
+func encodeSearchCursor(cursor searchCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeSearchCursor(value string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor searchCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// parseStationSearch reads and validates the query parameters of a search
+func parseStationSearch(c *gin.Context) (stationSearch, error) {
+	search := stationSearch{limit: defaultSearchLimit}
+	var err error
+
+	search.filter.Text = strings.TrimSpace(c.Query("q"))
+	if search.filter.Type = c.Query("type"); search.filter.Type != "" && !slices.Contains(database.StationTypes, search.filter.Type) {
+		return search, fmt.Errorf("type must be one of %v", database.StationTypes)
+	}
+	if search.filter.WaterType = c.Query("water"); search.filter.WaterType != "" && !slices.Contains(database.StationOfferedWaterTypes, search.filter.WaterType) {
+		return search, fmt.Errorf("water must be one of %v", database.StationOfferedWaterTypes)
+	}
+	if search.filter.Active, err = queryBool(c, "active"); err != nil {
+		return search, err
+	}
+	if search.openNow, err = queryBool(c, "open_now"); err != nil {
+		return search, err
+	}
+	if search.liked, err = queryBool(c, "liked"); err != nil {
+		return search, err
+	}
+
+	latStr, lonStr := c.Query("lat"), c.Query("lon")
+	if latStr != "" || lonStr != "" {
+		search.lat, err = strconv.ParseFloat(latStr, 64)
+		if err != nil || search.lat < -90 || search.lat > 90 {
+			return search, errors.New("lat must be a latitude between -90 and 90")
+		}
+		search.lon, err = strconv.ParseFloat(lonStr, 64)
+		if err != nil || search.lon < -180 || search.lon > 180 {
+			return search, errors.New("lon must be a longitude between -180 and 180")
+		}
+		search.hasPosition = true
+	}
+	if radiusStr := c.Query("radius_m"); radiusStr != "" {
+		search.radius, err = strconv.ParseFloat(radiusStr, 64)
+		if err != nil || search.radius <= 0 || search.radius > maxNearbyRadius {
+			return search, fmt.Errorf("radius_m must be between 0 and %d", maxNearbyRadius)
+		}
+		if !search.hasPosition {
+			return search, errors.New("radius_m needs lat and lon")
+		}
+		box := geo.BoundingBox(search.lat, search.lon, search.radius)
+		search.filter.Box = &box
+	}
+
+	for _, threshold := range []struct {
+		name  string
+		value *float64
+	}{
+		{"min_cleanness", &search.minCleanness},
+		{"min_accessibility", &search.minAccessibility},
+		{"min_water_quality", &search.minWaterQuality},
+		{"min_rating", &search.minRating},
+	} {
+		if valueStr := c.Query(threshold.name); valueStr != "" {
+			*threshold.value, err = strconv.ParseFloat(valueStr, 64)
+			if err != nil || *threshold.value < 1 || *threshold.value > 5 {
+				return search, fmt.Errorf("%s must be a rating between 1 and 5", threshold.name)
+			}
+		}
+	}
+	if minLikesStr := c.Query("min_likes"); minLikesStr != "" {
+		search.minLikes, err = strconv.ParseInt(minLikesStr, 10, 64)
+		if err != nil || search.minLikes < 0 {
+			return search, errors.New("min_likes must be a non-negative number")
+		}
+	}
+
+	search.sort = "id"
+	if search.hasPosition {
+		search.sort = "distance"
+	}
+	if sortStr := c.Query("sort"); sortStr != "" {
+		search.sort, search.descending = strings.TrimPrefix(sortStr, "-"), strings.HasPrefix(sortStr, "-")
+		if !slices.Contains(stationSortFields, search.sort) {
+			return search, fmt.Errorf("sort must be one of %v", stationSortFields)
+		}
+		if search.sort == "distance" && !search.hasPosition {
+			return search, errors.New("sorting by distance needs lat and lon")
+		}
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		search.limit, err = strconv.Atoi(limitStr)
+		if err != nil || search.limit < 1 || search.limit > maxSearchLimit {
+			return search, fmt.Errorf("limit must be between 1 and %d", maxSearchLimit)
+		}
+	}
+	if cursorStr := c.Query("cursor"); cursorStr != "" {
+		if search.after, err = decodeSearchCursor(cursorStr); err != nil {
+			return search, err
+		}
+		if search.after.Sort != search.sortParam() {
+			return search, errors.New("cursor belongs to another sort order")
+		}
+	}
+	return search, nil
+}
+
+// queryBool parses an optional boolean query parameter
+func queryBool(c *gin.Context, name string) (*bool, error) {
+	valueStr := c.Query(name)
+	if valueStr == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be true or false", name)
+	}
+	return &value, nil
+}
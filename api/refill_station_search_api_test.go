@@ -0,0 +1,127 @@
+package api_test
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+)
+
+func TestRefillStationSearchRoutes(t *testing.T) {
+	runRouteCases(t, []routeCase{
+		{"search", http.MethodGet, "/refill_stations/search", nil, http.StatusOK, 0},
+		{"search liked", http.MethodGet, "/refill_stations/search?liked=true", nil, http.StatusOK, 3},
+		{"search liked without token", http.MethodGet, "/refill_stations/search?liked=true", nil, http.StatusUnauthorized, 0},
+		{"search invalid type", http.MethodGet, "/refill_stations/search?type=robot", nil, http.StatusBadRequest, 0},
+		{"search invalid water", http.MethodGet, "/refill_stations/search?water=juice", nil, http.StatusBadRequest, 0},
+		{"search invalid active", http.MethodGet, "/refill_stations/search?active=maybe", nil, http.StatusBadRequest, 0},
+		{"search latitude only", http.MethodGet, "/refill_stations/search?lat=49.44", nil, http.StatusBadRequest, 0},
+		{"search radius without position", http.MethodGet, "/refill_stations/search?radius_m=500", nil, http.StatusBadRequest, 0},
+		{"search distance without position", http.MethodGet, "/refill_stations/search?sort=distance", nil, http.StatusBadRequest, 0},
+		{"search invalid sort", http.MethodGet, "/refill_stations/search?sort=size", nil, http.StatusBadRequest, 0},
+		{"search rating out of range", http.MethodGet, "/refill_stations/search?min_rating=6", nil, http.StatusBadRequest, 0},
+		{"search negative likes", http.MethodGet, "/refill_stations/search?min_likes=-1", nil, http.StatusBadRequest, 0},
+		{"search invalid limit", http.MethodGet, "/refill_stations/search?limit=0", nil, http.StatusBadRequest, 0},
+		{"search invalid cursor", http.MethodGet, "/refill_stations/search?cursor=nope", nil, http.StatusBadRequest, 0},
+	})
+}
+
+// searchIDs runs a search and returns the IDs of the found stations in order
+func searchIDs(t *testing.T, r http.Handler, token, query string) []uint {
+	t.Helper()
+	w := doAuthRequest(t, r, token, http.MethodGet, "/refill_stations/search?"+query, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("search %q: expected status 200, got %d: %s", query, w.Code, w.Body.String())
+	}
+	page := decodeResponse[api.StationSearchPage](t, w)
+	ids := []uint{}
+	for _, station := range page.Items {
+		ids = append(ids, station.ID)
+	}
+	return ids
+}
+
+func TestSearchRefillStations(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 3)
+	stadtpark := "lat=49.437551349217266&lon=7.761465081072085"
+
+	cases := []struct {
+		name  string
+		token string
+		query string
+		want  []uint
+	}{
+		{"by type", "", "type=manual", []uint{2, 11}},
+		{"by offered water", "", "water=mineral", []uint{1, 5, 6, 7, 9, 10, 11, 12}},
+		{"by rating threshold", "", "type=smart&water=mineral&min_water_quality=4", []uint{1}},
+		{"by mean rating descending", "", "min_rating=3&sort=-rating", []uint{1, 3, 2}},
+		{"by likes", "", "min_likes=4", []uint{1, 2}},
+		{"liked by me", token, "liked=true", []uint{1, 2}},
+		{"by text", "", "q=FRUCHTHALL", []uint{2, 5}},
+		{"by text with wildcards", "", "q=%25", []uint{}},
+		{"by distance", "", stadtpark + "&radius_m=600", []uint{1, 4, 10}},
+		{"by name", "", "type=manual&sort=-name", []uint{11, 2}},
+		{"everything combined", token, stadtpark + "&radius_m=2000&active=true&type=smart&water=mineral&min_water_quality=4&liked=true", []uint{1}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := searchIDs(t, r, tc.token, tc.query); !slices.Equal(got, tc.want) {
+				t.Errorf("expected stations %v, got %v", tc.want, got)
+			}
+		})
+	}
+
+	page := decodeResponse[api.StationSearchPage](t, doAuthRequest(t, r, token, http.MethodGet, "/refill_stations/search?"+stadtpark+"&limit=1", nil))
+	station := page.Items[0]
+	if station.ID != 1 || *station.DistanceMeters != 0 || station.ReviewCount != 2 || station.AverageCleanness != 4.5 ||
+		station.AverageRating < 4.33 || station.AverageRating > 4.34 || station.LikeCount != 4 || !*station.LikedByMe || station.IsOpenNow == nil {
+		t.Errorf("unexpected search result: %+v", station)
+	}
+	if page.NextCursor == "" {
+		t.Error("expected a cursor to the next page")
+	}
+}
+
+func TestSearchRefillStationsPagination(t *testing.T) {
+	r := newTestRouter(t)
+
+	for _, sort := range []string{"id", "-likes", "name", "distance"} {
+		query := url.Values{"sort": {sort}, "lat": {"49.4445"}, "lon": {"7.7690"}}
+		all := searchIDs(t, r, "", query.Encode())
+		if len(all) != 12 {
+			t.Fatalf("expected 12 stations sorted by %s, got %v", sort, all)
+		}
+
+		var paged []uint
+		query.Set("limit", "5")
+		for pages := 0; ; pages++ {
+			if pages > 3 {
+				t.Fatalf("expected 3 pages sorted by %s", sort)
+			}
+			page := decodeResponse[api.StationSearchPage](t, doRequest(t, r, http.MethodGet, "/refill_stations/search?"+query.Encode(), nil))
+			for _, station := range page.Items {
+				paged = append(paged, station.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			query.Set("cursor", page.NextCursor)
+		}
+		if !slices.Equal(paged, all) {
+			t.Errorf("expected the pages sorted by %s to add up to %v, got %v", sort, all, paged)
+		}
+	}
+
+	// Stations 1 and 2 have the most likes, ties are broken by ID
+	if got := searchIDs(t, r, "", "sort=-likes&limit=3"); !slices.Equal(got, []uint{2, 1, 12}) {
+		t.Errorf("expected stations 2, 1 and 12 first, got %v", got)
+	}
+
+	page := decodeResponse[api.StationSearchPage](t, doRequest(t, r, http.MethodGet, "/refill_stations/search?limit=2", nil))
+	if w := doRequest(t, r, http.MethodGet, "/refill_stations/search?sort=name&cursor="+page.NextCursor, nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a cursor of another sort order to be rejected, got %d", w.Code)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTextQueryLength bounds the work of matching every query word against the index
+const maxTextQueryLength = 200
+
+// @Summary Full-text search of refill stations
+// @Description Search the name, address, water source and description of the stations. Every word must match, by its German word stem, as a prefix, as part of a compound word or with a typo. Results are ordered by relevance, matches in the name rank highest.
+// @Tags Refill Stations
+// @Accept json
+// @Produce json
+// @Param q query string true "Search words"
+// @Param limit query int false "Maximum number of stations, at most 100" default(20)
+// @Success 200 {array} repository.ScoredStation
+// @Failure 400 {object} map[string]string
+// @Router /refill_stations/text_search [get]
+func (s *Server) TextSearchRefillStations(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" || len(query) > maxTextQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("q must have between 1 and %d characters", maxTextQueryLength)})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultSearchLimit)))
+	if err != nil || limit < 1 || limit > maxSearchLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxSearchLimit)})
+		return
+	}
+
+	stations, err := s.stations.TextSearch(c.Request.Context(), query, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	for i := range stations {
+		stations[i].SetOpeningState(now)
+	}
+	c.JSON(http.StatusOK, stations)
+}
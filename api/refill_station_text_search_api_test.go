@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+func TestRefillStationTextSearchRoutes(t *testing.T) {
+	runRouteCases(t, []routeCase{
+		{"text search", http.MethodGet, "/refill_stations/text_search?q=park", nil, http.StatusOK, 0},
+		{"text search without query", http.MethodGet, "/refill_stations/text_search", nil, http.StatusBadRequest, 0},
+		{"text search blank query", http.MethodGet, "/refill_stations/text_search?q=%20%20", nil, http.StatusBadRequest, 0},
+		{"text search invalid limit", http.MethodGet, "/refill_stations/text_search?q=park&limit=0", nil, http.StatusBadRequest, 0},
+		{"text search limit too large", http.MethodGet, "/refill_stations/text_search?q=park&limit=101", nil, http.StatusBadRequest, 0},
+	})
+}
+
+func TestTextSearchRefillStations(t *testing.T) {
+	r := newTestRouter(t)
+
+	cases := []struct {
+		name  string
+		query string
+		want  []uint
+	}{
+		{"by name", "Stadtpark", []uint{1}},
+		{"by address", "Trippstadter Str.", []uint{1}},
+		{"by inflected word", "Gartenschauen", []uint{3}},
+		{"by prefix", "Fruchthallstr", []uint{2, 5}},
+		{"by umlaut transcription", "Koenigstrasse", []uint{4}},
+		{"by water source", "Georgsbrunnen", []uint{3}},
+		{"by part of a compound word", "rainbrunnen", []uint{1, 2}},
+		{"with a typo", "Stadpark", []uint{1}},
+		{"every word must match", "Richard Wagner Buchhandlung", []uint{10}},
+		{"no match", "Berlin", []uint{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := doRequest(t, r, http.MethodGet, "/refill_stations/text_search?q="+url.QueryEscape(tc.query), nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+			}
+			ids := []uint{}
+			for _, station := range decodeResponse[[]repository.ScoredStation](t, w) {
+				ids = append(ids, station.ID)
+			}
+			if !slices.Equal(ids, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, ids)
+			}
+		})
+	}
+
+	t.Run("ranks name above description", func(t *testing.T) {
+		stations := decodeResponse[[]repository.ScoredStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/text_search?q=Gartenschau", nil))
+		if len(stations) == 0 || stations[0].ID != 3 || stations[0].Score <= 0 {
+			t.Fatalf("expected station 3 first with a positive score, got %+v", stations)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		stations := decodeResponse[[]repository.ScoredStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/text_search?q=Kaiserslautern&limit=3", nil))
+		if len(stations) != 3 {
+			t.Errorf("expected 3 stations, got %d", len(stations))
+		}
+	})
+}
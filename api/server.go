@@ -0,0 +1,148 @@
+package api
+
+import (
+	"github.com/PoseidonPSE2/code_backend/auth"
+	"github.com/PoseidonPSE2/code_backend/ingest"
+	"github.com/PoseidonPSE2/code_backend/media"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/PoseidonPSE2/code_backend/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// Server serves the HTTP API on top of the repositories
+type Server struct {
+	users        repository.UserRepository
+	bottles      repository.BottleRepository
+	stations     repository.RefillStationRepository
+	reviews      repository.RefillStationReviewRepository
+	problems     repository.RefillStationProblemRepository
+	transactions repository.WaterTransactionRepository
+	likes        repository.LikeRepository
+	tokens       repository.RefreshTokenRepository
+	dispenses    repository.DispenseRepository
+	devices      repository.DeviceRepository
+	telemetry    repository.TelemetryRepository
+	recorder     *ingest.Service
+	monitor      *telemetry.Service
+	images       *media.Images
+	issuer       *auth.TokenIssuer
+}
+
+// NewServer creates a server using the given repositories, keeping image files
+// in images and signing access tokens with issuer
+func NewServer(repos repository.Repositories, images *media.Images, issuer *auth.TokenIssuer) *Server {
+	return &Server{
+		users:        repos.Users,
+		bottles:      repos.Bottles,
+		stations:     repos.Stations,
+		reviews:      repos.Reviews,
+		problems:     repos.Problems,
+		transactions: repos.Transactions,
+		likes:        repos.Likes,
+		tokens:       repos.Tokens,
+		dispenses:    repos.Dispenses,
+		devices:      repos.Devices,
+		telemetry:    repos.Telemetry,
+		recorder:     ingest.NewService(repos),
+		monitor:      telemetry.NewService(repos),
+		images:       images,
+		issuer:       issuer,
+	}
+}
+
+// RegisterRoutes registers all API routes on the router.
+// Routes changing data require an access token, see RequireAuth, except
+// the water transactions, dispenses and telemetry posted by the refill stations, which
+// require a device API key, see RequireDevice. Some routes also need a role
+// permission, see RequirePermission, and handlers check ownership.
+func (s *Server) RegisterRoutes(r gin.IRouter) {
+	authed := s.RequireAuth
+	can := s.RequirePermission
+	device := s.RequireDevice
+
+	r.POST("/auth/register", s.Register)
+	r.POST("/auth/login", s.Login)
+	r.POST("/auth/refresh", s.RefreshTokens)
+	r.POST("/auth/logout", authed, s.Logout)
+	r.GET("/auth/me", authed, s.GetCurrentUser)
+
+	r.GET("/users", s.OptionalAuth, s.GetUsers)
+	r.POST("/users", authed, can(auth.ManageUsers), s.CreateUser)
+	r.PUT("/users", authed, s.UpdateUser)
+	r.DELETE("/users", authed, s.DeleteUser)
+	r.PUT("/users/:id/role", authed, can(auth.ManageUsers), s.SetUserRole)
+
+	r.GET("/bottles", s.GetBottles)
+	r.GET("/bottles/:id", s.GetBottleById)
+	r.GET("/bottles/image/:id", s.GetBottleImageById)
+	r.GET("/bottles/users/:userId", s.GetBottlesByUserID)
+	r.GET("/bottles/preferences/:nfcId", s.GetBottlePreferencesByNFCId)
+	r.POST("/bottles", authed, s.CreateBottle)
+	r.GET("/bottles/:id/image", s.GetBottleImageFile)
+	r.POST("/bottles/:id/image", authed, s.UploadBottleImage)
+	r.PUT("/bottles", authed, s.UpdateBottle)
+	r.DELETE("/bottles/:id", authed, s.DeleteBottle)
+
+	r.GET("/refill_stations", s.GetRefillStations)
+	r.GET("/refill_stations/markers", s.GetAllRefillstationMarker)
+	r.GET("/refill_stations/nearby", s.GetNearbyRefillStations)
+	r.GET("/refill_stations/search", s.OptionalAuth, s.SearchRefillStations)
+	r.GET("/refill_stations/text_search", s.TextSearchRefillStations)
+	r.GET("/refill_stations/:id", s.GetRefillStationById)
+	r.GET("/refill_stations/image/:id", s.GetRefillStationImageById)
+	r.GET("/refill_stations/:id/reviews", s.GetRefillStationReviewsAverageByID)
+	r.POST("/refill_stations", authed, can(auth.ManageStations), s.CreateRefillStation)
+	r.GET("/refill_stations/:id/image", s.GetRefillStationImageFile)
+	r.POST("/refill_stations/:id/image", authed, can(auth.OperateStations), s.UploadRefillStationImage)
+	r.PUT("/refill_stations", authed, can(auth.OperateStations), s.UpdateRefillStation)
+	r.DELETE("/refill_stations/:id", authed, can(auth.ManageStations), s.DeleteRefillStation)
+	r.PUT("/refill_stations/:id/active", authed, can(auth.OperateStations), s.SetRefillStationActive)
+	r.GET("/refill_stations/:id/status_changes", authed, can(auth.OperateStations), s.GetRefillStationStatusChanges)
+	r.GET("/refill_stations/:id/uptime", authed, can(auth.OperateStations), s.GetRefillStationUptime)
+	r.GET("/refill_stations/:id/devices", authed, can(auth.OperateStations), s.GetRefillStationDevices)
+	r.POST("/refill_stations/:id/devices", authed, can(auth.OperateStations), s.RegisterDevice)
+	r.POST("/refill_stations/:id/dispenses", device, s.AuthorizeDispense)
+	r.POST("/refill_stations/:id/dispenses/:dispenseId/confirm", device, s.ConfirmDispense)
+	r.GET("/refill_stations/:id/telemetry", authed, can(auth.OperateStations), s.GetRefillStationTelemetry)
+	r.POST("/refill_stations/:id/telemetry", device, s.ReportTelemetry)
+
+	r.GET("/refill_station_reviews", s.GetRefillStationReviews)
+	r.GET("/refill_station_reviews/:userId/:stationId", s.GetRefillStationReviewsByUserId)
+	r.POST("/refill_station_reviews", authed, s.CreateRefillStationReview)
+	r.PUT("/refill_station_reviews", authed, s.UpdateRefillStationReview)
+	r.DELETE("/refill_station_reviews/:id", authed, s.DeleteRefillStationReview)
+
+	r.GET("/refill_station_problems", s.GetRefillStationProblems)
+	r.GET("/refill_station_problems/:id", s.GetRefillStationProblemById)
+	r.POST("/refill_station_problems", authed, s.CreateRefillStationProblem)
+	r.GET("/refill_station_problems/:id/image", s.GetRefillStationProblemImageFile)
+	r.GET("/refill_station_problems/:id/timeline", s.GetRefillStationProblemTimeline)
+	r.POST("/refill_station_problems/:id/transitions", authed, can(auth.OperateStations), s.TransitionRefillStationProblem)
+	r.POST("/refill_station_problems/:id/image", authed, can(auth.OperateStations), s.UploadRefillStationProblemImage)
+	r.PUT("/refill_station_problems", authed, can(auth.OperateStations), s.UpdateRefillStationProblem)
+	r.DELETE("/refill_station_problems/:id", authed, can(auth.OperateStations), s.DeleteRefillStationProblem)
+
+	r.GET("/water_transactions", s.GetWaterTransactions)
+	r.POST("/water_transactions", device, s.CreateWaterTransaction)
+	r.POST("/water_transactions/batch", device, s.CreateWaterTransactionBatch)
+	r.PUT("/water_transactions", authed, can(auth.ModerateContent), s.UpdateWaterTransaction)
+	r.DELETE("/water_transactions", authed, can(auth.ModerateContent), s.DeleteWaterTransaction)
+
+	r.GET("/likes", s.GetLikes)
+	r.GET("/likes/:refillstationId/count", s.GetLikesCounterForStation)
+	r.GET("/likes/:refillstationId/:userId", s.GetLikeByUserIdAndStationID)
+	r.POST("/likes", authed, s.CreateLike)
+	r.PUT("/likes", authed, s.UpdateLike)
+	r.DELETE("/likes", authed, s.DeleteLike)
+
+	r.GET("/contribution/user/:id", s.GetContributionByUser)
+	r.GET("/contribution/community", s.GetContributionCommunity)
+	r.GET("/contribution/kl", s.GetContributionKL)
+
+	r.GET("/images/:id", s.GetImage)
+
+	r.DELETE("/devices/:id", authed, can(auth.OperateStations), s.RevokeDevice)
+	r.GET("/devices/:id/keys", authed, can(auth.OperateStations), s.GetDeviceKeys)
+	r.POST("/devices/:id/keys", authed, can(auth.OperateStations), s.RotateDeviceKey)
+	r.DELETE("/devices/:id/keys/:keyId", authed, can(auth.OperateStations), s.RevokeDeviceKey)
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// Ranges of the status history and uptime routes
+const (
+	defaultStatusRange = 30 * 24 * time.Hour
+	maxStatusRange     = 366 * 24 * time.Hour
+)
+
+type StationStatusUpdate struct {
+	Active *bool  `json:"active" binding:"required"`
+	Reason string `json:"reason" binding:"required,max=255"`
+}
+
+// Outage is an interval in which a refill station was inactive, clipped to the requested
+// range. End is left out if the outage lasted beyond the range. Source, Reason and the
+// actors are those of the deactivation, if it was recorded.
+type Outage struct {
+	Start           time.Time  `json:"start"`
+	End             *time.Time `json:"end,omitempty"`
+	DurationSeconds int64      `json:"duration_seconds"`
+	Source          string     `json:"source,omitempty"`
+	Reason          string     `json:"reason,omitempty"`
+	ActorID         *uint      `json:"actor_id,omitempty"`
+	DeviceID        *uint      `json:"device_id,omitempty"`
+}
+
+// StationUptime reports the share of the range in which a refill station was active, in percent
+type StationUptime struct {
+	StationID       uint      `json:"station_id"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Availability    float64   `json:"availability"`
+	UptimeSeconds   int64     `json:"uptime_seconds"`
+	DowntimeSeconds int64     `json:"downtime_seconds"`
+	Outages         []Outage  `json:"outages"`
+}
+
+// @Summary Activate or deactivate a refill station
+// @Description Set the active state of a refill station and record the change with its reason.
+// @Description Heartbeats of the station devices no longer change the state until the monitor deactivates it again.
+// @Tags Refill Stations
+// @Accept json
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param status body StationStatusUpdate true "Status"
+// @Success 200 {object} database.RefillStation
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/active [put]
+func (s *Server) SetRefillStationActive(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	var update StationStatusUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.setActive(c, database.StationStatusChange{StationID: station.ID, Active: *update.Active, Source: database.StatusSourceManual, Reason: update.Reason}) {
+		return
+	}
+	station, err := s.stations.Get(c.Request.Context(), station.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, station)
+}
+
+// @Summary Get the status history of a refill station
+// @Description Get the activations and deactivations of a refill station from from until before to,
+// @Description by default the last 30 days, at most 366 days, ordered by time
+// @Tags Refill Stations
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param from query string false "Start time (RFC 3339)"
+// @Param to query string false "End time (RFC 3339)"
+// @Success 200 {array} database.StationStatusChange
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/status_changes [get]
+func (s *Server) GetRefillStationStatusChanges(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	from, to, err := parseTimeRange(c, defaultStatusRange, maxStatusRange)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	changes, err := s.stations.ListStatusChanges(c.Request.Context(), station.ID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, changes)
+}
+
+// @Summary Get the uptime of a refill station
+// @Description Get the availability of a refill station from from until before to, by default the
+// @Description last 30 days, at most 366 days, with the intervals in which it was inactive.
+// @Description The range starts when the station was created and ends at the current time at the latest.
+// @Tags Refill Stations
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param from query string false "Start time (RFC 3339)"
+// @Param to query string false "End time (RFC 3339)"
+// @Success 200 {object} StationUptime
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/uptime [get]
+func (s *Server) GetRefillStationUptime(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	from, to, err := parseTimeRange(c, defaultStatusRange, maxStatusRange)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	if to.After(now) {
+		to = now
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be in the past"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	previous, err := s.stations.LastStatusChangeBefore(ctx, station.ID, from)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// The changes after the range tell the state within it if there is no previous change
+	changes, err := s.stations.ListStatusChanges(ctx, station.ID, from, now.Add(time.Second))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	// The station did not exist before its creation
+	if previous == nil && len(changes) > 0 && changes[0].Source == database.StatusSourceCreation {
+		if !changes[0].ChangedAt.Before(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The station was created after the range"})
+			return
+		}
+		from, previous, changes = changes[0].ChangedAt, &changes[0], changes[1:]
+	}
+	c.JSON(http.StatusOK, stationUptime(station, previous, changes, from, to))
+}
+
+// stationUptime replays the status changes of the station from the start of the range until
+// its end, starting from the state after the previous change. Without one, the station was in
+// the opposite state of the first change or, without any changes, in its current state.
+func stationUptime(station *database.RefillStation, previous *database.StationStatusChange, changes []database.StationStatusChange, from, to time.Time) StationUptime {
+	uptime := StationUptime{StationID: station.ID, From: from, To: to, Outages: []Outage{}}
+
+	var outage *Outage
+	var downtime time.Duration
+	startOutage := func(start time.Time, deactivation *database.StationStatusChange) {
+		outage = &Outage{Start: start}
+		if deactivation != nil {
+			outage.Source, outage.Reason = deactivation.Source, deactivation.Reason
+			outage.ActorID, outage.DeviceID = deactivation.ActorID, deactivation.DeviceID
+		}
+	}
+	endOutage := func(end *time.Time) {
+		until := to
+		if end != nil {
+			until = *end
+		}
+		outage.End = end
+		outage.DurationSeconds = int64(until.Sub(outage.Start).Seconds())
+		downtime += until.Sub(outage.Start)
+		uptime.Outages = append(uptime.Outages, *outage)
+		outage = nil
+	}
+
+	var active bool
+	switch {
+	case previous != nil:
+		active = previous.Active
+	case len(changes) > 0:
+		active = !changes[0].Active
+	default:
+		active = station.Active.Valid && station.Active.Bool
+	}
+	if !active {
+		startOutage(from, previous)
+	}
+	for i := range changes {
+		change := &changes[i]
+		if !change.ChangedAt.Before(to) {
+			break
+		}
+		if change.Active == active {
+			continue
+		}
+		active = change.Active
+		if active {
+			endOutage(&change.ChangedAt)
+		} else {
+			startOutage(change.ChangedAt, change)
+		}
+	}
+	if outage != nil {
+		endOutage(nil)
+	}
+
+	total := to.Sub(from)
+	uptime.DowntimeSeconds = int64(downtime.Seconds())
+	uptime.UptimeSeconds = int64((total - downtime).Seconds())
+	uptime.Availability = math.Round(float64(total-downtime)/float64(total)*10000) / 100
+	return uptime
+}
+
+// setActive sets the active state of the station of the change as the current user and
+// records the change, otherwise it responds with an error and returns false
+func (s *Server) setActive(c *gin.Context, change database.StationStatusChange) bool {
+	change.ActorID = &CurrentUser(c).ID
+	change.ChangedAt = time.Now()
+	if _, err := s.stations.SetActive(c.Request.Context(), &change); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Refill Station with ID not found"})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,159 @@
+package api_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestStationStatusRoutes(t *testing.T) {
+	status := map[string]interface{}{"active": false, "reason": "Maintenance"}
+
+	runRouteCases(t, []routeCase{
+		{"set anonymously", http.MethodPut, "/refill_stations/1/active", status, http.StatusUnauthorized, 0},
+		{"set as user", http.MethodPut, "/refill_stations/1/active", status, http.StatusForbidden, 4},
+		{"set for operated station", http.MethodPut, "/refill_stations/1/active", status, http.StatusOK, operatorID},
+		{"set for other station", http.MethodPut, "/refill_stations/3/active", status, http.StatusForbidden, operatorID},
+		{"set for unknown station", http.MethodPut, "/refill_stations/999/active", status, http.StatusNotFound, adminID},
+		{"set without reason", http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": true}, http.StatusBadRequest, adminID},
+		{"set without state", http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"reason": "Repaired"}, http.StatusBadRequest, adminID},
+		{"history as user", http.MethodGet, "/refill_stations/1/status_changes", nil, http.StatusForbidden, 4},
+		{"history", http.MethodGet, "/refill_stations/1/status_changes", nil, http.StatusOK, operatorID},
+		{"uptime anonymously", http.MethodGet, "/refill_stations/1/uptime", nil, http.StatusUnauthorized, 0},
+		{"uptime of other station", http.MethodGet, "/refill_stations/3/uptime", nil, http.StatusForbidden, operatorID},
+		{"uptime", http.MethodGet, "/refill_stations/1/uptime", nil, http.StatusOK, operatorID},
+		{"uptime in the future", http.MethodGet, "/refill_stations/1/uptime?from=2999-01-01T00:00:00Z&to=2999-01-02T00:00:00Z", nil, http.StatusBadRequest, adminID},
+		{"uptime with invalid range", http.MethodGet, "/refill_stations/1/uptime?from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z", nil, http.StatusBadRequest, adminID},
+	})
+}
+
+func TestStationStatusHistory(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+	start := time.Now().Add(-time.Hour)
+
+	w := doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": false, "reason": "Maintenance"})
+	if station := decodeResponse[database.RefillStation](t, w); station.Active.Bool {
+		t.Fatalf("expected the station to be inactive, got %+v", station)
+	}
+	uptime := decodeResponse[api.StationUptime](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/uptime", nil))
+	if len(uptime.Outages) != 1 || uptime.Outages[0].End != nil || uptime.Outages[0].Reason != "Maintenance" ||
+		uptime.Outages[0].Source != database.StatusSourceManual || *uptime.Outages[0].ActorID != operatorID {
+		t.Errorf("expected an ongoing outage, got %+v", uptime)
+	}
+
+	// Setting the same state again is not recorded
+	doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": false, "reason": "Still broken"})
+	doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations/1/active", map[string]interface{}{"active": true, "reason": "Repaired"})
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	station.Active = database.NullBool{Bool: false, Valid: true}
+	if w := doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations", station); w.Code != http.StatusOK {
+		t.Fatalf("failed to update the station: %d %s", w.Code, w.Body.String())
+	}
+	// The response is the stored station, not the partial request
+	w = doAuthRequest(t, r, operator, http.MethodPut, "/refill_stations", map[string]interface{}{"id": 1, "description": "Am Brunnen"})
+	if updated := decodeResponse[database.RefillStation](t, w); updated.Name != station.Name || updated.Description != "Am Brunnen" || updated.Active.Bool {
+		t.Errorf("expected the stored station, got %d %s", w.Code, w.Body.String())
+	}
+
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 3 {
+		t.Fatalf("expected three changes, got %+v", changes)
+	}
+	for i, reason := range []string{"Maintenance", "Repaired", "Updated with the refill station"} {
+		if changes[i].Reason != reason || changes[i].Active != (i == 1) || *changes[i].ActorID != operatorID {
+			t.Errorf("unexpected change %d: %+v", i, changes[i])
+		}
+	}
+
+	uptime = decodeResponse[api.StationUptime](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/uptime", nil))
+	if len(uptime.Outages) != 2 || uptime.Outages[0].End == nil || uptime.Outages[1].End != nil {
+		t.Errorf("expected a finished and an ongoing outage, got %+v", uptime.Outages)
+	}
+	if uptime.Availability <= 99 || uptime.UptimeSeconds+uptime.DowntimeSeconds < int64(30*24*time.Hour/time.Second)-1 {
+		t.Errorf("expected the station to be available almost all the time, got %+v", uptime)
+	}
+
+	// Before the first change the station was active, although it is inactive now
+	query := url.Values{"from": {start.Add(-time.Hour).Format(time.RFC3339)}, "to": {start.Format(time.RFC3339)}}
+	uptime = decodeResponse[api.StationUptime](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/uptime?"+query.Encode(), nil))
+	if uptime.Availability != 100 || len(uptime.Outages) != 0 || uptime.UptimeSeconds != 3600 {
+		t.Errorf("expected full availability before the changes, got %+v", uptime)
+	}
+	// A station without changes is in its current state
+	uptime = decodeResponse[api.StationUptime](t, doAuthRequest(t, r, login(t, r, adminID), http.MethodGet, fmt.Sprintf("/refill_stations/%d/uptime", 3), nil))
+	if uptime.Availability != 100 {
+		t.Errorf("expected an active station without changes to be available, got %+v", uptime)
+	}
+}
+
+func TestStationCreationStatus(t *testing.T) {
+	r := newTestRouter(t)
+	admin := login(t, r, adminID)
+	start := time.Now().Add(-time.Second)
+
+	w := doAuthRequest(t, r, admin, http.MethodPost, "/refill_stations", map[string]interface{}{
+		"name": "Neu", "latitude": 49.44, "longitude": 7.76, "type": "smart", "offered_water_types": "tap",
+		"active": database.NullBool{Bool: false, Valid: true},
+	})
+	station := decodeResponse[database.RefillStation](t, w)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("failed to create the station: %d %s", w.Code, w.Body.String())
+	}
+
+	path := fmt.Sprintf("/refill_stations/%d", station.ID)
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, admin, http.MethodGet, path+"/status_changes", nil))
+	if len(changes) != 1 || changes[0].Source != database.StatusSourceCreation || changes[0].Active || *changes[0].ActorID != adminID {
+		t.Fatalf("expected the creation to be recorded, got %+v", changes)
+	}
+
+	// The uptime starts with the creation instead of projecting the state back
+	uptime := decodeResponse[api.StationUptime](t, doAuthRequest(t, r, admin, http.MethodGet, path+"/uptime", nil))
+	if uptime.From.Before(start) || uptime.Availability != 0 || len(uptime.Outages) != 1 || uptime.Outages[0].Source != database.StatusSourceCreation {
+		t.Errorf("expected an outage since the creation, got %+v", uptime)
+	}
+	query := url.Values{"from": {start.Add(-2 * time.Hour).Format(time.RFC3339)}, "to": {start.Add(-time.Hour).Format(time.RFC3339)}}
+	if w := doAuthRequest(t, r, admin, http.MethodGet, path+"/uptime?"+query.Encode(), nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a range before the creation, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestProblemReportDeactivatesStation(t *testing.T) {
+	r := newTestRouter(t)
+	operator := login(t, r, operatorID)
+	problem := map[string]interface{}{"station_id": 1, "title": "Leak", "description": "Water on the floor", "deactivate_station": true}
+
+	if w := doAuthRequest(t, r, login(t, r, 4), http.MethodPost, "/refill_station_problems", problem); w.Code != http.StatusForbidden {
+		t.Fatalf("expected only operators to deactivate the station, got %d %s", w.Code, w.Body.String())
+	}
+	if w := doAuthRequest(t, r, operator, http.MethodPost, "/refill_station_problems", problem); w.Code != http.StatusCreated {
+		t.Fatalf("failed to report the problem: %d %s", w.Code, w.Body.String())
+	}
+	station := decodeResponse[database.RefillStation](t, doRequest(t, r, http.MethodGet, "/refill_stations/1", nil))
+	if station.Active.Bool {
+		t.Fatalf("expected the station to be deactivated, got %+v", station)
+	}
+	changes := decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 1 || changes[0].Active || changes[0].Source != database.StatusSourceProblem || changes[0].ProblemID == nil ||
+		*changes[0].ActorID != operatorID {
+		t.Fatalf("expected the deactivation by the problem, got %+v", changes)
+	}
+	uptime := decodeResponse[api.StationUptime](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/uptime", nil))
+	if len(uptime.Outages) != 1 || uptime.Outages[0].Source != database.StatusSourceProblem || uptime.Outages[0].Reason != fmt.Sprintf("Problem %d: Leak", *changes[0].ProblemID) {
+		t.Errorf("expected an outage because of the problem, got %+v", uptime.Outages)
+	}
+
+	// Solving the problem activates the station again
+	path := fmt.Sprintf("/refill_station_problems/%d/transitions", *changes[0].ProblemID)
+	doAuthRequest(t, r, operator, http.MethodPost, path, map[string]interface{}{"status": "INPROGRESS"})
+	doAuthRequest(t, r, operator, http.MethodPost, path, map[string]interface{}{"status": "SOLVED"})
+	changes = decodeResponse[[]database.StationStatusChange](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/status_changes", nil))
+	if len(changes) != 2 || !changes[1].Active || changes[1].Source != database.StatusSourceProblem {
+		t.Errorf("expected the activation by the solved problem, got %+v", changes)
+	}
+}
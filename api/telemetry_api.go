@@ -0,0 +1,234 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/telemetry"
+	"github.com/gin-gonic/gin"
+)
+
+// Limits of the telemetry time series
+const (
+	defaultTelemetryRange = 24 * time.Hour
+	maxTelemetryRange     = 31 * 24 * time.Hour
+	maxTelemetryPoints    = 1000
+	// maxTelemetryReports bounds the reports aggregated into one series
+	maxTelemetryReports = 100000
+)
+
+// TelemetryPoint is a report of a station device or, in a series with an interval, the
+// averages of the reports within one interval starting at Time and the last filter status
+type TelemetryPoint struct {
+	Time         time.Time `json:"time"`
+	TankLevel    *float64  `json:"tank_level,omitempty"`
+	FilterStatus *string   `json:"filter_status,omitempty"`
+	Temperature  *float64  `json:"temperature,omitempty"`
+	FlowRate     *float64  `json:"flow_rate,omitempty"`
+	Samples      int       `json:"samples"`
+}
+
+type TelemetrySeries struct {
+	StationID uint             `json:"station_id"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Interval  string           `json:"interval,omitempty"`
+	Points    []TelemetryPoint `json:"points"`
+}
+
+// @Summary Report telemetry
+// @Description Report the measurements of a smart refill station, authenticated with the API key
+// @Description of one of its devices. Every report is a heartbeat, a report without measurements
+// @Description is only a heartbeat and not stored. Stations whose devices stop sending heartbeats
+// @Description are deactivated until the next heartbeat arrives.
+// @Tags Telemetry
+// @Accept json
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param telemetry body database.Telemetry true "Telemetry"
+// @Success 201 {object} database.Telemetry
+// @Success 204
+// @Security DeviceKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /refill_stations/{id}/telemetry [post]
+func (s *Server) ReportTelemetry(c *gin.Context) {
+	var report database.Telemetry
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&report); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	stored, err := s.monitor.Record(c.Request.Context(), CurrentDevice(c), &report, time.Now())
+	if errors.Is(err, telemetry.ErrInvalidReport) {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !stored {
+		c.Status(http.StatusNoContent)
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// @Summary Get the telemetry of a refill station
+// @Description Get the reports of the devices of a refill station recorded from from until before to,
+// @Description by default the last 24 hours, at most 31 days. With an interval like 15m or 1h the
+// @Description reports are averaged per interval, otherwise every report is a point.
+// @Tags Telemetry
+// @Produce json
+// @Param id path int true "Refill Station ID"
+// @Param from query string false "Start time (RFC 3339)"
+// @Param to query string false "End time (RFC 3339)"
+// @Param interval query string false "Aggregation interval, e.g. 15m or 1h"
+// @Success 200 {object} TelemetrySeries
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /refill_stations/{id}/telemetry [get]
+func (s *Server) GetRefillStationTelemetry(c *gin.Context) {
+	station, ok := s.loadOperatedStation(c)
+	if !ok {
+		return
+	}
+	from, to, err := parseTimeRange(c, defaultTelemetryRange, maxTelemetryRange)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	series := TelemetrySeries{StationID: station.ID, From: from, To: to, Points: []TelemetryPoint{}}
+
+	limit := maxTelemetryPoints + 1
+	var interval time.Duration
+	if intervalStr := c.Query("interval"); intervalStr != "" {
+		interval, err = time.ParseDuration(intervalStr)
+		if err != nil || interval < time.Minute {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be a duration of at least 1m, e.g. 15m or 1h"})
+			return
+		}
+		if to.Sub(from)/interval >= maxTelemetryPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("interval yields more than %d points, choose a longer one", maxTelemetryPoints)})
+			return
+		}
+		series.Interval = interval.String()
+		limit = maxTelemetryReports
+	}
+
+	reports, err := s.telemetry.ListByStation(c.Request.Context(), station.ID, from, to, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if interval == 0 {
+		if len(reports) > maxTelemetryPoints {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("more than %d reports, choose a shorter range or an interval", maxTelemetryPoints)})
+			return
+		}
+		for _, report := range reports {
+			series.Points = append(series.Points, TelemetryPoint{
+				Time:         report.RecordedAt,
+				TankLevel:    report.TankLevel,
+				FilterStatus: report.FilterStatus,
+				Temperature:  report.Temperature,
+				FlowRate:     report.FlowRate,
+				Samples:      1,
+			})
+		}
+	} else {
+		series.Points = aggregateTelemetry(reports, from, interval)
+	}
+	c.JSON(http.StatusOK, series)
+}
+
+// parseTimeRange reads the from and to parameters, to defaults to now and
+// from to the default range before to
+func parseTimeRange(c *gin.Context, defaultRange, maxRange time.Duration) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("to must be an RFC 3339 time")
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultRange)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("from must be an RFC 3339 time")
+		}
+		from = parsed
+	}
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, errors.New("from must be before to")
+	}
+	if to.Sub(from) > maxRange {
+		return time.Time{}, time.Time{}, fmt.Errorf("the range may span at most %d days", int(maxRange.Hours()/24))
+	}
+	return from, to, nil
+}
+
+// telemetryMean averages the reported values of one measurement
+type telemetryMean struct {
+	sum   float64
+	count int
+}
+
+func (m *telemetryMean) add(value *float64) {
+	if value != nil {
+		m.sum += *value
+		m.count++
+	}
+}
+
+func (m *telemetryMean) value() *float64 {
+	if m.count == 0 {
+		return nil
+	}
+	mean := m.sum / float64(m.count)
+	return &mean
+}
+
+// aggregateTelemetry averages the reports ordered by time per interval starting at from,
+// intervals without reports are left out
+func aggregateTelemetry(reports []database.Telemetry, from time.Time, interval time.Duration) []TelemetryPoint {
+	points := []TelemetryPoint{}
+	var tankLevel, temperature, flowRate telemetryMean
+	flush := func() {
+		last := &points[len(points)-1]
+		last.TankLevel, last.Temperature, last.FlowRate = tankLevel.value(), temperature.value(), flowRate.value()
+		tankLevel, temperature, flowRate = telemetryMean{}, telemetryMean{}, telemetryMean{}
+	}
+	for _, report := range reports {
+		start := from.Add(report.RecordedAt.Sub(from) / interval * interval)
+		if len(points) == 0 || !points[len(points)-1].Time.Equal(start) {
+			if len(points) > 0 {
+				flush()
+			}
+			points = append(points, TelemetryPoint{Time: start})
+		}
+		last := &points[len(points)-1]
+		last.Samples++
+		if report.FilterStatus != nil {
+			last.FilterStatus = report.FilterStatus
+		}
+		tankLevel.add(report.TankLevel)
+		temperature.add(report.Temperature)
+		flowRate.add(report.FlowRate)
+	}
+	if len(points) > 0 {
+		flush()
+	}
+	return points
+}
@@ -0,0 +1,100 @@
+package api_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestReportTelemetry(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, 1)
+
+	w := doDeviceRequest(t, r, apiKey, http.MethodPost, "/refill_stations/1/telemetry", map[string]interface{}{
+		"tank_level": 75.5, "filter_status": "replace_soon", "temperature": 11.2, "flow_rate": 4.5,
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the report to be stored, got %d: %s", w.Code, w.Body.String())
+	}
+	report := decodeResponse[database.Telemetry](t, w)
+	if report.StationID != 1 || report.DeviceID == 0 || report.RecordedAt.IsZero() || *report.TankLevel != 75.5 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	cases := []struct {
+		name   string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"heartbeat", "/refill_stations/1/telemetry", nil, http.StatusNoContent},
+		{"empty report", "/refill_stations/1/telemetry", map[string]interface{}{}, http.StatusNoContent},
+		{"tank level out of range", "/refill_stations/1/telemetry", map[string]interface{}{"tank_level": 101}, http.StatusUnprocessableEntity},
+		{"unknown filter status", "/refill_stations/1/telemetry", map[string]interface{}{"filter_status": "broken"}, http.StatusUnprocessableEntity},
+		{"future report", "/refill_stations/1/telemetry", map[string]interface{}{"temperature": 10, "recorded_at": time.Now().Add(time.Hour)}, http.StatusUnprocessableEntity},
+		{"malformed report", "/refill_stations/1/telemetry", "{", http.StatusBadRequest},
+		{"other station", "/refill_stations/3/telemetry", nil, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if w := doDeviceRequest(t, r, apiKey, http.MethodPost, tc.path, tc.body); w.Code != tc.status {
+				t.Errorf("expected %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+	if w := doRequest(t, r, http.MethodPost, "/refill_stations/1/telemetry", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected a report without API key to be rejected, got %d", w.Code)
+	}
+}
+
+func TestGetRefillStationTelemetry(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, 1)
+	hour := time.Now().Truncate(time.Hour).Add(-2 * time.Hour)
+	for i, level := range []float64{90, 80, 60} {
+		body := map[string]interface{}{"tank_level": level, "recorded_at": hour.Add(time.Duration(i) * 20 * time.Minute)}
+		if i == 2 {
+			body["recorded_at"] = hour.Add(70 * time.Minute)
+			body["filter_status"] = "replace"
+		}
+		if w := doDeviceRequest(t, r, apiKey, http.MethodPost, "/refill_stations/1/telemetry", body); w.Code != http.StatusCreated {
+			t.Fatalf("failed to report telemetry: %d %s", w.Code, w.Body.String())
+		}
+	}
+	operator := login(t, r, operatorID)
+	query := url.Values{"from": {hour.Format(time.RFC3339)}, "to": {hour.Add(2 * time.Hour).Format(time.RFC3339)}}
+
+	series := decodeResponse[api.TelemetrySeries](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/telemetry?"+query.Encode(), nil))
+	if len(series.Points) != 3 || *series.Points[0].TankLevel != 90 || *series.Points[2].TankLevel != 60 || series.Interval != "" {
+		t.Errorf("expected the three reports in order, got %+v", series)
+	}
+
+	query.Set("interval", "1h")
+	series = decodeResponse[api.TelemetrySeries](t, doAuthRequest(t, r, operator, http.MethodGet, "/refill_stations/1/telemetry?"+query.Encode(), nil))
+	if len(series.Points) != 2 || series.Interval != "1h0m0s" {
+		t.Fatalf("expected two hourly points, got %+v", series)
+	}
+	first, second := series.Points[0], series.Points[1]
+	if !first.Time.Equal(hour) || first.Samples != 2 || *first.TankLevel != 85 || first.FilterStatus != nil {
+		t.Errorf("unexpected first point %+v", first)
+	}
+	if !second.Time.Equal(hour.Add(time.Hour)) || second.Samples != 1 || *second.FilterStatus != "replace" {
+		t.Errorf("unexpected second point %+v", second)
+	}
+
+	runRouteCases(t, []routeCase{
+		{"anonymously", http.MethodGet, "/refill_stations/1/telemetry", nil, http.StatusUnauthorized, 0},
+		{"as user", http.MethodGet, "/refill_stations/1/telemetry", nil, http.StatusForbidden, 4},
+		{"other station", http.MethodGet, "/refill_stations/3/telemetry", nil, http.StatusForbidden, operatorID},
+		{"last day", http.MethodGet, "/refill_stations/1/telemetry", nil, http.StatusOK, operatorID},
+		{"unknown station", http.MethodGet, "/refill_stations/999/telemetry", nil, http.StatusNotFound, adminID},
+		{"invalid from", http.MethodGet, "/refill_stations/1/telemetry?from=yesterday", nil, http.StatusBadRequest, adminID},
+		{"range too long", http.MethodGet, "/refill_stations/1/telemetry?from=2024-01-01T00:00:00Z&to=2024-03-01T00:00:00Z", nil, http.StatusBadRequest, adminID},
+		{"interval too short", http.MethodGet, "/refill_stations/1/telemetry?interval=10s", nil, http.StatusBadRequest, adminID},
+		{"too many points", http.MethodGet, "/refill_stations/1/telemetry?interval=1m", nil, http.StatusBadRequest, adminID},
+	})
+}
@@ -1,48 +1,101 @@
 package api
 
 import (
-	"log"
+	"errors"
 	"net/http"
+	"net/mail"
 	"strconv"
+	"strings"
 
+	"github.com/PoseidonPSE2/code_backend/auth"
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Show all users
-// @Description Get all users
+// @Description Get a page of users, or the user with the given ID. The email is only included
+// @Description for the authenticated user and for admins.
 // @Tags Users
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.User
+// @Param id query int false "User ID"
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, first_name, last_name, role" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.User]
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Router /users [get]
-func GetUsers(c *gin.Context) {
-	log.Print(db)
+func (s *Server) GetUsers(c *gin.Context) {
 	idStr := c.Query("id")
 	if idStr == "" {
-		var users []database.User
-		result := db.Find(&users)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		params, err := parseListParams[database.User](c, "first_name", "last_name", "role")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		users, total, err := s.users.List(c.Request.Context(), params.query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, users)
+		for i := range users {
+			hideEmail(c, &users[i])
+		}
+		respondList(c, users, total, params)
 	} else {
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var user database.User
-		result := db.First(&user, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		user, err := s.users.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
+		hideEmail(c, user)
 		c.JSON(http.StatusOK, user)
 	}
 }
 
+// hideEmail removes the email, the login name, unless the current user is the user or manages users
+func hideEmail(c *gin.Context, user *database.User) {
+	current := CurrentUser(c)
+	if current == nil || (current.ID != user.ID && !auth.Can(current.Role, auth.ManageUsers)) {
+		user.Email = nil
+	}
+}
+
+// checkEmail trims and lowercases the email of the user like registration and login do and
+// checks its format and that no other user has it, otherwise it responds with 400, 409 or 500
+// and returns false
+func (s *Server) checkEmail(c *gin.Context, user *database.User) bool {
+	if user.Email == nil {
+		return true
+	}
+	email := strings.ToLower(strings.TrimSpace(*user.Email))
+	if address, err := mail.ParseAddress(email); err != nil || address.Address != email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email"})
+		return false
+	}
+	user.Email = &email
+	other, err := s.users.GetByEmail(c.Request.Context(), email)
+	if err == nil && other.ID != user.ID {
+		c.JSON(http.StatusConflict, gin.H{"error": database.ErrEmailExists.Error()})
+		return false
+	}
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return false
+	}
+	return true
+}
+
 // @Summary Create a user
 // @Description Create a new user
 // @Tags Users
@@ -50,41 +103,69 @@ func GetUsers(c *gin.Context) {
 // @Produce json
 // @Param user body database.User true "User"
 // @Success 201 {object} database.User
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /users [post]
-func CreateUser(c *gin.Context) {
+func (s *Server) CreateUser(c *gin.Context) {
 	var user database.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result := db.Create(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if !s.checkEmail(c, &user) {
+		return
+	}
+	if err := s.users.Create(c.Request.Context(), &user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, user)
 }
 
 // @Summary Update a user
-// @Description Update an existing user
+// @Description Update the name and email of an existing user, fields left out keep their value
 // @Tags Users
 // @Accept json
 // @Produce json
 // @Param user body database.User true "User"
 // @Success 200 {object} database.User
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /users [put]
-func UpdateUser(c *gin.Context) {
+func (s *Server) UpdateUser(c *gin.Context) {
 	var user database.User
 	if err := c.ShouldBindJSON(&user); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	result := db.Save(&user)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if !isOwnerOr(c, user.ID, auth.ManageUsers) {
+		respondForbidden(c)
 		return
 	}
-	c.JSON(http.StatusOK, user)
+	if _, err := s.users.Get(c.Request.Context(), user.ID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !s.checkEmail(c, &user) {
+		return
+	}
+	updated, err := s.users.Update(c.Request.Context(), &user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
 }
 
 // @Summary Delete a user
@@ -94,8 +175,11 @@ func UpdateUser(c *gin.Context) {
 // @Produce json
 // @Param id query int true "User ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /users [delete]
-func DeleteUser(c *gin.Context) {
+func (s *Server) DeleteUser(c *gin.Context) {
 	idStr := c.Query("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -106,10 +190,68 @@ func DeleteUser(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	result := db.Delete(&database.User{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if !isOwnerOr(c, uint(id), auth.ManageUsers) {
+		respondForbidden(c)
+		return
+	}
+	if err := s.users.Delete(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+// SetRoleRequest is the body of PUT /users/{id}/role
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// @Summary Set the role of a user
+// @Description Make a user a regular user, an operator or an admin, admins only
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param role body SetRoleRequest true "Role"
+// @Success 200 {object} database.User
+// @Security BearerAuth
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /users/{id}/role [put]
+func (s *Server) SetUserRole(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var request SetRoleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := (&database.User{Role: request.Role}).Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.users.SetRole(c.Request.Context(), uint(id), request.Role); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	user, err := s.users.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, user)
+}
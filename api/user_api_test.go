@@ -0,0 +1,129 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestUserRoutes(t *testing.T) {
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/users", nil, http.StatusOK, 0},
+		{"get by id", http.MethodGet, "/users?id=1", nil, http.StatusOK, 0},
+		{"get invalid id", http.MethodGet, "/users?id=abc", nil, http.StatusBadRequest, 0},
+		{"get unknown id", http.MethodGet, "/users?id=999", nil, http.StatusNotFound, 0},
+		{"create", http.MethodPost, "/users", map[string]interface{}{"first_name": "Erika", "last_name": "Muster"}, http.StatusCreated, 1},
+		{"create malformed body", http.MethodPost, "/users", "{", http.StatusBadRequest, 1},
+		{"update", http.MethodPut, "/users", map[string]interface{}{"id": 1, "first_name": "Jonas", "last_name": "Blau"}, http.StatusOK, 1},
+		{"update malformed body", http.MethodPut, "/users", "[]", http.StatusBadRequest, 1},
+		{"update unknown id", http.MethodPut, "/users", map[string]interface{}{"id": 999, "first_name": "Erika"}, http.StatusNotFound, 1},
+		{"update without id", http.MethodPut, "/users", map[string]interface{}{"first_name": "Erika"}, http.StatusNotFound, 1},
+		{"update taken email", http.MethodPut, "/users", map[string]interface{}{"id": 1, "email": testEmail(2)}, http.StatusConflict, 1},
+		{"update taken email in other case", http.MethodPut, "/users", map[string]interface{}{"id": 1, "email": " User2@Poseidon.test"}, http.StatusConflict, 1},
+		{"update invalid email", http.MethodPut, "/users", map[string]interface{}{"id": 1, "email": "no email"}, http.StatusBadRequest, 1},
+		{"create taken email", http.MethodPost, "/users", map[string]interface{}{"first_name": "Erika", "email": "USER2@poseidon.test"}, http.StatusConflict, 1},
+		{"create invalid email", http.MethodPost, "/users", map[string]interface{}{"first_name": "Erika", "email": "Erika <erika@x.de>"}, http.StatusBadRequest, 1},
+		{"delete", http.MethodDelete, "/users?id=101", nil, http.StatusNoContent, 1},
+		{"delete missing id", http.MethodDelete, "/users", nil, http.StatusBadRequest, 1},
+		{"delete invalid id", http.MethodDelete, "/users?id=abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/users?id=999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestUserPayloads(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 1)
+
+	users := decodeResponse[api.ListPage[database.User]](t, doRequest(t, r, http.MethodGet, "/users", nil))
+	if users.Total != 101 {
+		t.Fatalf("expected 101 seeded users, got %d", users.Total)
+	}
+
+	user := decodeResponse[database.User](t, doRequest(t, r, http.MethodGet, "/users?id=1", nil))
+	if user.FirstName != "Jonas" || user.LastName != "Blum" {
+		t.Errorf("unexpected user 1: %+v", user)
+	}
+
+	created := decodeResponse[database.User](t, doAuthRequest(t, r, token, http.MethodPost, "/users", map[string]interface{}{"first_name": "Erika", "last_name": "Muster"}))
+	if created.ID != 102 {
+		t.Errorf("expected new user to get ID 102, got %d", created.ID)
+	}
+
+	doAuthRequest(t, r, token, http.MethodPut, "/users", map[string]interface{}{"id": 1, "first_name": "Jonas", "last_name": "Blau", "email": testEmail(1)})
+	user = decodeResponse[database.User](t, doRequest(t, r, http.MethodGet, "/users?id=1", nil))
+	if user.LastName != "Blau" {
+		t.Errorf("expected updated last name, got %q", user.LastName)
+	}
+	// Updating a user keeps the password
+	login(t, r, 1)
+
+	// Fields left out keep their value and the role cannot be changed
+	userToken := login(t, r, 4)
+	w := doAuthRequest(t, r, userToken, http.MethodPut, "/users", map[string]interface{}{"id": 4, "first_name": "Lena", "role": "admin"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed to update the user: %d %s", w.Code, w.Body.String())
+	}
+	user = decodeResponse[database.User](t, doAuthRequest(t, r, userToken, http.MethodGet, "/users?id=4", nil))
+	if user.FirstName != "Lena" || user.LastName == "" || user.Email == nil || *user.Email != testEmail(4) || user.Role != database.RoleUser {
+		t.Errorf("expected a partial update, got %+v", user)
+	}
+	if users := decodeResponse[api.ListPage[database.User]](t, doRequest(t, r, http.MethodGet, "/users", nil)); users.Total != 102 {
+		t.Errorf("expected the update not to create users, got %d", users.Total)
+	}
+
+	doAuthRequest(t, r, token, http.MethodDelete, "/users?id=102", nil)
+	if w := doRequest(t, r, http.MethodGet, "/users?id=102", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected deleted user to be gone, got status %d", w.Code)
+	}
+}
+
+func TestUserEmailVisibility(t *testing.T) {
+	r := newTestRouter(t)
+	admin, user := login(t, r, adminID), login(t, r, 4)
+
+	cases := []struct {
+		name    string
+		token   string
+		path    string
+		visible bool
+	}{
+		{"anonymous", "", "/users?id=4", false},
+		{"other user", user, "/users?id=1", false},
+		{"own user", user, "/users?id=4", true},
+		{"admin", admin, "/users?id=4", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := decodeResponse[database.User](t, doAuthRequest(t, r, tc.token, http.MethodGet, tc.path, nil))
+			if (got.Email != nil) != tc.visible {
+				t.Errorf("expected the email to be visible: %v, got %+v", tc.visible, got)
+			}
+		})
+	}
+
+	page := decodeResponse[api.ListPage[database.User]](t, doAuthRequest(t, r, user, http.MethodGet, "/users?limit=200", nil))
+	for _, listed := range page.Items {
+		if (listed.Email != nil) != (listed.ID == 4) {
+			t.Errorf("expected only the own email in the list, got %+v", listed)
+		}
+	}
+	if w := doAuthRequest(t, r, "invalid", http.MethodGet, "/users", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected an invalid token to be rejected, got %d", w.Code)
+	}
+}
+
+func TestUserEmailNormalized(t *testing.T) {
+	r := newTestRouter(t)
+	token := login(t, r, 4)
+
+	w := doAuthRequest(t, r, token, http.MethodPut, "/users", map[string]interface{}{"id": 4, "email": " Lena@Example.DE "})
+	if user := decodeResponse[database.User](t, w); w.Code != http.StatusOK || user.Email == nil || *user.Email != "lena@example.de" {
+		t.Fatalf("expected the email to be normalized, got %d %s", w.Code, w.Body.String())
+	}
+	// Login normalizes the email the same way
+	if w := doRequest(t, r, http.MethodPost, "/auth/login", map[string]string{"email": "LENA@example.de", "password": testPassword}); w.Code != http.StatusOK {
+		t.Errorf("expected to log in with the new email, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -6,11 +6,8 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-var db *gorm.DB
-
 func respondWithJSON(c *gin.Context, status int, payload interface{}) {
 	response, err := json.Marshal(payload)
 	if err != nil {
@@ -20,10 +17,6 @@ func respondWithJSON(c *gin.Context, status int, payload interface{}) {
 	c.Data(status, "application/json", response)
 }
 
-func SetDB(new_db *gorm.DB) {
-	db = new_db
-}
-
 // DecodeBase64ToBytes takes a base64 encoded string and returns a byte array
 func DecodeBase64ToBytes(encodedStr string) ([]byte, error) {
 	// Decode the base64 string
@@ -33,9 +26,3 @@ func DecodeBase64ToBytes(encodedStr string) ([]byte, error) {
 	}
 	return decodedBytes, nil
 }
-
-// EncodeBytesToBase64 takes a byte array and returns a base64 encoded string
-func EncodeBytesToBase64(data []byte) string {
-	// Encode the byte array to a base64 string
-	return base64.StdEncoding.EncodeToString(data)
-}
@@ -1,41 +1,55 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/ingest"
+	"github.com/PoseidonPSE2/code_backend/repository"
 	"github.com/gin-gonic/gin"
 )
 
 // @Summary Show all water transactions
-// @Description Get all water transactions
+// @Description Get a page of water transactions, or the transaction with the given ID
 // @Tags Water Transactions
 // @Accept json
 // @Produce json
-// @Success 200 {array} database.WaterTransaction
+// @Param id query int false "Water transaction ID"
+// @Param limit query int false "Page size, at most 200" default(50)
+// @Param offset query int false "Number of items to skip, cannot be combined with cursor"
+// @Param cursor query string false "next_cursor of the previous page"
+// @Param sort query string false "Sort field, descending with a leading -: id, station_id, volume, water_type, timestamp" default(id)
+// @Param fields query string false "Comma separated fields to return, the id is always included"
+// @Success 200 {object} ListPage[database.WaterTransaction]
+// @Failure 400 {object} map[string]string
 // @Router /water_transactions [get]
-func GetWaterTransactions(c *gin.Context) {
+func (s *Server) GetWaterTransactions(c *gin.Context) {
 	idStr := c.Query("id")
 	if idStr == "" {
-		var transactions []database.WaterTransaction
-		result := db.Find(&transactions)
-		if result.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		params, err := parseListParams[database.WaterTransaction](c, "station_id", "volume", "water_type", "timestamp")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		transactions, total, err := s.transactions.List(c.Request.Context(), params.query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
-		c.JSON(http.StatusOK, transactions)
+		respondList(c, transactions, total, params)
 	} else {
 		id, err := strconv.Atoi(idStr)
 		if err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 			return
 		}
-		var transaction database.WaterTransaction
-		result := db.First(&transaction, id)
-		if result.Error != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
+		transaction, err := s.transactions.Get(c.Request.Context(), uint(id))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, transaction)
@@ -43,26 +57,76 @@ func GetWaterTransactions(c *gin.Context) {
 }
 
 // @Summary Create a water transaction
-// @Description Create a new water transaction
+// @Description Create a new water transaction reported by the device of a refill station.
+// @Description The station ID defaults to the station of the device, other stations are rejected.
+// @Description Stations retrying a request send the same Idempotency-Key header or idempotency_key,
+// @Description e.g. a UUID, and get the stored transaction with status 200 instead of a duplicate.
 // @Tags Water Transactions
 // @Accept json
 // @Produce json
+// @Param Idempotency-Key header string false "Key of the transaction among those of the station"
 // @Param transaction body database.WaterTransaction true "Water Transaction"
+// @Success 200 {object} database.WaterTransaction
 // @Success 201 {object} database.WaterTransaction
+// @Security DeviceKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /water_transactions [post]
-func CreateWaterTransaction(c *gin.Context) {
+func (s *Server) CreateWaterTransaction(c *gin.Context) {
 	var transaction database.WaterTransaction
 	if err := c.ShouldBindJSON(&transaction); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	transaction.Timestamp = time.Now()
-	result := db.Create(&transaction)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := bindIdempotencyKey(c, &transaction); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	stored, created, err := s.recorder.Record(c.Request.Context(), CurrentDevice(c), &transaction)
+	if errors.Is(err, ingest.ErrOtherStation) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Device belongs to another refill station"})
 		return
 	}
-	c.JSON(http.StatusCreated, transaction)
+	if errors.Is(err, ingest.ErrKeyReused) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Idempotency key was already used for another transaction"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if created {
+		c.JSON(http.StatusCreated, stored)
+		return
+	}
+	c.Header(idempotentReplayedHeader, "true")
+	c.JSON(http.StatusOK, stored)
+}
+
+// Headers of idempotent requests, see bindIdempotencyKey
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// bindIdempotencyKey sets the idempotency key of the header on the transaction,
+// a key in the body has to match it
+func bindIdempotencyKey(c *gin.Context, transaction *database.WaterTransaction) error {
+	if key := c.GetHeader(idempotencyKeyHeader); key != "" {
+		if transaction.IdempotencyKey != nil && *transaction.IdempotencyKey != key {
+			return errors.New("idempotency_key differs from the Idempotency-Key header")
+		}
+		transaction.IdempotencyKey = &key
+	}
+	if transaction.IdempotencyKey == nil {
+		return nil
+	}
+	if key := *transaction.IdempotencyKey; key == "" || len(key) > ingest.MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key must have 1 to %d characters", ingest.MaxIdempotencyKeyLength)
+	}
+	return nil
 }
 
 // @Summary Update a water transaction
@@ -72,17 +136,19 @@ func CreateWaterTransaction(c *gin.Context) {
 // @Produce json
 // @Param transaction body database.WaterTransaction true "Water Transaction"
 // @Success 200 {object} database.WaterTransaction
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /water_transactions [put]
-func UpdateWaterTransaction(c *gin.Context) {
+func (s *Server) UpdateWaterTransaction(c *gin.Context) {
 	var transaction database.WaterTransaction
 	if err := c.ShouldBindJSON(&transaction); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 	transaction.Timestamp = time.Now()
-	result := db.Save(&transaction)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.transactions.Save(c.Request.Context(), &transaction); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, transaction)
@@ -95,8 +161,11 @@ func UpdateWaterTransaction(c *gin.Context) {
 // @Produce json
 // @Param id query int true "Water Transaction ID"
 // @Success 204
+// @Security BearerAuth
+// @Failure 401 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Router /water_transactions [delete]
-func DeleteWaterTransaction(c *gin.Context) {
+func (s *Server) DeleteWaterTransaction(c *gin.Context) {
 	idStr := c.Query("id")
 	if idStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "ID is required"})
@@ -107,9 +176,12 @@ func DeleteWaterTransaction(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
 		return
 	}
-	result := db.Delete(&database.WaterTransaction{}, id)
-	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+	if err := s.transactions.Delete(c.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Water transaction with ID not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.Status(http.StatusNoContent)
@@ -0,0 +1,167 @@
+package api_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestWaterTransactionRoutes(t *testing.T) {
+	newTransaction := map[string]interface{}{"station_id": 1, "bottle_id": 1, "user_id": 4, "volume": 500, "water_type": "TAP"}
+
+	runRouteCases(t, []routeCase{
+		{"list", http.MethodGet, "/water_transactions", nil, http.StatusOK, 0},
+		{"get by id", http.MethodGet, "/water_transactions?id=1", nil, http.StatusOK, 0},
+		{"get invalid id", http.MethodGet, "/water_transactions?id=abc", nil, http.StatusBadRequest, 0},
+		{"get unknown id", http.MethodGet, "/water_transactions?id=9999", nil, http.StatusNotFound, 0},
+		{"create without device", http.MethodPost, "/water_transactions", newTransaction, http.StatusUnauthorized, 0},
+		{"create as user", http.MethodPost, "/water_transactions", newTransaction, http.StatusUnauthorized, 1},
+		{"update", http.MethodPut, "/water_transactions", map[string]interface{}{"id": 1, "station_id": 1, "volume": 750, "water_type": "tap"}, http.StatusOK, 1},
+		{"update malformed body", http.MethodPut, "/water_transactions", "{", http.StatusBadRequest, 1},
+		{"delete", http.MethodDelete, "/water_transactions?id=1", nil, http.StatusNoContent, 1},
+		{"delete missing id", http.MethodDelete, "/water_transactions", nil, http.StatusBadRequest, 1},
+		{"delete invalid id", http.MethodDelete, "/water_transactions?id=abc", nil, http.StatusBadRequest, 1},
+		{"delete unknown id", http.MethodDelete, "/water_transactions?id=9999", nil, http.StatusNotFound, 1},
+	})
+}
+
+func TestWaterTransactionPayloads(t *testing.T) {
+	r := newTestRouter(t)
+
+	transactions := decodeResponse[api.ListPage[database.WaterTransaction]](t, doRequest(t, r, http.MethodGet, "/water_transactions", nil))
+	if transactions.Total != 236 {
+		t.Fatalf("expected 236 seeded transactions, got %d", transactions.Total)
+	}
+	if transactions.Items[0].WaterType != "tap" {
+		t.Errorf("expected seeded water types to be normalized, got %q", transactions.Items[0].WaterType)
+	}
+
+	before := time.Now()
+	created := decodeResponse[database.WaterTransaction](t, doDeviceRequest(t, r, registerDevice(t, r, 1), http.MethodPost, "/water_transactions",
+		map[string]interface{}{"station_id": 1, "bottle_id": 1, "user_id": 4, "volume": 500, "water_type": "MINERAL", "timestamp": "2020-01-01T00:00:00Z"}))
+	if created.ID != 237 || created.WaterType != "mineral" {
+		t.Errorf("unexpected created transaction: %+v", created)
+	}
+	if created.Timestamp.Before(before) {
+		t.Errorf("expected the server time as timestamp, got %v", created.Timestamp)
+	}
+}
+
+func TestCreateWaterTransactionAsDevice(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, 1)
+
+	for name, tc := range map[string]struct {
+		body   interface{}
+		status int
+	}{
+		"own station":        {map[string]interface{}{"station_id": 1, "volume": 500, "water_type": "tap", "guest": true}, http.StatusCreated},
+		"other station":      {map[string]interface{}{"station_id": 3, "volume": 500, "water_type": "tap", "guest": true}, http.StatusForbidden},
+		"invalid water type": {map[string]interface{}{"station_id": 1, "volume": 500, "water_type": "juice", "guest": true}, http.StatusInternalServerError},
+		"malformed body":     {"{", http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := doDeviceRequest(t, r, apiKey, http.MethodPost, "/water_transactions", tc.body); w.Code != tc.status {
+				t.Errorf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	// The station of the device is the default
+	created := decodeResponse[database.WaterTransaction](t, doDeviceRequest(t, r, apiKey, http.MethodPost, "/water_transactions",
+		map[string]interface{}{"volume": 300, "water_type": "tap", "guest": true}))
+	if created.StationID != 1 {
+		t.Errorf("expected the station of the device, got %+v", created)
+	}
+}
+
+func TestCreateWaterTransactionIdempotently(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, 1)
+	transaction := map[string]interface{}{"station_id": 1, "bottle_id": 1, "user_id": 4, "volume": 500, "water_type": "tap"}
+	send := func(key string, body interface{}) *httptest.ResponseRecorder {
+		req := newJSONRequest(t, http.MethodPost, "/water_transactions", body)
+		req.Header.Set("X-API-Key", apiKey)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21", transaction)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected the first request to create, got %d: %s", w.Code, w.Body.String())
+	}
+	created := decodeResponse[database.WaterTransaction](t, w)
+	if created.IdempotencyKey == nil || *created.IdempotencyKey != "3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21" {
+		t.Errorf("expected the idempotency key to be stored, got %+v", created)
+	}
+
+	// A retry returns the stored transaction, also when the key is sent in the body
+	for name, replay := range map[string]*httptest.ResponseRecorder{
+		"header": send("3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21", map[string]interface{}{"bottle_id": 1, "user_id": 4, "volume": 500, "water_type": "TAP"}),
+		"body": send("", map[string]interface{}{
+			"station_id": 1, "bottle_id": 1, "user_id": 4, "volume": 500, "water_type": "tap", "idempotency_key": "3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21",
+		}),
+	} {
+		if replay.Code != http.StatusOK || replay.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("%s: expected a replay, got %d: %s", name, replay.Code, replay.Body.String())
+			continue
+		}
+		if replayed := decodeResponse[database.WaterTransaction](t, replay); replayed.ID != created.ID || !replayed.Timestamp.Equal(created.Timestamp) {
+			t.Errorf("%s: expected the stored transaction %+v, got %+v", name, created, replayed)
+		}
+	}
+	total := listPage[database.WaterTransaction](t, r, "/water_transactions?limit=1").Total
+	if total != 237 {
+		t.Errorf("expected a single new transaction, got %d transactions", total)
+	}
+
+	changed := map[string]interface{}{"station_id": 1, "bottle_id": 1, "user_id": 4, "volume": 750, "water_type": "tap"}
+	if w := send("3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21", changed); w.Code != http.StatusConflict {
+		t.Errorf("expected reusing the key for another transaction to conflict, got %d: %s", w.Code, w.Body.String())
+	}
+	mismatch := map[string]interface{}{"station_id": 1, "volume": 500, "water_type": "tap", "idempotency_key": "other"}
+	if w := send("3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21", mismatch); w.Code != http.StatusBadRequest {
+		t.Errorf("expected differing keys to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := send(strings.Repeat("k", 65), transaction); w.Code != http.StatusBadRequest {
+		t.Errorf("expected a too long key to be rejected, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// An ID in the body is ignored, also if it belongs to a stored transaction
+	withID := map[string]interface{}{"id": 1, "station_id": 1, "volume": 250, "water_type": "tap", "guest": true}
+	for _, key := range []string{"", "7d2e9c1a-5b4f-4e3d-8c2b-1a0f9e8d7c6b"} {
+		w := send(key, withID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected a transaction with an existing ID to be created, got %d: %s", w.Code, w.Body.String())
+		}
+		if created := decodeResponse[database.WaterTransaction](t, w); created.ID == 1 {
+			t.Errorf("expected a new ID, got %+v", created)
+		}
+	}
+	if replay := send("7d2e9c1a-5b4f-4e3d-8c2b-1a0f9e8d7c6b", withID); replay.Code != http.StatusOK {
+		t.Errorf("expected a replay, got %d: %s", replay.Code, replay.Body.String())
+	}
+	if stored := decodeResponse[database.WaterTransaction](t, doRequest(t, r, http.MethodGet, "/water_transactions?id=1", nil)); stored.Volume == 250 {
+		t.Errorf("expected transaction 1 to stay unchanged, got %+v", stored)
+	}
+
+	// Keys are scoped to the station
+	other := map[string]interface{}{"station_id": 3, "volume": 500, "water_type": "tap", "guest": true}
+	req := newJSONRequest(t, http.MethodPost, "/water_transactions", other)
+	req.Header.Set("X-API-Key", registerDevice(t, r, 3))
+	req.Header.Set("Idempotency-Key", "3f0c5a4e-1b2d-4c6f-9a8b-7e6d5c4b3a21")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected the key to be free at another station, got %d: %s", w.Code, w.Body.String())
+	}
+}
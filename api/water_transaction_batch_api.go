@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/ingest"
+	"github.com/gin-gonic/gin"
+)
+
+// WaterTransactionBatch are transactions a station buffered while it was offline. Every
+// transaction needs its original timestamp and an idempotency key.
+type WaterTransactionBatch struct {
+	Transactions []database.WaterTransaction `json:"transactions" binding:"required,min=1,max=500"`
+}
+
+// BatchItemResult is the outcome of one transaction of a batch. Status is 201 for a
+// created transaction, 200 for one uploaded before, 409 if the idempotency key belongs to
+// another transaction and 422 for an invalid transaction, which Error describes.
+type BatchItemResult struct {
+	Index       int                        `json:"index"`
+	Status      int                        `json:"status"`
+	Transaction *database.WaterTransaction `json:"transaction,omitempty"`
+	Error       string                     `json:"error,omitempty"`
+}
+
+// BatchResult lists the results in the order of the transactions and counts them
+type BatchResult struct {
+	Results    []BatchItemResult `json:"results"`
+	Created    int               `json:"created"`
+	Duplicates int               `json:"duplicates"`
+	Failed     int               `json:"failed"`
+}
+
+// @Summary Upload buffered water transactions
+// @Description Create the transactions a station buffered while it was offline, with their original timestamps.
+// @Description Every transaction is checked on its own: the station has to be the one of the device and offer the
+// @Description water type, the bottle has to exist and belong to the user, and the timestamp may be at most 30 days old.
+// @Description Transactions uploaded before with the same idempotency key are not created again, so failed uploads
+// @Description can be retried as a whole. The response reports the result of every transaction.
+// @Tags Water Transactions
+// @Accept json
+// @Produce json
+// @Param batch body WaterTransactionBatch true "Buffered transactions"
+// @Success 200 {object} BatchResult
+// @Security DeviceKey
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /water_transactions/batch [post]
+func (s *Server) CreateWaterTransactionBatch(c *gin.Context) {
+	var batch WaterTransactionBatch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	station, err := s.stations.Get(c.Request.Context(), CurrentDevice(c).StationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	result := BatchResult{Results: make([]BatchItemResult, 0, len(batch.Transactions))}
+	for i := range batch.Transactions {
+		stored, created, err := s.recorder.RecordBuffered(c.Request.Context(), station, &batch.Transactions[i], now)
+		item := BatchItemResult{Index: i, Status: ingest.Status(created, err), Transaction: stored}
+		if err != nil {
+			item.Error = err.Error()
+		}
+		switch item.Status {
+		case http.StatusCreated:
+			result.Created++
+		case http.StatusOK:
+			result.Duplicates++
+		default:
+			result.Failed++
+		}
+		result.Results = append(result.Results, item)
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,93 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/api"
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestCreateWaterTransactionBatch(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, smartStationTap)
+	now := time.Now().UTC()
+	fill := func(key string, volume int, waterType string, at time.Time) map[string]interface{} {
+		return map[string]interface{}{"idempotency_key": key, "bottle_id": 1, "volume": volume, "water_type": waterType, "timestamp": at}
+	}
+	transactions := []interface{}{
+		fill("a", 250, "tap", now.Add(-48*time.Hour)),
+		fill("b", 200, "TAP", now.Add(-time.Hour)),
+		fill("a", 250, "tap", now.Add(-48*time.Hour)),
+		fill("a", 500, "tap", now.Add(-48*time.Hour)),
+		fill("c", 250, "mineral", now),
+		fill("d", 250, "tap", now.Add(time.Hour)),
+		fill("e", 250, "tap", now.Add(-60*24*time.Hour)),
+		fill("f", 250, "tap", time.Time{}),
+		fill("", 250, "tap", now),
+		fill("g", 0, "tap", now),
+		map[string]interface{}{"idempotency_key": "h", "station_id": smartStationBoth, "volume": 250, "water_type": "tap", "timestamp": now},
+		map[string]interface{}{"idempotency_key": "i", "bottle_id": 999, "volume": 250, "water_type": "tap", "timestamp": now},
+		map[string]interface{}{"idempotency_key": "j", "bottle_id": 1, "user_id": 5, "volume": 250, "water_type": "tap", "timestamp": now},
+		map[string]interface{}{"idempotency_key": "k", "volume": 250, "water_type": "tap", "guest": true, "timestamp": now},
+	}
+	expected := []int{201, 201, 200, 409, 422, 422, 422, 422, 422, 422, 422, 422, 422, 201}
+
+	w := doDeviceRequest(t, r, apiKey, http.MethodPost, "/water_transactions/batch", map[string]interface{}{"transactions": transactions})
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	result := decodeResponse[api.BatchResult](t, w)
+	if len(result.Results) != len(expected) || result.Created != 3 || result.Duplicates != 1 || result.Failed != 10 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	for i, item := range result.Results {
+		if item.Index != i || item.Status != expected[i] {
+			t.Errorf("transaction %d: expected status %d, got %+v", i, expected[i], item)
+		}
+	}
+
+	first := result.Results[0].Transaction
+	if first == nil || first.StationID != smartStationTap || first.UserID == nil || *first.UserID != 4 || !first.Timestamp.Equal(now.Add(-48*time.Hour)) {
+		t.Errorf("expected the original timestamp, station and bottle owner, got %+v", first)
+	}
+	if replay := result.Results[2].Transaction; replay == nil || replay.ID != first.ID {
+		t.Errorf("expected the duplicate to return the first transaction, got %+v", replay)
+	}
+	if result.Results[1].Transaction.WaterType != "tap" {
+		t.Errorf("expected the water type to be normalized, got %+v", result.Results[1].Transaction)
+	}
+
+	// Retrying the whole batch creates nothing
+	w = doDeviceRequest(t, r, apiKey, http.MethodPost, "/water_transactions/batch", map[string]interface{}{"transactions": transactions[:3]})
+	if retry := decodeResponse[api.BatchResult](t, w); retry.Created != 0 || retry.Duplicates != 3 {
+		t.Errorf("expected only duplicates on retry, got %+v", retry)
+	}
+	if total := listPage[database.WaterTransaction](t, r, "/water_transactions?limit=1").Total; total != 239 {
+		t.Errorf("expected 3 new transactions, got %d transactions", total)
+	}
+}
+
+func TestCreateWaterTransactionBatchRequest(t *testing.T) {
+	r := newTestRouter(t)
+	apiKey := registerDevice(t, r, smartStationBoth)
+	tooMany := make([]map[string]interface{}, 501)
+
+	for name, tc := range map[string]struct {
+		key    string
+		body   interface{}
+		status int
+	}{
+		"without device": {"", map[string]interface{}{"transactions": []interface{}{}}, http.StatusUnauthorized},
+		"empty":          {apiKey, map[string]interface{}{"transactions": []interface{}{}}, http.StatusBadRequest},
+		"too many":       {apiKey, map[string]interface{}{"transactions": tooMany}, http.StatusBadRequest},
+		"malformed":      {apiKey, "{", http.StatusBadRequest},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if w := doDeviceRequest(t, r, tc.key, http.MethodPost, "/water_transactions/batch", tc.body); w.Code != tc.status {
+				t.Errorf("expected status %d, got %d: %s", tc.status, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Package auth hashes passwords and issues the JWT access tokens and the
+// opaque refresh tokens used to authenticate API requests, and the API keys
+// of the station devices.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrInvalidToken is returned for malformed, forged or expired access tokens
+var ErrInvalidToken = errors.New("invalid token")
+
+const issuer = "poseidon-backend"
+
+// Claims are the claims of an access token. The subject is the user ID and
+// the session ID references the refresh token the access token was issued with,
+// so revoking the refresh token also revokes its access tokens.
+type Claims struct {
+	SessionID uint `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// UserID returns the user ID stored in the subject
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: invalid subject", ErrInvalidToken)
+	}
+	return uint(id), nil
+}
+
+// TokenIssuer signs and verifies access tokens with a shared HMAC secret
+type TokenIssuer struct {
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewTokenIssuer(secret string, accessTTL, refreshTTL time.Duration) *TokenIssuer {
+	return &TokenIssuer{secret: []byte(secret), accessTTL: accessTTL, refreshTTL: refreshTTL}
+}
+
+// AccessTTL returns the lifetime of access tokens
+func (i *TokenIssuer) AccessTTL() time.Duration {
+	return i.accessTTL
+}
+
+// RefreshTTL returns the lifetime of refresh tokens
+func (i *TokenIssuer) RefreshTTL() time.Duration {
+	return i.refreshTTL
+}
+
+// AccessToken signs an access token for the user and session valid from now
+func (i *TokenIssuer) AccessToken(userID, sessionID uint, now time.Time) (string, error) {
+	claims := Claims{
+		SessionID: sessionID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.accessTTL)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(i.secret)
+}
+
+// Parse verifies the signature and expiry of an access token and returns its claims
+func (i *TokenIssuer) Parse(token string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return i.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithIssuer(issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return &claims, nil
+}
+
+// NewRefreshToken creates a random refresh token and the hash to store instead of it
+func NewRefreshToken() (token, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(bytes)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex encoded SHA-256 hash of a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyPrefix starts every device API key, so leaked keys are easy to recognize
+const APIKeyPrefix = "pdk_"
+
+// NewAPIKey creates a random device API key and the hash to store instead of it
+func NewAPIKey() (key, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", err
+	}
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash of a device API key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashPassword hashes a password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether the password matches the bcrypt hash
+func CheckPassword(hash, password string) bool {
+	return hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,33 @@
+package auth
+
+import "github.com/PoseidonPSE2/code_backend/database"
+
+// Permission is an action a role may perform on records of other users
+type Permission string
+
+const (
+	// ManageUsers allows creating, editing and deleting any user and assigning roles
+	ManageUsers Permission = "users:manage"
+	// ManageStations allows creating, editing and deleting any refill station
+	ManageStations Permission = "stations:manage"
+	// OperateStations allows editing owned refill stations and handling their problems
+	OperateStations Permission = "stations:operate"
+	// ModerateContent allows editing and deleting bottles, reviews, likes and water transactions of others
+	ModerateContent Permission = "content:moderate"
+)
+
+var rolePermissions = map[string][]Permission{
+	database.RoleUser:     {},
+	database.RoleOperator: {OperateStations},
+	database.RoleAdmin:    {ManageUsers, ManageStations, OperateStations, ModerateContent},
+}
+
+// Can reports whether the role has the permission
+func Can(role string, permission Permission) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+// Package blob stores files by key in a pluggable backend: a directory of the
+// local filesystem, an S3-compatible object storage or memory for tests.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNotFound is returned for keys without a stored file
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps files addressed by slash separated keys like images/4f2a
+type Store interface {
+	// Put stores the content under the key, replacing an existing file
+	Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error
+	// Get opens the file of the key, the caller closes it
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file of the key, deleting a missing key is no error
+	Delete(ctx context.Context, key string) error
+}
+
+// validateKey rejects keys that could escape the storage location
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package blob
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(filepath.Join(dir, "blobs"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+
+	entries, _ := os.ReadDir(filepath.Join(dir, "blobs", "images", "ab"))
+	if len(entries) != 0 {
+		t.Errorf("expected no leftover files, got %v", entries)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
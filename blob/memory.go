@@ -0,0 +1,56 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+)
+
+// MemoryStore keeps the files in memory, for tests and development
+type MemoryStore struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: map[string][]byte{}}
+}
+
+func (s *MemoryStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if err := validateKey(key); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(content)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = data
+	return nil
+}
+
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
+
+// Len returns the number of stored files
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.files)
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/blob"
+	"github.com/PoseidonPSE2/code_backend/config"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/ingest"
+	"github.com/PoseidonPSE2/code_backend/media"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/PoseidonPSE2/code_backend/telemetry"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const usage = `Usage: run-app <command> [options]
+
+Commands:
+  serve                     Start the HTTP server (default)
+  migrate up                Apply all pending schema migrations
+  migrate down [--steps 1]  Revert the most recent schema migrations
+  migrate status            List applied and pending schema migrations
+  seed [--dir testdata]     Import the JSON test data
+  db recreate --yes         Drop and recreate the database
+  user set-role --email <email> --role <user|operator|admin>
+                            Change the role of a user, e.g. to create the first admin
+
+Every command accepts --config <file> to load a YAML configuration file,
+otherwise $CONFIG_FILE and the environment are used. Set
+DATABASE_URL=sqlite://poseidon.db to run without a Postgres server.
+`
+
+// run dispatches the command line to the matching subcommand
+func run(args []string) error {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		return serveCommand(args)
+	case "migrate":
+		return migrateCommand(args)
+	case "seed":
+		return seedCommand(args)
+	case "db":
+		return dbCommand(args)
+	case "user":
+		return userCommand(args)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+}
+
+// newFlagSet creates the flags of a subcommand including the shared --config flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(flags.Output(), usage) }
+	configPath := flags.String("config", "", "YAML configuration file")
+	return flags, configPath
+}
+
+// loadConfig loads the configuration and applies the log settings
+func loadConfig(path string) (*config.Config, error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	slog.SetLogLoggerLevel(cfg.Log.SlogLevel())
+	if cfg.Log.Level != "debug" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+	return cfg, nil
+}
+
+func connect(cfg *config.Config) (*gorm.DB, error) {
+	db, err := database.ConnectDatabase(cfg.Database.DSN(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
+
+// newImages opens the configured blob store for the image files
+func newImages(cfg *config.Config, db *gorm.DB) (*media.Images, error) {
+	var store blob.Store
+	var err error
+	switch cfg.Storage.Backend {
+	case "s3":
+		s3 := cfg.Storage.S3
+		store, err = blob.NewS3Store(blob.S3Config{
+			Endpoint:        s3.Endpoint,
+			Region:          s3.Region,
+			Bucket:          s3.Bucket,
+			AccessKeyID:     s3.AccessKeyID,
+			SecretAccessKey: s3.SecretAccessKey,
+		}, nil)
+	default:
+		store, err = blob.NewFileStore(cfg.Storage.Directory)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image storage: %w", err)
+	}
+	return media.NewImages(store, repository.NewGormRepositories(db).Images), nil
+}
+
+// moveLegacyImages moves images still stored as base64 in the database into the blob store
+func moveLegacyImages(db *gorm.DB, images *media.Images) error {
+	moved, kept, err := media.MoveLegacyImages(context.Background(), db, images)
+	if err != nil {
+		return fmt.Errorf("failed to move legacy images: %w", err)
+	}
+	if moved > 0 {
+		log.Printf("Moved %d images from the database into the image storage", moved)
+	}
+	if kept > 0 {
+		log.Printf("Kept %d images in the database that are no valid base64", kept)
+	}
+	return nil
+}
+
+// startSubscriber connects to the MQTT broker to receive the fillings and telemetry of the station devices
+func startSubscriber(cfg *config.Config, db *gorm.DB, monitor *telemetry.Service) (*ingest.Subscriber, error) {
+	repos := repository.NewGormRepositories(db)
+	subscriber := ingest.NewSubscriber(ingest.SubscriberOptions{
+		Broker:      cfg.MQTT.Broker,
+		ClientID:    cfg.MQTT.ClientID,
+		Username:    cfg.MQTT.Username,
+		Password:    cfg.MQTT.Password,
+		TopicPrefix: cfg.MQTT.TopicPrefix,
+	}, ingest.NewService(repos), repos)
+	subscriber.HandleTelemetry(monitor.HandleMessage)
+	if err := subscriber.Start(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to connect to MQTT broker: %w", err)
+	}
+	log.Printf("Receiving station devices over MQTT from %s", cfg.MQTT.Broker)
+	return subscriber, nil
+}
+
+// serveCommand runs the startup tasks enabled in the configuration and starts the HTTP server
+func serveCommand(args []string) error {
+	flags, configPath := newFlagSet("serve")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ValidateServe(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Database.Recreate {
+		if err := database.RecreateDatabase(cfg.Database.AdminDSN(), cfg.Database.Name, nil); err != nil {
+			return fmt.Errorf("failed to recreate database: %w", err)
+		}
+	}
+
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	images, err := newImages(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Database.Migrate {
+		log.Print("Schema migration starting")
+		migrator, err := database.NewMigrator(db)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		log.Printf("Schema migration done, %d migrations applied", len(applied))
+		if err := moveLegacyImages(db, images); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Database.Seed {
+		database.CreateTestData(db, cfg.Database.SeedDirectory, images)
+	}
+
+	monitor := telemetry.NewService(repository.NewGormRepositories(db))
+	ctx, stopMonitor := context.WithCancel(context.Background())
+	defer stopMonitor()
+	go monitor.Monitor(ctx, cfg.Telemetry.HeartbeatTimeout, cfg.Telemetry.MonitorInterval)
+
+	if cfg.MQTT.Broker != "" {
+		subscriber, err := startSubscriber(cfg, db, monitor)
+		if err != nil {
+			return err
+		}
+		defer subscriber.Stop()
+	}
+
+	r := newRouter(cfg, db, images)
+
+	log.Printf("Server running and serving at Port %d...", cfg.Server.Port)
+	if err := r.Run(cfg.Server.Address()); err != nil {
+		return fmt.Errorf("server could not start: %w", err)
+	}
+	return nil
+}
+
+// migrateCommand applies, reverts or reports the versioned schema migrations
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("migrate expects one of up, down, status\n\n%s", usage)
+	}
+	action := args[0]
+	flags, configPath := newFlagSet("migrate " + action)
+	steps := flags.Int("steps", 1, "number of migrations to revert with down")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrator.Up()
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		log.Printf("Schema up to date, %d migrations applied", len(applied))
+		images, err := newImages(cfg, db)
+		if err != nil {
+			return err
+		}
+		return moveLegacyImages(db, images)
+	case "down":
+		if *steps < 1 {
+			return fmt.Errorf("steps must be at least 1")
+		}
+		reverted, err := migrator.Down(*steps)
+		for _, migration := range reverted {
+			log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, migration := range status {
+			state := "pending"
+			if migration.AppliedAt != nil {
+				state = "applied " + migration.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%-32s %s\n", migration.Version, migration.Name, state)
+		}
+	default:
+		return fmt.Errorf("unknown migrate action %q, expected up, down or status", action)
+	}
+	return nil
+}
+
+// seedCommand imports the JSON test data from a directory
+func seedCommand(args []string) error {
+	flags, configPath := newFlagSet("seed")
+	dir := flags.String("dir", "", "directory with the JSON test data (default from configuration)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	if *dir == "" {
+		*dir = cfg.Database.SeedDirectory
+	}
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+
+	images, err := newImages(cfg, db)
+	if err != nil {
+		return err
+	}
+
+	database.CreateTestData(db, *dir, images)
+	return nil
+}
+
+// dbCommand runs administrative database operations
+func dbCommand(args []string) error {
+	if len(args) == 0 || args[0] != "recreate" {
+		return fmt.Errorf("db expects the action recreate\n\n%s", usage)
+	}
+	flags, configPath := newFlagSet("db recreate")
+	confirmed := flags.Bool("yes", false, "confirm dropping all data")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if !*confirmed {
+		return fmt.Errorf("db recreate drops all data, pass --yes to confirm")
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	if err := database.RecreateDatabase(cfg.Database.AdminDSN(), cfg.Database.Name, nil); err != nil {
+		return fmt.Errorf("failed to recreate database: %w", err)
+	}
+	log.Printf("Database %s recreated", cfg.Database.Name)
+	return nil
+}
+
+// userCommand manages user accounts
+func userCommand(args []string) error {
+	if len(args) == 0 || args[0] != "set-role" {
+		return fmt.Errorf("user expects the action set-role\n\n%s", usage)
+	}
+	flags, configPath := newFlagSet("user set-role")
+	email := flags.String("email", "", "email of the user")
+	role := flags.String("role", "", "new role: user, operator or admin")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *email == "" || *role == "" {
+		return fmt.Errorf("user set-role requires --email and --role")
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	db, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+
+	users := repository.NewGormRepositories(db).Users
+	ctx := context.Background()
+	user, err := users.GetByEmail(ctx, *email)
+	if err != nil {
+		return fmt.Errorf("failed to find user %s: %w", *email, err)
+	}
+	if err := users.SetRole(ctx, user.ID, *role); err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	log.Printf("User %s is now %s", *email, *role)
+	return nil
+}
@@ -0,0 +1,240 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// envKeys are the variables Load reads
+var envKeys = []string{
+	"CONFIG_FILE", "PORT",
+	"DATABASE_URL", "DB_HOST", "DB_PORT", "DB_USER", "DB_PASSWORD", "DB_NAME", "DB_SSLMODE",
+	"DB_RECREATE", "DB_MIGRATE", "DB_SEED", "DB_SEED_DIRECTORY",
+	"JWT_SECRET", "ACCESS_TOKEN_TTL", "REFRESH_TOKEN_TTL",
+	"STORAGE_BACKEND", "STORAGE_DIRECTORY", "S3_ENDPOINT", "S3_REGION", "S3_BUCKET", "S3_ACCESS_KEY_ID", "S3_SECRET_ACCESS_KEY",
+	"MQTT_BROKER", "MQTT_CLIENT_ID", "MQTT_USERNAME", "MQTT_PASSWORD", "MQTT_TOPIC_PREFIX",
+	"HEARTBEAT_TIMEOUT", "HEARTBEAT_MONITOR_INTERVAL", "CORS_ALLOWED_ORIGINS", "LOG_LEVEL",
+}
+
+// clearEnv unsets the variables Load reads for the duration of the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, key := range envKeys {
+		if value, ok := os.LookupEnv(key); ok {
+			os.Unsetenv(key)
+			t.Cleanup(func() { os.Setenv(key, value) })
+		}
+	}
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	cases := []struct {
+		name  string
+		file  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+		err   string
+	}{
+		{
+			name: "defaults",
+			env:  map[string]string{"JWT_SECRET": testSecret, "DB_PASSWORD": "secret"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Address() != ":8080" || cfg.Database.Port != 5432 || cfg.Database.SSLMode != "disable" {
+					t.Errorf("unexpected defaults: %+v", cfg)
+				}
+				if cfg.Auth.AccessTokenTTL != 15*time.Minute || cfg.Storage.Backend != "file" || cfg.Log.Level != "info" {
+					t.Errorf("unexpected defaults: %+v", cfg)
+				}
+				if cfg.Database.Migrate || cfg.Database.Seed || cfg.Database.Recreate {
+					t.Errorf("expected the startup tasks to be disabled, got %+v", cfg.Database)
+				}
+			},
+		},
+		{
+			name: "file",
+			file: "server:\n  port: 9090\ndatabase:\n  url: \"sqlite::memory:\"\n  migrate: true\nauth:\n  jwt_secret: " + testSecret + "\nlog:\n  level: DEBUG\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != 9090 || cfg.Database.DSN() != "sqlite::memory:" || !cfg.Database.Migrate {
+					t.Errorf("file values not applied: %+v", cfg)
+				}
+				if cfg.Log.Level != "debug" {
+					t.Errorf("expected the log level to be normalized, got %s", cfg.Log.Level)
+				}
+				if cfg.Auth.RefreshTokenTTL != 30*24*time.Hour {
+					t.Errorf("expected the defaults to remain for values missing in the file, got %s", cfg.Auth.RefreshTokenTTL)
+				}
+			},
+		},
+		{
+			name: "environment overrides the file",
+			file: "server:\n  port: 9090\ndatabase:\n  url: \"sqlite::memory:\"\nauth:\n  jwt_secret: " + testSecret + "\n",
+			env: map[string]string{
+				"PORT": "7070", "DB_SEED": "true", "ACCESS_TOKEN_TTL": "5m",
+				"CORS_ALLOWED_ORIGINS": "https://a.example, ,https://b.example", "HEARTBEAT_TIMEOUT": "2m",
+			},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Server.Port != 7070 || !cfg.Database.Seed || cfg.Auth.AccessTokenTTL != 5*time.Minute || cfg.Telemetry.HeartbeatTimeout != 2*time.Minute {
+					t.Errorf("environment not applied: %+v", cfg)
+				}
+				if origins := strings.Join(cfg.CORS.AllowedOrigins, " "); origins != "https://a.example https://b.example" {
+					t.Errorf("unexpected origins: %q", origins)
+				}
+			},
+		},
+		{
+			name: "postgres url",
+			env:  map[string]string{"JWT_SECRET": testSecret, "DATABASE_URL": "postgres://user:pw@db:5432/poseidon?sslmode=require"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.Name != "poseidon" {
+					t.Errorf("expected the name from the url, got %q", cfg.Database.Name)
+				}
+				if dsn := cfg.Database.AdminDSN(); dsn != "postgres://user:pw@db:5432/postgres?sslmode=require" {
+					t.Errorf("unexpected admin dsn: %s", dsn)
+				}
+			},
+		},
+		{
+			name: "connection fields",
+			env:  map[string]string{"JWT_SECRET": testSecret, "DB_HOST": "localhost", "DB_PASSWORD": "pw", "DB_NAME": "water"},
+			check: func(t *testing.T, cfg *Config) {
+				if dsn := cfg.Database.DSN(); dsn != "host=localhost user=postgres password=pw port=5432 sslmode=disable dbname=water" {
+					t.Errorf("unexpected dsn: %s", dsn)
+				}
+				if dsn := cfg.Database.AdminDSN(); !strings.HasSuffix(dsn, "dbname=postgres") {
+					t.Errorf("unexpected admin dsn: %s", dsn)
+				}
+			},
+		},
+		{name: "invalid integer", env: map[string]string{"JWT_SECRET": testSecret, "PORT": "http"}, err: "invalid value for PORT"},
+		{name: "invalid boolean", env: map[string]string{"JWT_SECRET": testSecret, "DB_MIGRATE": "sometimes"}, err: "invalid value for DB_MIGRATE"},
+		{name: "invalid duration", env: map[string]string{"JWT_SECRET": testSecret, "REFRESH_TOKEN_TTL": "30"}, err: "invalid value for REFRESH_TOKEN_TTL"},
+		{name: "malformed file", file: "server: [", err: "failed to parse config file"},
+		{
+			name: "missing secret",
+			env:  map[string]string{"DATABASE_URL": "sqlite::memory:"},
+			check: func(t *testing.T, cfg *Config) {
+				if err := cfg.ValidateServe(); err == nil || !strings.Contains(err.Error(), "jwt secret") {
+					t.Errorf("expected serving without a secret to fail, got %v", err)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
+			path := ""
+			if tc.file != "" {
+				path = writeConfigFile(t, tc.file)
+			}
+
+			cfg, err := Load(path)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tc.check(t, cfg)
+		})
+	}
+}
+
+func TestLoadConfigFileVariable(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, "database:\n  url: sqlite://poseidon.db\nauth:\n  jwt_secret: "+testSecret+"\n"))
+
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.DSN() != "sqlite://poseidon.db" || cfg.Database.AdminDSN() != "sqlite://poseidon.db" {
+		t.Errorf("expected the file of CONFIG_FILE to be loaded, got %+v", cfg.Database)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.yaml")); err == nil || !strings.Contains(err.Error(), "failed to read config file") {
+		t.Errorf("expected a missing file to fail, got %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() Config {
+		cfg := Default()
+		cfg.Auth.JWTSecret = testSecret
+		cfg.Database.Password = "secret"
+		return cfg
+	}
+	cases := []struct {
+		name   string
+		modify func(cfg *Config)
+		err    string
+	}{
+		{"valid", func(cfg *Config) {}, ""},
+		{"port out of range", func(cfg *Config) { cfg.Server.Port = 70000 }, "invalid server port"},
+		{"negative token lifetime", func(cfg *Config) { cfg.Auth.AccessTokenTTL = -time.Minute }, "token lifetimes"},
+		{"sqlite url", func(cfg *Config) { cfg.Database = DatabaseConfig{URL: "sqlite://poseidon.db"} }, ""},
+		{"sqlite memory url", func(cfg *Config) { cfg.Database = DatabaseConfig{URL: "sqlite::memory:"} }, ""},
+		{"postgresql url", func(cfg *Config) { cfg.Database = DatabaseConfig{URL: "postgresql://user@db/poseidon"} }, ""},
+		{"postgres url without name", func(cfg *Config) { cfg.Database = DatabaseConfig{URL: "postgres://user@db:5432"} }, "database url needs a database name"},
+		{"unsupported url scheme", func(cfg *Config) { cfg.Database = DatabaseConfig{URL: "mysql://user@db/poseidon"} }, "unsupported database url scheme"},
+		{"missing host", func(cfg *Config) { cfg.Database.Host = "" }, "database host is required"},
+		{"invalid database port", func(cfg *Config) { cfg.Database.Port = 0 }, "invalid database port"},
+		{"missing user", func(cfg *Config) { cfg.Database.User = "" }, "database user is required"},
+		{"missing password", func(cfg *Config) { cfg.Database.Password = "" }, "database password is required"},
+		{"missing name", func(cfg *Config) { cfg.Database.Name = "" }, "database name is required"},
+		{"invalid sslmode", func(cfg *Config) { cfg.Database.SSLMode = "always" }, "invalid database sslmode"},
+		{"seed without directory", func(cfg *Config) { cfg.Database.Seed, cfg.Database.SeedDirectory = true, "" }, "seed directory is required"},
+		{"file storage without directory", func(cfg *Config) { cfg.Storage.Directory = "" }, "storage directory is required"},
+		{"s3 without bucket", func(cfg *Config) { cfg.Storage.Backend = "s3" }, "s3 endpoint and bucket are required"},
+		{"s3 without credentials", func(cfg *Config) {
+			cfg.Storage.Backend, cfg.Storage.S3.Endpoint, cfg.Storage.S3.Bucket = "s3", "http://localhost:9000", "images"
+		}, "s3 credentials are required"},
+		{"unknown storage backend", func(cfg *Config) { cfg.Storage.Backend = "ftp" }, "invalid storage backend"},
+		{"mqtt broker", func(cfg *Config) { cfg.MQTT.Broker = "tcp://localhost:1883" }, ""},
+		{"mqtt broker without host", func(cfg *Config) { cfg.MQTT.Broker = "tcp://" }, "invalid mqtt broker url"},
+		{"mqtt broker with unknown scheme", func(cfg *Config) { cfg.MQTT.Broker = "http://localhost:1883" }, "invalid mqtt broker url"},
+		{"mqtt topic prefix with wildcard", func(cfg *Config) { cfg.MQTT.Broker, cfg.MQTT.TopicPrefix = "tcp://localhost:1883", "poseidon/#" }, "invalid mqtt topic prefix"},
+		{"non positive heartbeat timeout", func(cfg *Config) { cfg.Telemetry.HeartbeatTimeout = 0 }, "heartbeat timeout"},
+		{"unknown log level", func(cfg *Config) { cfg.Log.Level = "verbose" }, "invalid log level"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := valid()
+			tc.modify(&cfg)
+			before := cfg
+			err := cfg.Validate()
+			if !reflect.DeepEqual(cfg, before) {
+				t.Errorf("expected validation to leave the config unchanged, got %+v", cfg)
+			}
+			if tc.err == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
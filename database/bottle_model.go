@@ -9,16 +9,19 @@ import (
 
 var BottleWaterTypes []string = []string{"tap", "mineral"}
 
-// Bottle Model (previously NFCChip)
+// Bottle Model (previously NFCChip). BottleImage is a base64 encoded image of create
+// and update requests, it is stored in the blob store and referenced by ImageID. NFCID
+// keeps the JSON key NFCID that clients have always used.
 // @swagger:model
 type Bottle struct {
 	ID                uint               `gorm:"primaryKey" json:"id"`
 	UserID            uint               `gorm:"not null" json:"user_id"`
-	NFCID             string             `gorm:"size:20; json:"nfc_id"`
+	NFCID             string             `gorm:"size:20" json:"NFCID"`
 	FillVolume        int                `gorm:"not null" json:"fill_volume"`
 	WaterType         string             `gorm:"size:16;not null" json:"water_type"`
 	Title             string             `gorm:"size:16;not null" json:"title"`
-	BottleImage       *string            `gorm:"type:TEXT;default:null" json:"bottle_image,omitempty"`
+	ImageID           *uint              `json:"image_id,omitempty"`
+	BottleImage       *string            `gorm:"-" json:"bottle_image,omitempty"`
 	Active            bool               `gorm:"default:true" json:"active"`
 	WaterTransactions []WaterTransaction `gorm:"foreignKey:BottleID" json:"-"`
 }
@@ -28,18 +31,31 @@ func (Bottle) TableName() string {
 	return "bottle"
 }
 
-func (bottle *Bottle) BeforeCreate(tx *gorm.DB) (err error) {
+// Validate checks and normalizes the water type
+func (bottle *Bottle) Validate() error {
 	waterType := strings.ToLower(bottle.WaterType)
 	if !contains(BottleWaterTypes, waterType) {
 		return fmt.Errorf("invalid water type: %s", bottle.WaterType)
 	}
 	bottle.WaterType = waterType
+	return nil
+}
+
+// ErrNFCIDExists reports an NFC ID that is already assigned to another bottle
+func ErrNFCIDExists(nfcID string) error {
+	return fmt.Errorf("NFC ID '%s' %w", nfcID, ErrAlreadyExists)
+}
+
+func (bottle *Bottle) BeforeCreate(tx *gorm.DB) (err error) {
+	if err := bottle.Validate(); err != nil {
+		return err
+	}
 
 	// If there is already a bottle with this nfc id no second can be added with same id, empty id is always possible
 	var count int64
 	if result := tx.Model(&Bottle{}).Where("nfc_id = ?", bottle.NFCID).Count(&count); result.Error == nil {
 		if count != 0 && bottle.NFCID != "" {
-			return fmt.Errorf("NFC ID '%s' already exists", bottle.NFCID)
+			return ErrNFCIDExists(bottle.NFCID)
 		}
 	}
 
@@ -1,26 +1,79 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// Driver names, equal to the dialector names used by gorm
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// DriverName returns the driver selected by the scheme of the DSN.
+// DSNs starting with sqlite: use SQLite, everything else is passed to Postgres.
+func DriverName(dsn string) string {
+	if strings.HasPrefix(dsn, DriverSQLite+":") {
+		return DriverSQLite
+	}
+	return DriverPostgres
+}
+
+// sqliteDSN strips the sqlite: scheme and enables the foreign key checks,
+// e.g. sqlite://poseidon.db, sqlite:///tmp/poseidon.db or sqlite::memory:
+func sqliteDSN(dsn string) string {
+	path := strings.TrimPrefix(dsn, DriverSQLite+":")
+	path = strings.TrimPrefix(path, "//")
+	separator := "?"
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	return path + separator + "_foreign_keys=on"
+}
+
 func ConnectDatabase(dsn string, db *gorm.DB) (*gorm.DB, error) {
 	var err error
-	db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	driver := DriverName(dsn)
+	if driver == DriverSQLite {
+		db, err = gorm.Open(sqlite.Open(sqliteDSN(dsn)), &gorm.Config{})
+	} else {
+		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	}
 	if err != nil {
-		log.Fatalf("Failed to connect database: %v", err)
+		return nil, err
+	}
+
+	if driver == DriverSQLite {
+		// SQLite allows a single writer, and every connection to :memory: opens a new database
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
 	}
-	fmt.Println("Successfully connected to database!")
+	log.Printf("Successfully connected to %s database!", driver)
 
 	return db, nil
 }
 
+// errUnsupported reports an administrative operation the driver cannot perform
+func errUnsupported(operation, driver string) error {
+	return fmt.Errorf("%s is not supported on %s: %w", operation, driver, errors.ErrUnsupported)
+}
+
 // Function to drop and recreate the database
 func RecreateDatabase(adminDsn, dbName string, db *gorm.DB) error {
+	if driver := DriverName(adminDsn); driver != DriverPostgres {
+		return errUnsupported("recreating the database", driver)
+	}
+
 	// Connect to the PostgreSQL server without specifying a database (default is "postgres")
 	db, err := ConnectDatabase(adminDsn, db)
 	if err != nil {
@@ -47,6 +100,9 @@ func RecreateDatabase(adminDsn, dbName string, db *gorm.DB) error {
 
 // Function to terminate active connections to a database
 func terminateConnections(db *gorm.DB, dbName string) error {
+	if driver := db.Dialector.Name(); driver != DriverPostgres {
+		return errUnsupported("terminating connections", driver)
+	}
 	query := fmt.Sprintf(`
         SELECT pg_terminate_backend(pg_stat_activity.pid)
         FROM pg_stat_activity
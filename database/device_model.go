@@ -0,0 +1,35 @@
+package database
+
+import "time"
+
+// Device is the controller of a smart refill station. It authenticates with its
+// API keys and may only report for StationID. Revoking the device revokes all its keys.
+// LastSeenAt is the time of its last heartbeat.
+// @swagger:model
+type Device struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	StationID  uint       `gorm:"not null;index" json:"station_id"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// DeviceKey is an API key of a device. Only the SHA-256 hash of the key is stored,
+// Prefix is the start of the key to tell the keys of a device apart. Rotated keys
+// stay valid until ExpiresAt, so the device can switch to the new key.
+// @swagger:model
+type DeviceKey struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	DeviceID  uint       `gorm:"not null;index" json:"device_id"`
+	KeyHash   string     `gorm:"size:64;not null;unique" json:"-"`
+	Prefix    string     `gorm:"size:16;not null" json:"prefix"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Active reports whether the key can still be used at the given time
+func (key *DeviceKey) Active(now time.Time) bool {
+	return key.RevokedAt == nil && (key.ExpiresAt == nil || now.Before(*key.ExpiresAt))
+}
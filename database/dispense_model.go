@@ -0,0 +1,25 @@
+package database
+
+import "time"
+
+// Dispense authorizes a smart station to fill a scanned bottle with Volume milliliters
+// of WaterType. The station confirms the dispensed volume before ExpiresAt, which
+// creates the water transaction TransactionID.
+// @swagger:model
+type Dispense struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	StationID     uint       `gorm:"not null;index" json:"station_id"`
+	BottleID      uint       `gorm:"not null" json:"bottle_id"`
+	UserID        uint       `gorm:"not null" json:"user_id"`
+	Volume        int        `gorm:"not null" json:"volume"`
+	WaterType     string     `gorm:"size:16;not null" json:"water_type"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	TransactionID *uint      `gorm:"unique" json:"transaction_id,omitempty"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Open reports whether the dispense can still be confirmed at the given time
+func (dispense *Dispense) Open(now time.Time) bool {
+	return dispense.ConfirmedAt == nil && now.Before(dispense.ExpiresAt)
+}
@@ -0,0 +1,20 @@
+package database
+
+import "time"
+
+// Image is the metadata of an image file kept in the blob store under Key.
+// Checksum is the hex encoded SHA-256 hash of the file. Downscaled variants
+// of an image reference it by OriginalID and are named by Variant.
+// @swagger:model
+type Image struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"size:255;not null;unique" json:"-"`
+	ContentType string    `gorm:"size:100;not null" json:"content_type"`
+	Size        int64     `gorm:"not null" json:"size"`
+	Checksum    string    `gorm:"size:64;not null" json:"checksum"`
+	Width       int       `gorm:"not null" json:"width"`
+	Height      int       `gorm:"not null" json:"height"`
+	OriginalID  *uint     `gorm:"uniqueIndex:idx_images_variant" json:"original_id,omitempty"`
+	Variant     string    `gorm:"size:16;not null;uniqueIndex:idx_images_variant" json:"variant,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
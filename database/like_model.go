@@ -14,12 +14,15 @@ type Like struct {
 	UserID    uint `gorm:"not null" json:"user_id"`
 }
 
+// ErrLikeExists reports a second like of the same user for the same station
+var ErrLikeExists = fmt.Errorf("Like %w", ErrAlreadyExists)
+
 func (like *Like) BeforeCreate(tx *gorm.DB) (err error) {
 	var count int64
 	// Check if like is already present in database (Same Refill Station and User)
 	if result := tx.Model(&Like{}).Where(&Like{StationID: like.StationID, UserID: like.UserID}).Count(&count); result.Error == nil {
 		if count != 0 {
-			return fmt.Errorf("Like already exists")
+			return ErrLikeExists
 		}
 	}
 	return nil
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// migrationLockID identifies the advisory lock held while migrating, so two
+// machines starting at the same time do not migrate concurrently
+const migrationLockID = 5_821_337
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with the SQL to apply and revert it
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// SchemaMigration is one row of the schema_migrations bookkeeping table
+type SchemaMigration struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Migrator applies and reverts the embedded migrations
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator loads the embedded migrations for the database
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dir, err := fs.Sub(migrationFiles, path.Join("migrations", db.Dialector.Name()))
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads all <version>_<name>.up.sql and .down.sql files of a directory
+// sorted by version. Every version needs both files.
+func LoadMigrations(dir fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(dir, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(dir, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies all pending migrations and returns them
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(func(conn *gorm.DB, done map[int]SchemaMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := done[migration.Version]; ok {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Up).Error; err != nil {
+					return err
+				}
+				return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the given number of most recently applied migrations and returns them
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(func(conn *gorm.DB, done map[int]SchemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := done[migration.Version]; !ok {
+				continue
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Exec(migration.Down).Error; err != nil {
+					return err
+				}
+				return tx.Delete(&SchemaMigration{}, migration.Version).Error
+			})
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists all known migrations with the time they were applied
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	var status []MigrationStatus
+	err := m.locked(func(conn *gorm.DB, done map[int]SchemaMigration) error {
+		for _, migration := range m.migrations {
+			entry := MigrationStatus{Migration: migration}
+			if row, ok := done[migration.Version]; ok {
+				entry.AppliedAt = &row.AppliedAt
+			}
+			status = append(status, entry)
+		}
+		return nil
+	})
+	return status, err
+}
+
+// schemaMigrationsTable creates the bookkeeping table in the dialect of each driver
+var schemaMigrationsTable = map[string]string{
+	DriverPostgres: `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" bigint PRIMARY KEY,
+			"name" varchar(255) NOT NULL,
+			"applied_at" timestamptz NOT NULL
+		)`,
+	DriverSQLite: `CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" integer PRIMARY KEY,
+			"name" varchar(255) NOT NULL,
+			"applied_at" datetime NOT NULL
+		)`,
+}
+
+// locked runs fn on a single connection holding the migration lock and passes the applied migrations.
+// SQLite has no advisory locks, its migrations rely on the database file lock of each transaction.
+func (m *Migrator) locked(fn func(conn *gorm.DB, done map[int]SchemaMigration) error) error {
+	driver := m.db.Dialector.Name()
+	createTable, ok := schemaMigrationsTable[driver]
+	if !ok {
+		return errUnsupported("migrating", driver)
+	}
+
+	return m.db.Connection(func(conn *gorm.DB) error {
+		if driver == DriverPostgres {
+			if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+				return fmt.Errorf("failed to acquire migration lock: %w", err)
+			}
+			defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+		}
+
+		if err := conn.Exec(createTable).Error; err != nil {
+			return fmt.Errorf("failed to create schema_migrations table: %w", err)
+		}
+
+		var rows []SchemaMigration
+		if err := conn.Find(&rows).Error; err != nil {
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		done := map[int]SchemaMigration{}
+		for _, row := range rows {
+			done[row.Version] = row
+		}
+		return fn(conn, done)
+	})
+}
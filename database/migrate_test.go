@@ -0,0 +1,118 @@
+package database_test
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+func TestMigrationsMatchAcrossDrivers(t *testing.T) {
+	postgres, err := database.LoadMigrations(os.DirFS("migrations/postgres"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlite, err := database.LoadMigrations(os.DirFS("migrations/sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(postgres) != len(sqlite) {
+		t.Fatalf("postgres has %d migrations, sqlite %d", len(postgres), len(sqlite))
+	}
+	for i := range postgres {
+		if postgres[i].Version != sqlite[i].Version || postgres[i].Name != sqlite[i].Name {
+			t.Errorf("migration %d_%s has no sqlite counterpart, got %d_%s",
+				postgres[i].Version, postgres[i].Name, sqlite[i].Version, sqlite[i].Name)
+		}
+	}
+}
+
+func TestMigratorOnSQLite(t *testing.T) {
+	db, err := database.ConnectDatabase("sqlite::memory:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+	if len(applied) == 0 {
+		t.Fatal("expected migrations to be applied")
+	}
+	if applied, err := migrator.Up(); err != nil || len(applied) != 0 {
+		t.Fatalf("expected second up to be a no-op, got %d migrations, %v", len(applied), err)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, migration := range status {
+		if migration.AppliedAt == nil {
+			t.Errorf("expected migration %d_%s to be applied", migration.Version, migration.Name)
+		}
+	}
+
+	if _, err := migrator.Down(len(status)); err != nil {
+		t.Fatalf("down failed: %v", err)
+	}
+	if db.Migrator().HasTable("users") {
+		t.Error("expected users table to be dropped")
+	}
+}
+
+func TestImageRollbackRefusedWhileReferenced(t *testing.T) {
+	db, err := database.ConnectDatabase("sqlite::memory:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("up failed: %v", err)
+	}
+	statements := []string{
+		`INSERT INTO "users" ("id", "first_name", "last_name", "email") VALUES (1, 'Ada', 'Lovelace', 'ada@poseidon.test')`,
+		`INSERT INTO "images" ("id", "key", "content_type", "size", "checksum") VALUES (1, 'images/1', 'image/png', 1, 'sum')`,
+		`INSERT INTO "bottle" ("id", "user_id", "fill_volume", "water_type", "title", "image_id") VALUES (1, 1, 750, 'tap', 'Bottle', 1)`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(len(status)); err == nil || !strings.Contains(err.Error(), "images in the blob store would be lost") {
+		t.Fatalf("expected the rollback of the images to be refused, got %v", err)
+	}
+	if !db.Migrator().HasColumn("bottle", "image_id") {
+		t.Error("expected the image reference to be kept")
+	}
+
+	if err := db.Exec(`UPDATE "bottle" SET "image_id" = NULL`).Error; err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(len(status)); err != nil {
+		t.Fatalf("expected the rollback without images to succeed, got %v", err)
+	}
+}
+
+func TestRecreateDatabaseUnsupportedOnSQLite(t *testing.T) {
+	err := database.RecreateDatabase("sqlite://poseidon.db", "poseidon_db", nil)
+	if !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("expected unsupported error, got %v", err)
+	}
+}
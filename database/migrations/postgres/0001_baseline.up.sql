@@ -0,0 +1,91 @@
+-- Baseline schema, equivalent to the tables previously created by gorm AutoMigrate.
+-- IF NOT EXISTS keeps the migration a no-op on databases created that way.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" bigserial,
+    "first_name" varchar(100) NOT NULL,
+    "last_name" varchar(100) NOT NULL,
+    "email" varchar(100) DEFAULT null,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS "bottle" (
+    "id" bigserial,
+    "user_id" bigint NOT NULL,
+    "nfc_id" varchar(20),
+    "fill_volume" bigint NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "title" varchar(16) NOT NULL,
+    "bottle_image" TEXT DEFAULT null,
+    "active" boolean DEFAULT true,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_bottles" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "refill_stations" (
+    "id" bigserial,
+    "name" varchar(100) NOT NULL,
+    "description" varchar(255) NOT NULL,
+    "latitude" decimal NOT NULL,
+    "longitude" decimal NOT NULL,
+    "address" varchar(255) NOT NULL,
+    "water_source" varchar(50) NOT NULL,
+    "opening_times" varchar(100) NOT NULL,
+    "active" boolean DEFAULT true,
+    "type" varchar(16) NOT NULL,
+    "offered_water_types" varchar(32) NOT NULL,
+    "refill_station_image" TEXT DEFAULT null,
+    PRIMARY KEY ("id")
+);
+
+CREATE TABLE IF NOT EXISTS "refill_station_reviews" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "cleanness" bigint NOT NULL,
+    "accessibility" bigint NOT NULL,
+    "water_quality" bigint NOT NULL,
+    "timestamp" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_reviews" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_refill_stations_reviews" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id"),
+    CONSTRAINT "chk_refill_station_reviews_cleanness" CHECK (cleanness >= 1 AND cleanness <= 5),
+    CONSTRAINT "chk_refill_station_reviews_accessibility" CHECK (accessibility >= 1 AND accessibility <= 5),
+    CONSTRAINT "chk_refill_station_reviews_water_quality" CHECK (water_quality >= 1 AND water_quality <= 5)
+);
+
+CREATE TABLE IF NOT EXISTS "refill_station_problem" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "title" varchar(100) NOT NULL,
+    "description" varchar(255) NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "refill_station_problem_image" TEXT DEFAULT null,
+    "timestamp" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refill_stations_problems" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "water_transactions" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "bottle_id" bigint DEFAULT null,
+    "user_id" bigint DEFAULT null,
+    "volume" bigint NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "timestamp" timestamptz,
+    "guest" boolean DEFAULT false,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_bottle_water_transactions" FOREIGN KEY ("bottle_id") REFERENCES "bottle"("id"),
+    CONSTRAINT "fk_refill_stations_water_transactions" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "likes" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_users_likes" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_refill_stations_likes" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
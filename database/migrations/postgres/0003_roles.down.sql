@@ -0,0 +1,2 @@
+ALTER TABLE "refill_stations" DROP COLUMN IF EXISTS "owner_id";
+ALTER TABLE "users" DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar(16) NOT NULL DEFAULT 'user'
+    CONSTRAINT "chk_users_role" CHECK (role IN ('user', 'operator', 'admin'));
+
+ALTER TABLE "refill_stations" ADD COLUMN "owner_id" bigint DEFAULT null
+    CONSTRAINT "fk_users_refill_stations" REFERENCES "users"("id") ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE "refill_stations" ALTER COLUMN "opening_times" TYPE varchar(100) USING left("opening_times", 100);
+ALTER TABLE "refill_stations" DROP COLUMN IF EXISTS "opening_hours";
@@ -0,0 +1,3 @@
+-- Structured opening hours as JSON, the formatted text can exceed the old length
+ALTER TABLE "refill_stations" ADD COLUMN "opening_hours" text;
+ALTER TABLE "refill_stations" ALTER COLUMN "opening_times" TYPE varchar(255);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_refill_stations_search_vector";
+ALTER TABLE "refill_stations" DROP COLUMN IF EXISTS "search_vector";
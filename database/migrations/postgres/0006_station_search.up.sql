@@ -0,0 +1,8 @@
+-- Weighted German full-text vector of the searchable station texts
+ALTER TABLE "refill_stations" ADD COLUMN "search_vector" tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('german', coalesce("name", '')), 'A') ||
+    setweight(to_tsvector('german', coalesce("address", '')), 'B') ||
+    setweight(to_tsvector('german', coalesce("water_source", '')), 'C') ||
+    setweight(to_tsvector('german', coalesce("description", '')), 'D')
+) STORED;
+CREATE INDEX IF NOT EXISTS "idx_refill_stations_search_vector" ON "refill_stations" USING GIN ("search_vector");
@@ -0,0 +1,15 @@
+-- The images live in the blob store and cannot be copied back into the base64 columns,
+-- so the rollback is refused while rows still reference an image.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM "bottle" WHERE "image_id" IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM "refill_stations" WHERE "image_id" IS NOT NULL)
+        OR EXISTS (SELECT 1 FROM "refill_station_problem" WHERE "image_id" IS NOT NULL) THEN
+        RAISE EXCEPTION 'images in the blob store would be lost, remove the images of bottles, stations and problems before rolling back';
+    END IF;
+END $$;
+
+ALTER TABLE "refill_station_problem" DROP COLUMN IF EXISTS "image_id";
+ALTER TABLE "refill_stations" DROP COLUMN IF EXISTS "image_id";
+ALTER TABLE "bottle" DROP COLUMN IF EXISTS "image_id";
+DROP TABLE IF EXISTS "images";
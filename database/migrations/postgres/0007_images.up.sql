@@ -0,0 +1,17 @@
+-- Image files move to the blob store, the tables reference their metadata.
+-- The base64 columns stay until the application has moved their data, see media.MoveLegacyImages.
+-- Moved images cannot be copied back, the down migration refuses to run while rows reference one.
+CREATE TABLE "images" (
+    "id" bigserial,
+    "key" varchar(255) NOT NULL,
+    "content_type" varchar(100) NOT NULL,
+    "size" bigint NOT NULL,
+    "checksum" varchar(64) NOT NULL,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_images_key" UNIQUE ("key")
+);
+
+ALTER TABLE "bottle" ADD COLUMN "image_id" bigint REFERENCES "images"("id") ON DELETE SET NULL;
+ALTER TABLE "refill_stations" ADD COLUMN "image_id" bigint REFERENCES "images"("id") ON DELETE SET NULL;
+ALTER TABLE "refill_station_problem" ADD COLUMN "image_id" bigint REFERENCES "images"("id") ON DELETE SET NULL;
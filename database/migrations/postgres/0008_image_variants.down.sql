@@ -0,0 +1,7 @@
+-- The files of the variants stay in the blob store
+DELETE FROM "images" WHERE "original_id" IS NOT NULL;
+DROP INDEX IF EXISTS "idx_images_variant";
+ALTER TABLE "images" DROP COLUMN IF EXISTS "variant";
+ALTER TABLE "images" DROP COLUMN IF EXISTS "original_id";
+ALTER TABLE "images" DROP COLUMN IF EXISTS "height";
+ALTER TABLE "images" DROP COLUMN IF EXISTS "width";
@@ -0,0 +1,20 @@
+-- Dispense authorizations of smart stations, confirmed by the water transaction they created
+CREATE TABLE "dispenses" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "bottle_id" bigint NOT NULL,
+    "user_id" bigint NOT NULL,
+    "volume" bigint NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "transaction_id" bigint,
+    "confirmed_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_dispenses_transaction_id" UNIQUE ("transaction_id"),
+    CONSTRAINT "fk_refill_stations_dispenses" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_bottle_dispenses" FOREIGN KEY ("bottle_id") REFERENCES "bottle"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_water_transactions_dispenses" FOREIGN KEY ("transaction_id") REFERENCES "water_transactions"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_dispenses_station_id" ON "dispenses" ("station_id");
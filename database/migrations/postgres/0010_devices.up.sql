@@ -0,0 +1,27 @@
+-- Devices of smart stations and their API keys, of which only the hashes are stored
+CREATE TABLE "devices" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "name" varchar(100) NOT NULL,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refill_stations_devices" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_devices_station_id" ON "devices" ("station_id");
+
+CREATE TABLE "device_keys" (
+    "id" bigserial,
+    "device_id" bigint NOT NULL,
+    "key_hash" varchar(64) NOT NULL,
+    "prefix" varchar(16) NOT NULL,
+    "expires_at" timestamptz,
+    "revoked_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_device_keys_key_hash" UNIQUE ("key_hash"),
+    CONSTRAINT "fk_devices_device_keys" FOREIGN KEY ("device_id") REFERENCES "devices"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_device_keys_device_id" ON "device_keys" ("device_id");
@@ -0,0 +1,3 @@
+ALTER TABLE "refill_stations" DROP COLUMN IF EXISTS "offline_since";
+ALTER TABLE "devices" DROP COLUMN IF EXISTS "last_seen_at";
+DROP TABLE IF EXISTS "station_telemetry";
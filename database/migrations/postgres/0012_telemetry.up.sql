@@ -0,0 +1,20 @@
+-- Measurements reported by the station devices, and the heartbeat state of devices and stations
+CREATE TABLE "station_telemetry" (
+    "id" bigserial,
+    "station_id" bigint NOT NULL,
+    "device_id" bigint NOT NULL,
+    "recorded_at" timestamptz NOT NULL,
+    "tank_level" decimal,
+    "filter_status" varchar(16),
+    "temperature" decimal,
+    "flow_rate" decimal,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refill_stations_telemetry" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_devices_telemetry" FOREIGN KEY ("device_id") REFERENCES "devices"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_station_telemetry_recorded_at" ON "station_telemetry" ("station_id", "recorded_at");
+
+ALTER TABLE "devices" ADD COLUMN "last_seen_at" timestamptz;
+ALTER TABLE "refill_stations" ADD COLUMN "offline_since" timestamptz;
@@ -0,0 +1,2 @@
+ALTER TABLE "station_status_changes" DROP COLUMN IF EXISTS "problem_id";
+DROP TABLE IF EXISTS "problem_status_changes";
@@ -0,0 +1,19 @@
+-- Timeline of the status changes of the refill station problems
+CREATE TABLE "problem_status_changes" (
+    "id" bigserial,
+    "problem_id" bigint NOT NULL,
+    "from_status" varchar(16) NOT NULL,
+    "to_status" varchar(16) NOT NULL,
+    "comment" varchar(255) NOT NULL,
+    "actor_id" bigint,
+    "changed_at" timestamptz NOT NULL,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_refill_station_problem_status_changes" FOREIGN KEY ("problem_id") REFERENCES "refill_station_problem"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_users_problem_status_changes" FOREIGN KEY ("actor_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_problem_status_changes_problem_id" ON "problem_status_changes" ("problem_id");
+
+-- Stations deactivated because of a problem
+ALTER TABLE "station_status_changes" ADD COLUMN "problem_id" bigint
+    CONSTRAINT "fk_refill_station_problem_station_status_changes" REFERENCES "refill_station_problem"("id") ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE "refill_station_problem" DROP COLUMN IF EXISTS "deactivate_station";
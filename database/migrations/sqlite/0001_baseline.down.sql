@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS "likes";
+DROP TABLE IF EXISTS "water_transactions";
+DROP TABLE IF EXISTS "refill_station_problem";
+DROP TABLE IF EXISTS "refill_station_reviews";
+DROP TABLE IF EXISTS "refill_stations";
+DROP TABLE IF EXISTS "bottle";
+DROP TABLE IF EXISTS "users";
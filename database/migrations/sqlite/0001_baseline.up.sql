@@ -0,0 +1,83 @@
+-- Baseline schema, the SQLite counterpart of migrations/postgres/0001_baseline.up.sql.
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "first_name" varchar(100) NOT NULL,
+    "last_name" varchar(100) NOT NULL,
+    "email" varchar(100) DEFAULT null,
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+
+CREATE TABLE IF NOT EXISTS "bottle" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "nfc_id" varchar(20),
+    "fill_volume" integer NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "title" varchar(16) NOT NULL,
+    "bottle_image" text DEFAULT null,
+    "active" boolean DEFAULT true,
+    CONSTRAINT "fk_users_bottles" FOREIGN KEY ("user_id") REFERENCES "users"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "refill_stations" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "name" varchar(100) NOT NULL,
+    "description" varchar(255) NOT NULL,
+    "latitude" real NOT NULL,
+    "longitude" real NOT NULL,
+    "address" varchar(255) NOT NULL,
+    "water_source" varchar(50) NOT NULL,
+    "opening_times" varchar(100) NOT NULL,
+    "active" boolean DEFAULT true,
+    "type" varchar(16) NOT NULL,
+    "offered_water_types" varchar(32) NOT NULL,
+    "refill_station_image" text DEFAULT null
+);
+
+CREATE TABLE IF NOT EXISTS "refill_station_reviews" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "user_id" integer NOT NULL,
+    "cleanness" integer NOT NULL,
+    "accessibility" integer NOT NULL,
+    "water_quality" integer NOT NULL,
+    "timestamp" datetime,
+    CONSTRAINT "fk_users_reviews" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_refill_stations_reviews" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id"),
+    CONSTRAINT "chk_refill_station_reviews_cleanness" CHECK (cleanness >= 1 AND cleanness <= 5),
+    CONSTRAINT "chk_refill_station_reviews_accessibility" CHECK (accessibility >= 1 AND accessibility <= 5),
+    CONSTRAINT "chk_refill_station_reviews_water_quality" CHECK (water_quality >= 1 AND water_quality <= 5)
+);
+
+CREATE TABLE IF NOT EXISTS "refill_station_problem" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "title" varchar(100) NOT NULL,
+    "description" varchar(255) NOT NULL,
+    "status" varchar(16) NOT NULL,
+    "refill_station_problem_image" text DEFAULT null,
+    "timestamp" datetime,
+    CONSTRAINT "fk_refill_stations_problems" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "water_transactions" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "bottle_id" integer DEFAULT null,
+    "user_id" integer DEFAULT null,
+    "volume" integer NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "timestamp" datetime,
+    "guest" boolean DEFAULT false,
+    CONSTRAINT "fk_bottle_water_transactions" FOREIGN KEY ("bottle_id") REFERENCES "bottle"("id"),
+    CONSTRAINT "fk_refill_stations_water_transactions" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
+
+CREATE TABLE IF NOT EXISTS "likes" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "user_id" integer NOT NULL,
+    CONSTRAINT "fk_users_likes" FOREIGN KEY ("user_id") REFERENCES "users"("id"),
+    CONSTRAINT "fk_refill_stations_likes" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id")
+);
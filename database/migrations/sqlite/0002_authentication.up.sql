@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "password_hash" varchar(255) NOT NULL DEFAULT '';
+
+CREATE TABLE "refresh_tokens" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "user_id" integer NOT NULL,
+    "token_hash" varchar(64) NOT NULL,
+    "expires_at" datetime NOT NULL,
+    "revoked_at" datetime,
+    "created_at" datetime,
+    CONSTRAINT "uni_refresh_tokens_token_hash" UNIQUE ("token_hash"),
+    CONSTRAINT "fk_users_refresh_tokens" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
@@ -0,0 +1,5 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar(16) NOT NULL DEFAULT 'user'
+    CONSTRAINT "chk_users_role" CHECK (role IN ('user', 'operator', 'admin'));
+
+-- Without the foreign key of the postgres migration, SQLite cannot drop columns used in one
+ALTER TABLE "refill_stations" ADD COLUMN "owner_id" integer DEFAULT null;
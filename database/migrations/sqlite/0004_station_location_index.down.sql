@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "idx_refill_stations_location";
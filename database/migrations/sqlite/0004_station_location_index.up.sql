@@ -0,0 +1,2 @@
+-- Speeds up the bounding box prefilter of the nearby station search
+CREATE INDEX IF NOT EXISTS "idx_refill_stations_location" ON "refill_stations" ("latitude", "longitude");
@@ -0,0 +1 @@
+ALTER TABLE "refill_stations" DROP COLUMN "opening_hours";
@@ -0,0 +1,2 @@
+-- Structured opening hours as JSON, SQLite does not enforce the length of opening_times
+ALTER TABLE "refill_stations" ADD COLUMN "opening_hours" text;
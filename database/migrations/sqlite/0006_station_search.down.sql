@@ -0,0 +1 @@
+-- SQLite has no German full-text search, stations are searched with the in-process index
@@ -0,0 +1,17 @@
+-- The images live in the blob store and cannot be copied back into the base64 columns,
+-- so the rollback is refused while rows still reference an image.
+CREATE TEMP TABLE "image_rollback_check" ("referenced" integer);
+CREATE TEMP TRIGGER "image_rollback_check" BEFORE INSERT ON "image_rollback_check" WHEN NEW."referenced" > 0
+BEGIN
+    SELECT RAISE(ABORT, 'images in the blob store would be lost, remove the images of bottles, stations and problems before rolling back');
+END;
+INSERT INTO "image_rollback_check" SELECT
+    (SELECT COUNT(*) FROM "bottle" WHERE "image_id" IS NOT NULL)
+    + (SELECT COUNT(*) FROM "refill_stations" WHERE "image_id" IS NOT NULL)
+    + (SELECT COUNT(*) FROM "refill_station_problem" WHERE "image_id" IS NOT NULL);
+DROP TABLE "image_rollback_check";
+
+ALTER TABLE "refill_station_problem" DROP COLUMN "image_id";
+ALTER TABLE "refill_stations" DROP COLUMN "image_id";
+ALTER TABLE "bottle" DROP COLUMN "image_id";
+DROP TABLE IF EXISTS "images";
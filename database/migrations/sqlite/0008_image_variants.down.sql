@@ -0,0 +1,7 @@
+-- The files of the variants stay in the blob store
+DELETE FROM "images" WHERE "original_id" IS NOT NULL;
+DROP INDEX IF EXISTS "idx_images_variant";
+ALTER TABLE "images" DROP COLUMN "variant";
+ALTER TABLE "images" DROP COLUMN "original_id";
+ALTER TABLE "images" DROP COLUMN "height";
+ALTER TABLE "images" DROP COLUMN "width";
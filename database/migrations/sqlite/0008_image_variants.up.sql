@@ -0,0 +1,7 @@
+-- Images get their dimensions, downscaled variants reference their original.
+-- Images stored before have no dimensions and no variants.
+ALTER TABLE "images" ADD COLUMN "width" integer NOT NULL DEFAULT 0;
+ALTER TABLE "images" ADD COLUMN "height" integer NOT NULL DEFAULT 0;
+ALTER TABLE "images" ADD COLUMN "original_id" integer REFERENCES "images"("id") ON DELETE CASCADE;
+ALTER TABLE "images" ADD COLUMN "variant" varchar(16) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_images_variant" ON "images" ("original_id", "variant");
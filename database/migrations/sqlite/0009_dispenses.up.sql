@@ -0,0 +1,19 @@
+-- Dispense authorizations of smart stations, confirmed by the water transaction they created
+CREATE TABLE "dispenses" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "bottle_id" integer NOT NULL,
+    "user_id" integer NOT NULL,
+    "volume" integer NOT NULL,
+    "water_type" varchar(16) NOT NULL,
+    "expires_at" datetime NOT NULL,
+    "transaction_id" integer,
+    "confirmed_at" datetime,
+    "created_at" datetime,
+    CONSTRAINT "uni_dispenses_transaction_id" UNIQUE ("transaction_id"),
+    CONSTRAINT "fk_refill_stations_dispenses" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_bottle_dispenses" FOREIGN KEY ("bottle_id") REFERENCES "bottle"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_water_transactions_dispenses" FOREIGN KEY ("transaction_id") REFERENCES "water_transactions"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_dispenses_station_id" ON "dispenses" ("station_id");
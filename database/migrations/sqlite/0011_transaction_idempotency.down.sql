@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "idx_water_transactions_idempotency_key";
+ALTER TABLE "water_transactions" DROP COLUMN "idempotency_key";
@@ -0,0 +1,3 @@
+-- Stations send an idempotency key with every transaction, so retries do not count twice
+ALTER TABLE "water_transactions" ADD COLUMN "idempotency_key" varchar(64);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_water_transactions_idempotency_key" ON "water_transactions" ("station_id", "idempotency_key");
@@ -0,0 +1,19 @@
+-- Measurements reported by the station devices, and the heartbeat state of devices and stations
+CREATE TABLE "station_telemetry" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "device_id" integer NOT NULL,
+    "recorded_at" datetime NOT NULL,
+    "tank_level" real,
+    "filter_status" varchar(16),
+    "temperature" real,
+    "flow_rate" real,
+    "created_at" datetime,
+    CONSTRAINT "fk_refill_stations_telemetry" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_devices_telemetry" FOREIGN KEY ("device_id") REFERENCES "devices"("id") ON DELETE CASCADE
+);
+
+CREATE INDEX "idx_station_telemetry_recorded_at" ON "station_telemetry" ("station_id", "recorded_at");
+
+ALTER TABLE "devices" ADD COLUMN "last_seen_at" datetime;
+ALTER TABLE "refill_stations" ADD COLUMN "offline_since" datetime;
@@ -0,0 +1,16 @@
+-- Activations and deactivations of the refill stations
+CREATE TABLE "station_status_changes" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "station_id" integer NOT NULL,
+    "active" boolean NOT NULL,
+    "source" varchar(16) NOT NULL,
+    "reason" varchar(255) NOT NULL,
+    "actor_id" integer,
+    "device_id" integer,
+    "changed_at" datetime NOT NULL,
+    CONSTRAINT "fk_refill_stations_status_changes" FOREIGN KEY ("station_id") REFERENCES "refill_stations"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_users_status_changes" FOREIGN KEY ("actor_id") REFERENCES "users"("id") ON DELETE SET NULL,
+    CONSTRAINT "fk_devices_status_changes" FOREIGN KEY ("device_id") REFERENCES "devices"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_station_status_changes_changed_at" ON "station_status_changes" ("station_id", "changed_at");
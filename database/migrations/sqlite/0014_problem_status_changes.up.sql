@@ -0,0 +1,18 @@
+-- Timeline of the status changes of the refill station problems
+CREATE TABLE "problem_status_changes" (
+    "id" integer PRIMARY KEY AUTOINCREMENT,
+    "problem_id" integer NOT NULL,
+    "from_status" varchar(16) NOT NULL,
+    "to_status" varchar(16) NOT NULL,
+    "comment" varchar(255) NOT NULL,
+    "actor_id" integer,
+    "changed_at" datetime NOT NULL,
+    CONSTRAINT "fk_refill_station_problem_status_changes" FOREIGN KEY ("problem_id") REFERENCES "refill_station_problem"("id") ON DELETE CASCADE,
+    CONSTRAINT "fk_users_problem_status_changes" FOREIGN KEY ("actor_id") REFERENCES "users"("id") ON DELETE SET NULL
+);
+
+CREATE INDEX "idx_problem_status_changes_problem_id" ON "problem_status_changes" ("problem_id");
+
+-- Stations deactivated because of a problem, without the foreign key of the postgres
+-- migration, SQLite cannot drop columns used in one
+ALTER TABLE "station_status_changes" ADD COLUMN "problem_id" integer DEFAULT null;
@@ -0,0 +1 @@
+ALTER TABLE "refill_station_problem" DROP COLUMN "deactivate_station";
@@ -0,0 +1,2 @@
+-- Open problems that keep their station deactivated
+ALTER TABLE "refill_station_problem" ADD COLUMN "deactivate_station" boolean NOT NULL DEFAULT false;
@@ -3,6 +3,9 @@ package database
 import (
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/hours"
 
 	"gorm.io/gorm"
 )
@@ -10,28 +13,36 @@ import (
 var StationTypes []string = []string{"manual", "smart"}
 var StationOfferedWaterTypes []string = []string{"mineral", "tap", "both"}
 
-// RefillStation Model
+// RefillStation Model, OwnerID is the operator maintaining the station.
+// OpeningTimes is the text form of OpeningHours, IsOpenNow and NextChangeAt are computed for responses.
+// OfflineSince is the last heartbeat of a smart station the heartbeat monitor deactivated.
 // @swagger:model
 type RefillStation struct {
-	ID                 uint                   `gorm:"primaryKey" json:"id"`
-	Name               string                 `gorm:"size:100;not null" json:"name"`
-	Description        string                 `gorm:"size:255;not null" json:"description"`
-	Latitude           float64                `gorm:"not null" json:"latitude"`
-	Longitude          float64                `gorm:"not null" json:"longitude"`
-	Address            string                 `gorm:"size:255;not null" json:"address"`
-	WaterSource        string                 `gorm:"size:50;not null" json:"water_source"`
-	OpeningTimes       string                 `gorm:"size:100;not null" json:"opening_times"`
-	Active             NullBool               `gorm:"default:true" json:"active"`
-	Type               string                 `gorm:"size:16;not null" json:"type"`
-	OfferedWaterTypes  string                 `gorm:"size:32;not null" json:"offered_water_types"`
-	RefillStationImage *string                `gorm:"type:TEXT;default:null" json:"-"`
-	Reviews            []RefillStationReview  `gorm:"foreignKey:StationID" json:"-"`
-	Problems           []RefillStationProblem `gorm:"foreignKey:StationID" json:"-"`
-	WaterTransactions  []WaterTransaction     `gorm:"foreignKey:StationID" json:"-"`
-	Likes              []Like                 `gorm:"foreignKey:StationID" json:"-"`
+	ID                uint                   `gorm:"primaryKey" json:"id"`
+	Name              string                 `gorm:"size:100;not null" json:"name"`
+	Description       string                 `gorm:"size:255;not null" json:"description"`
+	Latitude          float64                `gorm:"not null;index:idx_refill_stations_location,priority:1" json:"latitude"`
+	Longitude         float64                `gorm:"not null;index:idx_refill_stations_location,priority:2" json:"longitude"`
+	Address           string                 `gorm:"size:255;not null" json:"address"`
+	WaterSource       string                 `gorm:"size:50;not null" json:"water_source"`
+	OpeningTimes      string                 `gorm:"size:255;not null" json:"opening_times"`
+	OpeningHours      *hours.Schedule        `gorm:"type:TEXT;serializer:json" json:"opening_hours,omitempty"`
+	IsOpenNow         *bool                  `gorm:"-" json:"is_open_now,omitempty"`
+	NextChangeAt      *time.Time             `gorm:"-" json:"next_change_at,omitempty"`
+	Active            NullBool               `gorm:"default:true" json:"active"`
+	OfflineSince      *time.Time             `json:"offline_since,omitempty"`
+	Type              string                 `gorm:"size:16;not null" json:"type"`
+	OfferedWaterTypes string                 `gorm:"size:32;not null" json:"offered_water_types"`
+	ImageID           *uint                  `json:"image_id,omitempty"`
+	OwnerID           *uint                  `json:"owner_id,omitempty"`
+	Reviews           []RefillStationReview  `gorm:"foreignKey:StationID" json:"-"`
+	Problems          []RefillStationProblem `gorm:"foreignKey:StationID" json:"-"`
+	WaterTransactions []WaterTransaction     `gorm:"foreignKey:StationID" json:"-"`
+	Likes             []Like                 `gorm:"foreignKey:StationID" json:"-"`
 }
 
-func (station *RefillStation) BeforeCreate(tx *gorm.DB) (err error) {
+// Validate checks the station type and the offered water types
+func (station *RefillStation) Validate() error {
 	stationType := strings.ToLower(station.Type)
 	stationOfferedWaterTypes := strings.ToLower(station.OfferedWaterTypes)
 
@@ -41,5 +52,65 @@ func (station *RefillStation) BeforeCreate(tx *gorm.DB) (err error) {
 	if !contains(StationOfferedWaterTypes, stationOfferedWaterTypes) {
 		return fmt.Errorf("invalid water types: %s, possible valules: %s, %s, %s", station.OfferedWaterTypes, StationOfferedWaterTypes[0], StationOfferedWaterTypes[1], StationOfferedWaterTypes[2])
 	}
+	return station.ResolveOpeningHours()
+}
+
+// IsSmart reports whether the station dispenses water through its own controller
+func (station *RefillStation) IsSmart() bool {
+	return strings.EqualFold(station.Type, StationTypes[1])
+}
+
+// ResolveOpeningHours parses the opening times into opening hours unless those are
+// given and fills in missing opening times from the opening hours. Opening times are free
+// text, if they do not parse the opening hours stay unknown.
+func (station *RefillStation) ResolveOpeningHours() error {
+	if station.OpeningHours == nil && station.OpeningTimes != "" {
+		if schedule, err := hours.Parse(station.OpeningTimes); err == nil {
+			station.OpeningHours = &schedule
+		}
+	}
+	if station.OpeningHours == nil {
+		return nil
+	}
+	if err := station.OpeningHours.Validate(); err != nil {
+		return fmt.Errorf("invalid opening hours: %w", err)
+	}
+	if station.OpeningTimes == "" {
+		station.OpeningTimes = station.OpeningHours.String()
+	}
 	return nil
 }
+
+// SetOpeningState computes IsOpenNow and NextChangeAt at the time now. Stations stored
+// before opening hours existed get them from their opening times if those parse.
+func (station *RefillStation) SetOpeningState(now time.Time) {
+	if station.OpeningHours == nil {
+		schedule, err := hours.Parse(station.OpeningTimes)
+		if err != nil {
+			return
+		}
+		station.OpeningHours = &schedule
+	}
+	open, next := station.OpeningHours.State(now)
+	station.IsOpenNow = &open
+	station.NextChangeAt = next
+}
+
+// DispensedWaterType returns the requested water type if the station offers it,
+// otherwise the only type it offers
+func (station *RefillStation) DispensedWaterType(requested string) string {
+	if station.Offers(requested) {
+		return strings.ToLower(requested)
+	}
+	return strings.ToLower(station.OfferedWaterTypes)
+}
+
+// Offers reports whether the station dispenses the water type
+func (station *RefillStation) Offers(waterType string) bool {
+	offered := strings.ToLower(station.OfferedWaterTypes)
+	return offered == "both" || offered == strings.ToLower(waterType)
+}
+
+func (station *RefillStation) BeforeCreate(tx *gorm.DB) (err error) {
+	return station.Validate()
+}
@@ -2,31 +2,99 @@ package database
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
 )
 
-// RefillStationProblem Model
+// Statuses of a refill station problem
+const (
+	ProblemOpen       = "OPEN"
+	ProblemInProgress = "INPROGRESS"
+	ProblemSolved     = "SOLVED"
+	ProblemClosed     = "CLOSED"
+)
+
+// OpenProblemStatuses are the statuses of problems that are not solved or closed yet
+var OpenProblemStatuses = []string{ProblemOpen, ProblemInProgress}
+
+// problemTransitions lists the statuses a problem can move to from each status.
+// Problems are reported open, solved and closed problems can be reopened.
+var problemTransitions = map[string][]string{
+	ProblemOpen:       {ProblemInProgress, ProblemClosed},
+	ProblemInProgress: {ProblemOpen, ProblemSolved, ProblemClosed},
+	ProblemSolved:     {ProblemOpen},
+	ProblemClosed:     {ProblemOpen},
+}
+
+// RefillStationProblem Model. DeactivateStation is set while the problem is open and keeps
+// its station deactivated.
 // @swagger:model
 type RefillStationProblem struct {
-	ID                        uint      `gorm:"primaryKey" json:"id"`
-	StationID                 uint      `gorm:"not null" json:"station_id"`
-	Title                     string    `gorm:"size:100;not null" json:"title"`
-	Description               string    `gorm:"size:255;not null" json:"description"`
-	Status                    string    `gorm:"size:16;not null" json:"status"`
-	RefillStationProblemImage *string   `gorm:"type:TEXT;default:null" json:"-"`
-	Timestamp                 time.Time `gorm:"autoCreateTime" json:"timestamp"`
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	StationID         uint      `gorm:"not null" json:"station_id"`
+	Title             string    `gorm:"size:100;not null" json:"title"`
+	Description       string    `gorm:"size:255;not null" json:"description"`
+	Status            string    `gorm:"size:16;not null" json:"status"`
+	DeactivateStation bool      `gorm:"not null;default:false" json:"deactivate_station"`
+	ImageID           *uint     `json:"image_id,omitempty"`
+	Timestamp         time.Time `gorm:"autoCreateTime" json:"timestamp"`
 }
 
 func (RefillStationProblem) TableName() string {
 	return "refill_station_problem"
 }
 
-func (problem *RefillStationProblem) BeforeCreate(tx *gorm.DB) (err error) {
-	allowedStatuses := []string{"OPEN", "INPROGRESS", "CLOSED", "SOLVED"}
-	if !contains(allowedStatuses, problem.Status) {
+// Validate checks the problem status
+func (problem *RefillStationProblem) Validate() error {
+	if _, ok := problemTransitions[problem.Status]; !ok {
 		return fmt.Errorf("invalid problem status: %s", problem.Status)
 	}
 	return nil
 }
+
+func (problem *RefillStationProblem) BeforeCreate(tx *gorm.DB) (err error) {
+	return problem.Validate()
+}
+
+// CanTransition reports whether the problem may move from its status to the given one
+func (problem *RefillStationProblem) CanTransition(status string) bool {
+	return contains(problemTransitions[problem.Status], status)
+}
+
+// IsOpen reports whether the problem is neither solved nor closed
+func (problem *RefillStationProblem) IsOpen() bool {
+	return contains(OpenProblemStatuses, problem.Status)
+}
+
+// StationStatusChange returns the deactivation of the station because of the problem or,
+// once it is solved or closed, the activation
+func (problem *RefillStationProblem) StationStatusChange(actorID *uint, changedAt time.Time) *StationStatusChange {
+	change := &StationStatusChange{
+		StationID: problem.StationID,
+		Active:    !problem.IsOpen(),
+		Source:    StatusSourceProblem,
+		Reason:    fmt.Sprintf("Problem %d: %s", problem.ID, problem.Title),
+		ActorID:   actorID,
+		ProblemID: &problem.ID,
+		ChangedAt: changedAt,
+	}
+	if change.Active {
+		change.Reason = fmt.Sprintf("Problem %d %s", problem.ID, strings.ToLower(problem.Status))
+	}
+	return change
+}
+
+// ProblemStatusChange records a status change of a problem in its timeline. FromStatus
+// is empty for the report of the problem, ActorID is the user who changed the status.
+// @swagger:model
+type ProblemStatusChange struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProblemID  uint      `gorm:"not null;index" json:"problem_id"`
+	FromStatus string    `gorm:"size:16;not null" json:"from_status,omitempty"`
+	ToStatus   string    `gorm:"size:16;not null" json:"to_status"`
+	Comment    string    `gorm:"size:255;not null" json:"comment,omitempty"`
+	ActorID    *uint     `json:"actor_id,omitempty"`
+	ChangedAt  time.Time `gorm:"not null" json:"changed_at"`
+}
@@ -19,7 +19,8 @@ type RefillStationReview struct {
 	Timestamp     time.Time `gorm:"autoCreateTime" json:"timestamp"`
 }
 
-func (review *RefillStationReview) BeforeCreate(tx *gorm.DB) (err error) {
+// Validate checks that all ratings are between 1 and 5
+func (review *RefillStationReview) Validate() error {
 	if !isValidRating(review.Cleanness) ||
 		!isValidRating(review.Accessibility) ||
 		!isValidRating(review.WaterQuality) {
@@ -28,11 +29,10 @@ func (review *RefillStationReview) BeforeCreate(tx *gorm.DB) (err error) {
 	return nil
 }
 
+func (review *RefillStationReview) BeforeCreate(tx *gorm.DB) (err error) {
+	return review.Validate()
+}
+
 func (review *RefillStationReview) BeforeUpdate(tx *gorm.DB) (err error) {
-	if !isValidRating(review.Cleanness) ||
-		!isValidRating(review.Accessibility) ||
-		!isValidRating(review.WaterQuality) {
-		return fmt.Errorf("rating should be between 1 und 5")
-	}
-	return nil
+	return review.Validate()
 }
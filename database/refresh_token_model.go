@@ -0,0 +1,19 @@
+package database
+
+import "time"
+
+// RefreshToken is one login session. Only the SHA-256 hash of the token is stored.
+// @swagger:model
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	TokenHash string     `gorm:"size:64;not null;unique" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// Active reports whether the token can still be used at the given time
+func (token *RefreshToken) Active(now time.Time) bool {
+	return token.RevokedAt == nil && now.Before(token.ExpiresAt)
+}
@@ -0,0 +1,28 @@
+package database
+
+import "time"
+
+// Sources of the changes of the active state of a refill station
+const (
+	StatusSourceManual    = "manual"
+	StatusSourceTelemetry = "telemetry"
+	StatusSourceProblem   = "problem"
+	// StatusSourceCreation records the initial state of a station, it did not exist before
+	StatusSourceCreation = "creation"
+)
+
+// StationStatusChange records an activation or deactivation of a refill station. ActorID
+// is the user who changed it manually or through a problem, DeviceID the device whose
+// heartbeats stopped or resumed and ProblemID the problem that caused the change.
+// @swagger:model
+type StationStatusChange struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	StationID uint      `gorm:"not null;index:idx_station_status_changes_changed_at,priority:1" json:"station_id"`
+	Active    bool      `gorm:"not null" json:"active"`
+	Source    string    `gorm:"size:16;not null" json:"source"`
+	Reason    string    `gorm:"size:255;not null" json:"reason"`
+	ActorID   *uint     `json:"actor_id,omitempty"`
+	DeviceID  *uint     `json:"device_id,omitempty"`
+	ProblemID *uint     `json:"problem_id,omitempty"`
+	ChangedAt time.Time `gorm:"not null;index:idx_station_status_changes_changed_at,priority:2" json:"changed_at"`
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var FilterStatuses []string = []string{"ok", "replace_soon", "replace"}
+
+// Telemetry is a measurement report of a station device. Reports without any
+// measurement are heartbeats, which only update Device.LastSeenAt and are not stored.
+// @swagger:model
+type Telemetry struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	StationID  uint      `gorm:"not null;index:idx_station_telemetry_recorded_at,priority:1" json:"station_id"`
+	DeviceID   uint      `gorm:"not null" json:"device_id"`
+	RecordedAt time.Time `gorm:"not null;index:idx_station_telemetry_recorded_at,priority:2" json:"recorded_at"`
+	// TankLevel is the fill level of the tank in percent
+	TankLevel    *float64 `json:"tank_level,omitempty"`
+	FilterStatus *string  `gorm:"size:16" json:"filter_status,omitempty"`
+	// Temperature of the water in degrees Celsius
+	Temperature *float64 `json:"temperature,omitempty"`
+	// FlowRate in liters per minute
+	FlowRate  *float64  `json:"flow_rate,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Telemetry) TableName() string {
+	return "station_telemetry"
+}
+
+// HasMeasurements reports whether the report is more than a heartbeat
+func (t *Telemetry) HasMeasurements() bool {
+	return t.TankLevel != nil || t.FilterStatus != nil || t.Temperature != nil || t.FlowRate != nil
+}
+
+// Validate checks the ranges of the measurements and normalizes the filter status
+func (t *Telemetry) Validate() error {
+	if t.TankLevel != nil && (*t.TankLevel < 0 || *t.TankLevel > 100) {
+		return fmt.Errorf("tank_level must be between 0 and 100 percent")
+	}
+	if t.FilterStatus != nil {
+		status := strings.ToLower(*t.FilterStatus)
+		if !contains(FilterStatuses, status) {
+			return fmt.Errorf("invalid filter status: %s, allowed values: %s", *t.FilterStatus, strings.Join(FilterStatuses, ", "))
+		}
+		t.FilterStatus = &status
+	}
+	if t.Temperature != nil && (*t.Temperature < -40 || *t.Temperature > 100) {
+		return fmt.Errorf("temperature must be between -40 and 100 degrees Celsius")
+	}
+	if t.FlowRate != nil && *t.FlowRate < 0 {
+		return fmt.Errorf("flow_rate must not be negative")
+	}
+	return nil
+}
@@ -1,10 +1,12 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
-	"io"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"time"
 
 	"gorm.io/gorm"
@@ -63,265 +65,183 @@ type WaterTransactionJSON struct {
 	Guest     bool
 }
 
-func CreateTestData(db *gorm.DB) *gorm.DB {
-	log.Print("Test data creation started")
-
-	db = CreateUsers(db)
-	db = CreateBottles(db)
-	db = CreateRefillStations(db)
-	db = CreateRefillStationReviews(db)
-	db = CreateRefillStationProblems(db)
-	db = CreateWaterTransactions(db)
-	db = CreateLikes(db)
-
-	log.Print("Test data creation finished")
+// TestData holds the models parsed from the JSON test data files and the
+// image files of the bottles, stations and problems at the same index
+type TestData struct {
+	Users                      []User
+	Bottles                    []Bottle
+	BottleImages               [][]byte
+	RefillStations             []RefillStation
+	RefillStationImages        [][]byte
+	RefillStationReviews       []RefillStationReview
+	RefillStationProblems      []RefillStationProblem
+	RefillStationProblemImages [][]byte
+	WaterTransactions          []WaterTransaction
+	Likes                      []Like
+}
 
-	return db
+// ImageSaver stores an image file and its metadata, see media.Images
+type ImageSaver interface {
+	Save(ctx context.Context, data []byte) (*Image, error)
 }
 
-func CreateUsers(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/users.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
+// SaveImages stores the image files of the test data and references them from the models
+func (data *TestData) SaveImages(ctx context.Context, images ImageSaver) error {
+	save := func(files [][]byte, imageID func(i int) **uint) error {
+		for i, file := range files {
+			image, err := images.Save(ctx, file)
+			if err != nil {
+				return err
+			}
+			*imageID(i) = &image.ID
+		}
+		return nil
+	}
+	if err := save(data.BottleImages, func(i int) **uint { return &data.Bottles[i].ImageID }); err != nil {
+		return fmt.Errorf("failed to save bottle image: %w", err)
+	}
+	if err := save(data.RefillStationImages, func(i int) **uint { return &data.RefillStations[i].ImageID }); err != nil {
+		return fmt.Errorf("failed to save refill station image: %w", err)
+	}
+	if err := save(data.RefillStationProblemImages, func(i int) **uint { return &data.RefillStationProblems[i].ImageID }); err != nil {
+		return fmt.Errorf("failed to save refill station problem image: %w", err)
 	}
-	defer file.Close()
+	return nil
+}
+
+// CreateTestData imports all JSON test data files from the given directory
+func CreateTestData(db *gorm.DB, dir string, images ImageSaver) *gorm.DB {
+	log.Print("Test data creation started")
 
-	// Read the file content
-	bytes, err := io.ReadAll(file)
+	data, err := LoadTestData(dir)
 	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
+		log.Fatalf("failed to load test data: %v", err)
 	}
-
-	// Unmarshal the JSON data into intermediate struct
-	var usersJson []UsersJSON
-	if err := json.Unmarshal(bytes, &usersJson); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+	if err := data.SaveImages(context.Background(), images); err != nil {
+		log.Fatal(err)
 	}
 
-	// Convert intermediate data to actual User structs
-	var users []User
-	for _, userData := range usersJson {
-		user := User{
-			FirstName: userData.FirstName,
-			LastName:  userData.LastName,
+	create := func(name string, rows interface{}) {
+		if err := db.Create(rows).Error; err != nil {
+			log.Fatalf("failed to create %s: %v", name, err)
 		}
-		users = append(users, user)
-	}
-
-	// Create users in the database
-	if err := db.Create(&users).Error; err != nil {
-		log.Fatalf("failed to create users: %v", err)
+		log.Printf("Created %s successfully", name)
 	}
+	create("users", &data.Users)
+	create("bottles", &data.Bottles)
+	create("refill stations", &data.RefillStations)
+	create("refill station reviews", &data.RefillStationReviews)
+	create("refill station problems", &data.RefillStationProblems)
+	create("water transactions", &data.WaterTransactions)
+	create("likes", &data.Likes)
 
-	log.Print("Created users successfully")
+	log.Print("Test data creation finished")
 
 	return db
 }
 
-func CreateBottles(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/bottles.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
-	}
-	defer file.Close()
+// LoadTestData parses all JSON test data files of a directory into models.
+// Image paths in the files are resolved relative to the working directory.
+func LoadTestData(dir string) (*TestData, error) {
+	var data TestData
 
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
+	// Convert intermediate data to actual User structs
+	var usersJson []UsersJSON
+	if err := readJSON(filepath.Join(dir, "users.json"), &usersJson); err != nil {
+		return nil, err
+	}
+	for _, userData := range usersJson {
+		data.Users = append(data.Users, User{
+			FirstName: userData.FirstName,
+			LastName:  userData.LastName,
+		})
 	}
 
-	// Unmarshal the JSON data into a slice of BottleJSON
+	// Read the images and create Bottle slice
 	var bottlesJSON []BottleJSON
-	if err := json.Unmarshal(bytes, &bottlesJSON); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+	if err := readJSON(filepath.Join(dir, "bottles.json"), &bottlesJSON); err != nil {
+		return nil, err
 	}
-
-	// Convert image paths to base64 strings and create Bottle slice
-	var bottles []Bottle
 	for _, bottleJSON := range bottlesJSON {
-		bottleImage := ImageToBase64(bottleJSON.ImagePath)
-		bottles = append(bottles, Bottle{
-			UserID:      bottleJSON.UserID,
-			NFCID:       bottleJSON.NFCID,
-			FillVolume:  bottleJSON.FillVolume,
-			WaterType:   bottleJSON.WaterType,
-			Title:       bottleJSON.Title,
-			BottleImage: &bottleImage,
+		bottleImage, err := readImage(bottleJSON.ImagePath)
+		if err != nil {
+			return nil, err
+		}
+		data.Bottles = append(data.Bottles, Bottle{
+			UserID:     bottleJSON.UserID,
+			NFCID:      bottleJSON.NFCID,
+			FillVolume: bottleJSON.FillVolume,
+			WaterType:  bottleJSON.WaterType,
+			Title:      bottleJSON.Title,
 		})
+		data.BottleImages = append(data.BottleImages, bottleImage)
 	}
 
-	// Create bottles in the database
-	if err := db.Create(&bottles).Error; err != nil {
-		log.Fatalf("failed to create bottles: %v", err)
-	}
-
-	log.Print("Created bottles successfully")
-
-	return db
-}
-
-func CreateRefillStations(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/refill_stations.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
-	}
-	defer file.Close()
-
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
-	}
-
-	// Unmarshal the JSON data into a slice of RefillStationJSON
+	// Read the images and create RefillStation slice
 	var refillStationsJSON []RefillStationJSON
-	if err := json.Unmarshal(bytes, &refillStationsJSON); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+	if err := readJSON(filepath.Join(dir, "refill_stations.json"), &refillStationsJSON); err != nil {
+		return nil, err
 	}
-
-	// Convert image paths to base64 strings and create RefillStation slice
-	var refillStations []RefillStation
 	for _, stationJSON := range refillStationsJSON {
-		refillStationImage := ImageToBase64(stationJSON.ImagePath)
-		refillStations = append(refillStations, RefillStation{
-			Name:               stationJSON.Name,
-			Description:        stationJSON.Description,
-			Latitude:           stationJSON.Latitude,
-			Longitude:          stationJSON.Longitude,
-			Address:            stationJSON.Address,
-			WaterSource:        stationJSON.WaterSource,
-			OpeningTimes:       stationJSON.OpeningTimes,
-			Type:               stationJSON.Type,
-			OfferedWaterTypes:  stationJSON.OfferedWaterTypes,
-			RefillStationImage: &refillStationImage,
+		refillStationImage, err := readImage(stationJSON.ImagePath)
+		if err != nil {
+			return nil, err
+		}
+		data.RefillStations = append(data.RefillStations, RefillStation{
+			Name:              stationJSON.Name,
+			Description:       stationJSON.Description,
+			Latitude:          stationJSON.Latitude,
+			Longitude:         stationJSON.Longitude,
+			Address:           stationJSON.Address,
+			WaterSource:       stationJSON.WaterSource,
+			OpeningTimes:      stationJSON.OpeningTimes,
+			Type:              stationJSON.Type,
+			OfferedWaterTypes: stationJSON.OfferedWaterTypes,
 		})
+		data.RefillStationImages = append(data.RefillStationImages, refillStationImage)
 	}
 
-	// Create refill stations in the database
-	if err := db.Create(&refillStations).Error; err != nil {
-		log.Fatalf("failed to create refill stations: %v", err)
-	}
-
-	log.Print("Created refill stations successfully")
-
-	return db
-}
-
-func CreateRefillStationReviews(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/refill_station_reviews.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
-	}
-	defer file.Close()
-
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
-	}
-
-	// Unmarshal the JSON data into a slice of the temporary struct
+	// Convert the temporary struct to the actual model
 	var reviewsJSON []RefillStationReviewJSON
-	if err := json.Unmarshal(bytes, &reviewsJSON); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+	if err := readJSON(filepath.Join(dir, "refill_station_reviews.json"), &reviewsJSON); err != nil {
+		return nil, err
+	}
+	for _, reviewJSON := range reviewsJSON {
+		data.RefillStationReviews = append(data.RefillStationReviews, RefillStationReview{
+			StationID:     reviewJSON.StationID,
+			UserID:        reviewJSON.UserID,
+			Cleanness:     reviewJSON.Cleanness,
+			WaterQuality:  reviewJSON.WaterQuality,
+			Accessibility: reviewJSON.Accessibility,
+		})
 	}
 
 	// Convert the temporary struct to the actual model
-	var reviews []RefillStationReview
-	for _, problemJSON := range reviewsJSON {
-		problem := RefillStationReview{
-			StationID:     problemJSON.StationID,
-			UserID:        problemJSON.UserID,
-			Cleanness:     problemJSON.Cleanness,
-			WaterQuality:  problemJSON.WaterQuality,
-			Accessibility: problemJSON.Accessibility,
-		}
-		reviews = append(reviews, problem)
-	}
-
-	// Create refill station problems in the database
-	if err := db.Create(&reviews).Error; err != nil {
-		log.Fatalf("failed to create refill station reviews: %v", err)
-	}
-
-	log.Print("Created refill station reviews successfully")
-
-	return db
-}
-
-func CreateRefillStationProblems(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/refill_station_problems.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
-	}
-	defer file.Close()
-
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
-	}
-
-	// Unmarshal the JSON data into a slice of the temporary struct
 	var problemsJSON []RefillStationProblemJSON
-	if err := json.Unmarshal(bytes, &problemsJSON); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+	if err := readJSON(filepath.Join(dir, "refill_station_problems.json"), &problemsJSON); err != nil {
+		return nil, err
 	}
-
-	// Convert the temporary struct to the actual model
-	var problems []RefillStationProblem
 	for _, problemJSON := range problemsJSON {
-		imageBase64 := ImageToBase64(problemJSON.RefillStationProblemImage)
-		problem := RefillStationProblem{
-			StationID:                 problemJSON.StationID,
-			Title:                     problemJSON.Title,
-			Description:               problemJSON.Description,
-			Status:                    problemJSON.Status,
-			RefillStationProblemImage: &imageBase64,
+		problemImage, err := readImage(problemJSON.RefillStationProblemImage)
+		if err != nil {
+			return nil, err
 		}
-		problems = append(problems, problem)
-	}
-
-	// Create refill station problems in the database
-	if err := db.Create(&problems).Error; err != nil {
-		log.Fatalf("failed to create refill station problems: %v", err)
-	}
-
-	log.Print("Created refill station problems successfully")
-
-	return db
-}
-
-func CreateWaterTransactions(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/water_transactions.json")
-	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
-	}
-	defer file.Close()
-
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
-	}
-
-	// Unmarshal the JSON data into a slice of WaterTransaction
-	var json_transactions []WaterTransactionJSON
-	if err := json.Unmarshal(bytes, &json_transactions); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
+		data.RefillStationProblems = append(data.RefillStationProblems, RefillStationProblem{
+			StationID:   problemJSON.StationID,
+			Title:       problemJSON.Title,
+			Description: problemJSON.Description,
+			Status:      problemJSON.Status,
+		})
+		data.RefillStationProblemImages = append(data.RefillStationProblemImages, problemImage)
 	}
 
 	// Convert the temporary struct to the actual model
-	var transactions []WaterTransaction
-	for _, transJSON := range json_transactions {
-		transaction := WaterTransaction{
+	var transactionsJSON []WaterTransactionJSON
+	if err := readJSON(filepath.Join(dir, "water_transactions.json"), &transactionsJSON); err != nil {
+		return nil, err
+	}
+	for _, transJSON := range transactionsJSON {
+		data.WaterTransactions = append(data.WaterTransactions, WaterTransaction{
 			StationID: transJSON.StationID,
 			BottleID:  transJSON.BottleID,
 			UserID:    transJSON.UserID,
@@ -329,46 +249,24 @@ func CreateWaterTransactions(db *gorm.DB) *gorm.DB {
 			WaterType: transJSON.WaterType,
 			Timestamp: transJSON.Timestamp,
 			Guest:     transJSON.Guest,
-		}
-		transactions = append(transactions, transaction)
+		})
 	}
 
-	// Create water transactions in the database
-	if err := db.Create(&transactions).Error; err != nil {
-		log.Fatalf("failed to create water transactions: %v", err)
+	// Likes use the JSON format of the model
+	if err := readJSON(filepath.Join(dir, "likes.json"), &data.Likes); err != nil {
+		return nil, err
 	}
 
-	log.Print("Created water transactions successfully")
-
-	return db
+	return &data, nil
 }
 
-func CreateLikes(db *gorm.DB) *gorm.DB {
-	// Read the JSON file
-	file, err := os.Open("./testdata/likes.json")
+func readJSON(path string, target interface{}) error {
+	bytes, err := os.ReadFile(path)
 	if err != nil {
-		log.Fatalf("failed to open JSON file: %v", err)
+		return fmt.Errorf("failed to read JSON file: %w", err)
 	}
-	defer file.Close()
-
-	// Read the file content
-	bytes, err := io.ReadAll(file)
-	if err != nil {
-		log.Fatalf("failed to read JSON file: %v", err)
+	if err := json.Unmarshal(bytes, target); err != nil {
+		return fmt.Errorf("failed to unmarshal JSON data of %s: %w", path, err)
 	}
-
-	// Unmarshal the JSON data into a slice of Like structs
-	var likes []Like
-	if err := json.Unmarshal(bytes, &likes); err != nil {
-		log.Fatalf("failed to unmarshal JSON data: %v", err)
-	}
-
-	// Create likes in the database
-	if err := db.Create(&likes).Error; err != nil {
-		log.Fatalf("failed to create likes: %v", err)
-	}
-
-	log.Print("Created likes successfully")
-
-	return db
+	return nil
 }
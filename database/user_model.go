@@ -1,13 +1,48 @@
 package database
 
-// User Model
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Roles of a user, the auth package maps them to permissions
+const (
+	RoleUser     = "user"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var UserRoles []string = []string{RoleUser, RoleOperator, RoleAdmin}
+
+// ErrEmailExists reports an email address that is already registered
+var ErrEmailExists = fmt.Errorf("Email %w", ErrAlreadyExists)
+
+// User Model, PasswordHash is the bcrypt hash of the password and empty for users that cannot log in
 // @swagger:model
 type User struct {
-	ID        uint                  `gorm:"primaryKey;autoIncrement" json:"id"`
-	FirstName string                `gorm:"size:100;not null" json:"first_name"`
-	LastName  string                `gorm:"size:100;not null" json:"last_name"`
-	Email     *string               `gorm:"size:100;unique;default:null" json:"email"`
-	Bottles   []Bottle              `gorm:"foreignKey:UserID" json:"-"`
-	Reviews   []RefillStationReview `gorm:"foreignKey:UserID" json:"-"`
-	Likes     []Like                `gorm:"foreignKey:UserID" json:"-"`
+	ID           uint                  `gorm:"primaryKey;autoIncrement" json:"id"`
+	FirstName    string                `gorm:"size:100;not null" json:"first_name"`
+	LastName     string                `gorm:"size:100;not null" json:"last_name"`
+	Email        *string               `gorm:"size:100;unique;default:null" json:"email"`
+	PasswordHash string                `gorm:"size:255" json:"-"`
+	Role         string                `gorm:"size:16;not null;default:user" json:"role"`
+	Bottles      []Bottle              `gorm:"foreignKey:UserID" json:"-"`
+	Reviews      []RefillStationReview `gorm:"foreignKey:UserID" json:"-"`
+	Likes        []Like                `gorm:"foreignKey:UserID" json:"-"`
+}
+
+// Validate checks the role, an empty role becomes a regular user
+func (user *User) Validate() error {
+	if user.Role == "" {
+		user.Role = RoleUser
+	}
+	if !contains(UserRoles, user.Role) {
+		return fmt.Errorf("invalid role: %s, allowed roles: %v", user.Role, UserRoles)
+	}
+	return nil
+}
+
+func (user *User) BeforeCreate(tx *gorm.DB) (err error) {
+	return user.Validate()
 }
@@ -3,11 +3,14 @@ package database
 import (
 	"database/sql"
 	"database/sql/driver"
-	"encoding/base64"
-	"log"
+	"errors"
+	"fmt"
 	"os"
 )
 
+// ErrAlreadyExists is wrapped by all uniqueness violations detected before insert
+var ErrAlreadyExists = errors.New("already exists")
+
 // NullBool is a custom struct for handling sql.NullBool in Swagger
 // @swagger:model
 type NullBool struct {
@@ -47,17 +50,11 @@ func isValidRating(rating int) bool {
 	return rating >= 1 && rating <= 5
 }
 
-// Function to read an image file and return its base64 representation
-func ImageToBase64(filePath string) string {
-	// Read the entire image file
+// readImage reads an image file of the test data
+func readImage(filePath string) ([]byte, error) {
 	imageData, err := os.ReadFile(filePath)
 	if err != nil {
-		log.Fatalf("failed to read image: %v", err)
-		return ""
+		return nil, fmt.Errorf("failed to read image: %w", err)
 	}
-
-	// Encode image data to base64 string
-	base64Encoded := base64.StdEncoding.EncodeToString(imageData)
-
-	return base64Encoded
+	return imageData, nil
 }
@@ -8,20 +8,23 @@ import (
 	"gorm.io/gorm"
 )
 
-// WaterTransaction Model
+// WaterTransaction Model. The IdempotencyKey is chosen by the station and
+// identifies the transaction among those of the station.
 // @swagger:model
 type WaterTransaction struct {
-	ID        uint      `gorm:"primaryKey" json:"id"`
-	StationID uint      `gorm:"not null" json:"station_id"`
-	BottleID  *uint     `gorm:"default:null" json:"bottle_id,omitempty"`
-	UserID    *uint     `gorm:"default:null" json:"user_id,omitempty"`
-	Volume    int       `gorm:"not null" json:"volume"`
-	WaterType string    `gorm:"size:16;not null" json:"water_type"`
-	Timestamp time.Time `gorm:"autoCreateTime" json:"timestamp"`
-	Guest     bool      `gorm:"default:false" json:"guest"`
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	StationID      uint      `gorm:"not null;uniqueIndex:idx_water_transactions_idempotency_key,priority:1" json:"station_id"`
+	BottleID       *uint     `json:"bottle_id,omitempty"`
+	UserID         *uint     `json:"user_id,omitempty"`
+	Volume         int       `gorm:"not null" json:"volume"`
+	WaterType      string    `gorm:"size:16;not null" json:"water_type"`
+	Timestamp      time.Time `gorm:"autoCreateTime" json:"timestamp"`
+	Guest          bool      `gorm:"default:false" json:"guest"`
+	IdempotencyKey *string   `gorm:"size:64;uniqueIndex:idx_water_transactions_idempotency_key,priority:2" json:"idempotency_key,omitempty"`
 }
 
-func (transaction *WaterTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+// Validate checks and normalizes the water type
+func (transaction *WaterTransaction) Validate() error {
 	allowedWaterTypes := []string{"tap", "mineral"}
 	waterType := strings.ToLower(transaction.WaterType)
 	if !contains(allowedWaterTypes, waterType) {
@@ -30,3 +33,22 @@ func (transaction *WaterTransaction) BeforeCreate(tx *gorm.DB) (err error) {
 	transaction.WaterType = waterType
 	return nil
 }
+
+// SamePayload reports whether both transactions report the same filling,
+// regardless of their IDs and timestamps
+func (transaction *WaterTransaction) SamePayload(other *WaterTransaction) bool {
+	return transaction.StationID == other.StationID &&
+		equalIDs(transaction.BottleID, other.BottleID) &&
+		equalIDs(transaction.UserID, other.UserID) &&
+		transaction.Volume == other.Volume &&
+		strings.EqualFold(transaction.WaterType, other.WaterType) &&
+		transaction.Guest == other.Guest
+}
+
+func equalIDs(a, b *uint) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func (transaction *WaterTransaction) BeforeCreate(tx *gorm.DB) (err error) {
+	return transaction.Validate()
+}
@@ -24,9 +24,212 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/auth/login": {
+            "post": {
+                "description": "Exchange email and password for an access and a refresh token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Log in",
+                "parameters": [
+                    {
+                        "description": "Credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the refresh token and all access tokens of the current session",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Log out",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/me": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the user authenticated by the access token",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Show the current user",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "description": "Exchange a refresh token for new tokens, the refresh token can only be used once",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Refresh the tokens",
+                "parameters": [
+                    {
+                        "description": "Refresh token",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RefreshRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/register": {
+            "post": {
+                "description": "Create a user with a password and log in",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authentication"
+                ],
+                "summary": "Register a user",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.TokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/bottles": {
             "get": {
-                "description": "Get all bottles",
+                "description": "Get a page of bottles",
                 "consumes": [
                     "application/json"
                 ],
@@ -37,19 +240,64 @@ const docTemplate = `{
                     "Bottles"
                 ],
                 "summary": "Show all bottles",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, user_id, title, fill_volume, water_type",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Bottle"
+                            "$ref": "#/definitions/api.ListPage-database_Bottle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             },
             "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Update an existing bottle",
                 "consumes": [
                     "application/json"
@@ -78,10 +326,33 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/database.Bottle"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             },
             "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Create a new bottle",
                 "consumes": [
                     "application/json"
@@ -110,13 +381,22 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/database.Bottle"
                         }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
         "/bottles/image/{id}": {
             "get": {
-                "description": "Get one bottle image with the given ID",
+                "description": "Get one bottle image with the given ID as base64 in JSON, kept for older apps.\nNew clients load the file from /bottles/{id}/image.",
                 "consumes": [
                     "application/json"
                 ],
@@ -127,6 +407,7 @@ const docTemplate = `{
                     "Bottles"
                 ],
                 "summary": "Get bottle image by bottle ID",
+                "deprecated": true,
                 "parameters": [
                     {
                         "type": "integer",
@@ -245,6 +526,11 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
                 "description": "Delete an existing bottle",
                 "consumes": [
                     "application/json"
@@ -268,91 +554,160 @@ const docTemplate = `{
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/contribution/community": {
+        "/bottles/{id}/image": {
             "get": {
-                "description": "Get the total water amount and savings for the community",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream the current image of a bottle, revalidate cached copies with If-None-Match.",
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
                 ],
                 "tags": [
-                    "Contribution"
+                    "Bottles"
+                ],
+                "summary": "Get a bottle image file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Bottle ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "thumb",
+                            "marker"
+                        ],
+                        "type": "string",
+                        "description": "Variant, the original if omitted",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
                 ],
-                "summary": "Get community contribution",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.ContributionCommunityResponse"
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/contribution/kl": {
-            "get": {
-                "description": "Get the number of smart and manual refill stations",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Contribution"
-                ],
-                "summary": "Get contribution by station type",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/api.ContributionKLResponse"
-                        }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                }
-            }
-        },
-        "/contribution/user/{id}": {
-            "get": {
-                "description": "Get the total water amount and savings for a user",
+                ],
+                "description": "Replace the image of a bottle by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,\nanimations at most 200 frames and 40 megapixels in all.\nMetadata like EXIF is removed and thumbnails are generated.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Contribution"
+                    "Bottles"
                 ],
-                "summary": "Get user contribution",
+                "summary": "Upload a bottle image",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Bottle ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.ContributionUserResponse"
+                            "$ref": "#/definitions/database.Bottle"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/likes": {
+        "/contribution/community": {
             "get": {
-                "description": "Get all likes",
+                "description": "Get the total water amount and savings for the community",
                 "consumes": [
                     "application/json"
                 ],
@@ -360,23 +715,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Contribution"
                 ],
-                "summary": "Show all likes",
+                "summary": "Get community contribution",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.Like"
-                            }
+                            "$ref": "#/definitions/api.ContributionCommunityResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing like",
+            }
+        },
+        "/contribution/kl": {
+            "get": {
+                "description": "Get the number of smart and manual refill stations",
                 "consumes": [
                     "application/json"
                 ],
@@ -384,31 +738,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
-                ],
-                "summary": "Update a like",
-                "parameters": [
-                    {
-                        "description": "Like",
-                        "name": "like",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.Like"
-                        }
-                    }
+                    "Contribution"
                 ],
+                "summary": "Get contribution by station type",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Like"
+                            "$ref": "#/definitions/api.ContributionKLResponse"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Create a new like",
+            }
+        },
+        "/contribution/user/{id}": {
+            "get": {
+                "description": "Get the total water amount and savings for a user",
                 "consumes": [
                     "application/json"
                 ],
@@ -416,77 +761,94 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Contribution"
                 ],
-                "summary": "Create a like",
+                "summary": "Get user contribution",
                 "parameters": [
                     {
-                        "description": "Like",
-                        "name": "like",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.Like"
-                        }
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.Like"
+                            "$ref": "#/definitions/api.ContributionUserResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/devices/{id}": {
             "delete": {
-                "description": "Delete an existing like",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Revoke the device, none of its API keys work anymore",
                 "tags": [
-                    "Likes"
+                    "Devices"
                 ],
-                "summary": "Delete a like",
+                "summary": "Revoke a device",
                 "parameters": [
                     {
-                        "description": "Like",
-                        "name": "like",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.Like"
-                        }
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/likes/{refillstationId}/count": {
+        "/devices/{id}/keys": {
             "get": {
-                "description": "Get counter like for station",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get all API keys of the device, the keys themselves are never shown again",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Devices"
                 ],
-                "summary": "Return a like counter fo a given station id",
+                "summary": "List the API keys of a device",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
-                        "name": "refillstationId",
+                        "description": "Device ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -495,147 +857,217 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.StationLikeCounter"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.DeviceKey"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/likes/{refillstationId}/{usedId}": {
-            "get": {
-                "description": "Check if a specific user likes a specific refill station",
-                "consumes": [
-                    "application/json"
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Create a new API key for the device. The previous keys keep working for 24 hours,\nso the device can switch over, unless they are revoked.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Likes"
+                    "Devices"
                 ],
-                "summary": "Check if a user likes a refill station",
+                "summary": "Rotate the API key of a device",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
-                        "name": "refillstationId",
+                        "description": "Device ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeviceCredentials"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/devices/{id}/keys/{keyId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoke the API key immediately, e.g. after it leaked",
+                "tags": [
+                    "Devices"
+                ],
+                "summary": "Revoke an API key of a device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
+                        "description": "Device Key ID",
+                        "name": "keyId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
-                                "type": "boolean"
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             }
         },
-        "/refill_station_problems": {
+        "/images/{id}": {
             "get": {
-                "description": "Get all refill station problems",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream an image file, cacheable forever as the file of an ID never changes.\nsize selects a downscaled variant, images smaller than the variant are returned as they are.",
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
                 ],
                 "tags": [
-                    "Refill Station Problems"
-                ],
-                "summary": "Show all refill station problems",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.RefillStationProblem"
-                            }
-                        }
-                    }
-                }
-            },
-            "put": {
-                "description": "Update an existing refill station problem",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+                    "Images"
                 ],
-                "tags": [
-                    "Refill Station Problems"
-                ],
-                "summary": "Update a refill station problem",
+                "summary": "Get an image file",
                 "parameters": [
                     {
-                        "description": "Refill Station Problem",
-                        "name": "problem",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.RefillStationProblem"
-                        }
+                        "type": "integer",
+                        "description": "Image ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "thumb",
+                            "marker"
+                        ],
+                        "type": "string",
+                        "description": "Variant, the original if omitted",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationProblem"
+                            "type": "file"
                         }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create a new refill station problem",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Refill Station Problems"
-                ],
-                "summary": "Create a refill station problem",
-                "parameters": [
-                    {
-                        "description": "Refill Station Problem",
-                        "name": "problem",
-                        "in": "body",
-                        "required": true,
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/api.PostRequestRefillStationProblem"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "Created",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationProblem"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/refill_station_problems/{id}": {
+        "/likes": {
             "get": {
-                "description": "Get refill station problem",
+                "description": "Get a page of likes",
                 "consumes": [
                     "application/json"
                 ],
@@ -643,29 +1075,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Problems"
+                    "Likes"
                 ],
-                "summary": "Show refill station problem by id",
+                "summary": "Show all likes",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, station_id, user_id",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationProblem"
+                            "$ref": "#/definitions/api.ListPage-database_Like"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete an existing refill station problem",
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing like",
                 "consumes": [
                     "application/json"
                 ],
@@ -673,52 +1144,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Problems"
+                    "Likes"
                 ],
-                "summary": "Delete a refill station problem",
+                "summary": "Update a like",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Refill Station Problem ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
+                        "description": "Like",
+                        "name": "like",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.Like"
+                        }
                     }
-                }
-            }
-        },
-        "/refill_station_reviews": {
-            "get": {
-                "description": "Get all refill station reviews",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Refill Station Reviews"
                 ],
-                "summary": "Show all refill station reviews",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.RefillStationReview"
+                            "$ref": "#/definitions/database.Like"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update an existing refill station review",
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new like",
                 "consumes": [
                     "application/json"
                 ],
@@ -726,31 +1199,45 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Reviews"
+                    "Likes"
                 ],
-                "summary": "Update a refill station review",
+                "summary": "Create a like",
                 "parameters": [
                     {
-                        "description": "Refill Station Review",
-                        "name": "review",
+                        "description": "Like",
+                        "name": "like",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationReview"
+                            "$ref": "#/definitions/database.Like"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationReview"
+                            "$ref": "#/definitions/database.Like"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a new refill station review",
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing like",
                 "consumes": [
                     "application/json"
                 ],
@@ -758,33 +1245,39 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Reviews"
+                    "Likes"
                 ],
-                "summary": "Create a refill station review",
+                "summary": "Delete a like",
                 "parameters": [
                     {
-                        "description": "Refill Station Review",
-                        "name": "review",
+                        "description": "Like",
+                        "name": "like",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationReview"
+                            "$ref": "#/definitions/database.Like"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationReview"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/refill_station_reviews/:id": {
-            "delete": {
-                "description": "Delete an existing refill station review",
+        "/likes/{refillstationId}/count": {
+            "get": {
+                "description": "Get counter like for station",
                 "consumes": [
                     "application/json"
                 ],
@@ -792,28 +1285,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Reviews"
+                    "Likes"
                 ],
-                "summary": "Delete a refill station review",
+                "summary": "Return a like counter fo a given station id",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station Review ID",
-                        "name": "id",
+                        "description": "Refill Station ID",
+                        "name": "refillstationId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StationLikeCounter"
+                        }
                     }
                 }
             }
         },
-        "/refill_station_reviews/{userId}/{stationId}": {
+        "/likes/{refillstationId}/{usedId}": {
             "get": {
-                "description": "Get all refill station reviews by user ID and station ID",
+                "description": "Check if a specific user likes a specific refill station",
                 "consumes": [
                     "application/json"
                 ],
@@ -821,21 +1317,21 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Station Reviews"
+                    "Likes"
                 ],
-                "summary": "Show all refill station reviews by user ID and station ID",
+                "summary": "Check if a user likes a refill station",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "userId",
+                        "description": "Refill Station ID",
+                        "name": "refillstationId",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "integer",
-                        "description": "Station ID",
-                        "name": "stationId",
+                        "description": "User ID",
+                        "name": "userId",
                         "in": "path",
                         "required": true
                     }
@@ -844,15 +1340,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStationReview"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
                         }
                     }
                 }
             }
         },
-        "/refill_stations": {
+        "/refill_station_problems": {
             "get": {
-                "description": "Get all refill stations",
+                "description": "Get a page of refill station problems",
                 "consumes": [
                     "application/json"
                 ],
@@ -860,23 +1359,68 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
+                ],
+                "summary": "Show all refill station problems",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, station_id, title, status, timestamp",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Show all refill stations",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.RefillStation"
+                            "$ref": "#/definitions/api.ListPage-database_RefillStationProblem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update an existing refill station",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing refill station problem, a change of the status has to be an allowed transition.\ndeactivate_station only takes effect along with a change of the status.",
                 "consumes": [
                     "application/json"
                 ],
@@ -884,17 +1428,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Update a refill station",
+                "summary": "Update a refill station problem",
                 "parameters": [
                     {
-                        "description": "Refill Station",
-                        "name": "station",
+                        "description": "Refill Station Problem",
+                        "name": "problem",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStation"
+                            "$ref": "#/definitions/database.RefillStationProblem"
                         }
                     }
                 ],
@@ -902,13 +1446,45 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStation"
+                            "$ref": "#/definitions/database.RefillStationProblem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new refill station",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Report a new refill station problem, problems are reported with the status OPEN.\nOperators of the station can deactivate it until the problem is solved or closed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -916,17 +1492,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Create a refill station",
+                "summary": "Create a refill station problem",
                 "parameters": [
                     {
-                        "description": "Refill Station",
-                        "name": "station",
+                        "description": "Refill Station Problem",
+                        "name": "problem",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStation"
+                            "$ref": "#/definitions/api.PostRequestRefillStationProblem"
                         }
                     }
                 ],
@@ -934,15 +1510,42 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStation"
+                            "$ref": "#/definitions/database.RefillStationProblem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/refill_stations/image/{id}": {
+        "/refill_station_problems/{id}": {
             "get": {
-                "description": "Get the image from a refill station by ID",
+                "description": "Get refill station problem",
                 "consumes": [
                     "application/json"
                 ],
@@ -950,13 +1553,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Get the image from a refill station by ID",
+                "summary": "Show refill station problem by id",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
+                        "description": "id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -966,15 +1569,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.StationImage"
+                            "$ref": "#/definitions/database.RefillStationProblem"
                         }
                     }
                 }
-            }
-        },
-        "/refill_stations/markers": {
-            "get": {
-                "description": "Get all refill station markers with specific attributes",
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing refill station problem, the station it kept deactivated is activated again\nif no other open problem keeps it deactivated",
                 "consumes": [
                     "application/json"
                 ],
@@ -982,99 +1588,186 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
+                ],
+                "summary": "Delete a refill station problem",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station Problem ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all refill station markers",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     }
                 }
             }
         },
-        "/refill_stations/{id}": {
+        "/refill_station_problems/{id}/image": {
             "get": {
-                "description": "Get a refill station by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Stream the current image of a problem, revalidate cached copies with If-None-Match.",
                 "produces": [
-                    "application/json"
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Get a refill station by ID",
+                "summary": "Get a refill station problem image file",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
+                        "description": "Refill Station Problem ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "thumb",
+                            "marker"
+                        ],
+                        "type": "string",
+                        "description": "Variant, the original if omitted",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.RefillStation"
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete an existing refill station",
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the image of a problem by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,\nanimations at most 200 frames and 40 megapixels in all.\nMetadata like EXIF is removed and thumbnails are generated.",
                 "consumes": [
-                    "application/json"
+                    "multipart/form-data"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Delete a refill station",
+                "summary": "Upload a refill station problem image",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
+                        "description": "Refill Station Problem ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStationProblem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/refill_stations/{id}/reviews": {
+        "/refill_station_problems/{id}/timeline": {
             "get": {
-                "description": "Get the average review score for a refill station by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get the status changes of a problem ordered by time, starting with its report",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Refill Stations"
+                    "Refill Station Problems"
                 ],
-                "summary": "Get the average review score for a refill station",
+                "summary": "Get the timeline of a refill station problem",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Refill Station ID",
+                        "description": "Problem ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1084,15 +1777,41 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/api.StationReviewAverage"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.ProblemStatusChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/users": {
-            "get": {
-                "description": "Get all users",
+        "/refill_station_problems/{id}/transitions": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a problem along its workflow with an optional comment: OPEN to INPROGRESS or CLOSED,\nINPROGRESS to OPEN, SOLVED or CLOSED, and SOLVED or CLOSED back to OPEN. The station can be\ndeactivated because of an open problem, it is activated again when the problem is solved or closed\nand no other open problem keeps it deactivated.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1100,23 +1819,145 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Refill Station Problems"
                 ],
-                "summary": "Show all users",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.User"
-                            }
-                        }
-                    }
-                }
+                "summary": "Change the status of a refill station problem",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Problem ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transition",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ProblemTransition"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStationProblem"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_station_reviews": {
+            "get": {
+                "description": "Get a page of refill station reviews",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Station Reviews"
+                ],
+                "summary": "Show all refill station reviews",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, station_id, user_id, cleanness, accessibility, water_quality, timestamp",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ListPage-database_RefillStationReview"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
             },
             "put": {
-                "description": "Update an existing user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing refill station review",
                 "consumes": [
                     "application/json"
                 ],
@@ -1124,17 +1965,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Refill Station Reviews"
                 ],
-                "summary": "Update a user",
+                "summary": "Update a refill station review",
                 "parameters": [
                     {
-                        "description": "User",
-                        "name": "user",
+                        "description": "Refill Station Review",
+                        "name": "review",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.User"
+                            "$ref": "#/definitions/database.RefillStationReview"
                         }
                     }
                 ],
@@ -1142,13 +1983,36 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.User"
+                            "$ref": "#/definitions/database.RefillStationReview"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new refill station review",
                 "consumes": [
                     "application/json"
                 ],
@@ -1156,17 +2020,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Refill Station Reviews"
                 ],
-                "summary": "Create a user",
+                "summary": "Create a refill station review",
                 "parameters": [
                     {
-                        "description": "User",
-                        "name": "user",
+                        "description": "Refill Station Review",
+                        "name": "review",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.User"
+                            "$ref": "#/definitions/database.RefillStationReview"
                         }
                     }
                 ],
@@ -1174,13 +2038,29 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/database.User"
+                            "$ref": "#/definitions/database.RefillStationReview"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/refill_station_reviews/:id": {
             "delete": {
-                "description": "Delete an existing user",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing refill station review",
                 "consumes": [
                     "application/json"
                 ],
@@ -1188,28 +2068,46 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Refill Station Reviews"
                 ],
-                "summary": "Delete a user",
+                "summary": "Delete a refill station review",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
+                        "description": "Refill Station Review ID",
                         "name": "id",
-                        "in": "query",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/water_transactions": {
+        "/refill_station_reviews/{userId}/{stationId}": {
             "get": {
-                "description": "Get all water transactions",
+                "description": "Get all refill station reviews by user ID and station ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -1217,23 +2115,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Water Transactions"
+                    "Refill Station Reviews"
+                ],
+                "summary": "Show all refill station reviews by user ID and station ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Station ID",
+                        "name": "stationId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Show all water transactions",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/database.WaterTransaction"
-                            }
+                            "$ref": "#/definitions/database.RefillStationReview"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update an existing water transaction",
+            }
+        },
+        "/refill_stations": {
+            "get": {
+                "description": "Get a page of refill stations with their opening state, optionally only those currently open or closed",
                 "consumes": [
                     "application/json"
                 ],
@@ -1241,31 +2154,74 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Water Transactions"
+                    "Refill Stations"
                 ],
-                "summary": "Update a water transaction",
+                "summary": "Show all refill stations",
                 "parameters": [
                     {
-                        "description": "Water Transaction",
-                        "name": "transaction",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/database.WaterTransaction"
-                        }
+                        "type": "boolean",
+                        "description": "Only stations that are open (true) or closed (false) now, stations without opening hours are left out",
+                        "name": "open_now",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, name, type, address, latitude, longitude",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.WaterTransaction"
+                            "$ref": "#/definitions/api.ListPage-database_RefillStation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "post": {
-                "description": "Create a new water transaction",
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing refill station, a change of the active state is recorded in its status history",
                 "consumes": [
                     "application/json"
                 ],
@@ -1273,31 +2229,54 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Water Transactions"
+                    "Refill Stations"
                 ],
-                "summary": "Create a water transaction",
+                "summary": "Update a refill station",
                 "parameters": [
                     {
-                        "description": "Water Transaction",
-                        "name": "transaction",
+                        "description": "Refill Station",
+                        "name": "station",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/database.WaterTransaction"
+                            "$ref": "#/definitions/database.RefillStation"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/database.WaterTransaction"
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete an existing water transaction",
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new refill station, its initial active state starts its status history",
                 "consumes": [
                     "application/json"
                 ],
@@ -1305,31 +2284,2507 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Water Transactions"
+                    "Refill Stations"
                 ],
-                "summary": "Delete a water transaction",
+                "summary": "Create a refill station",
+                "parameters": [
+                    {
+                        "description": "Refill Station",
+                        "name": "station",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/image/{id}": {
+            "get": {
+                "description": "Get the image from a refill station by ID as base64 in JSON, kept for older apps.\nNew clients load the file from /refill_stations/{id}/image.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get the image from a refill station by ID",
+                "deprecated": true,
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Water Transaction ID",
+                        "description": "Refill Station ID",
                         "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StationImage"
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/markers": {
+            "get": {
+                "description": "Get the markers of the stations in view. With a zoom level below 17, stations close to each other on the map are merged into clusters with their count.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get refill station markers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Visible area as minLon,minLat,maxLon,maxLat",
+                        "name": "bbox",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Map zoom level from 0 to 22, enables clustering",
+                        "name": "zoom",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/api.Marker"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/nearby": {
+            "get": {
+                "description": "Get the stations within a radius around a position ordered by great-circle distance, nearest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Find refill stations nearby",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Latitude in degrees",
+                        "name": "lat",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude in degrees",
+                        "name": "lon",
                         "in": "query",
                         "required": true
+                    },
+                    {
+                        "type": "number",
+                        "default": 5000,
+                        "description": "Search radius in meters, at most 50000",
+                        "name": "radius_m",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of stations, at most 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.NearbyStation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Filter stations by type, offered water, state, opening state, ratings, likes, distance and text, sorted and paginated with a cursor. liked needs an access token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Search refill stations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Text in the name, description or address",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "manual",
+                            "smart"
+                        ],
+                        "type": "string",
+                        "description": "Station type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mineral",
+                            "tap",
+                            "both"
+                        ],
+                        "type": "string",
+                        "description": "Offered water, stations offering both match mineral and tap",
+                        "name": "water",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only active or inactive stations",
+                        "name": "active",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only stations that are open or closed now",
+                        "name": "open_now",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Latitude of the position to measure distances from",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Longitude of the position to measure distances from",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Only stations within the radius in meters, needs lat and lon",
+                        "name": "radius_m",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum average cleanness",
+                        "name": "min_cleanness",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum average accessibility",
+                        "name": "min_accessibility",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum average water quality",
+                        "name": "min_water_quality",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Minimum mean of the three averages",
+                        "name": "min_rating",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum number of likes",
+                        "name": "min_likes",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only stations the authenticated user liked or did not like",
+                        "name": "liked",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "id, name, distance, rating or likes, prefixed with - to sort descending, distance by default with a position and id otherwise",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Page size, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StationSearchPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/text_search": {
+            "get": {
+                "description": "Search the name, address, water source and description of the stations. Every word must match, by its German word stem, as a prefix, as part of a compound word or with a typo. Results are ordered by relevance, matches in the name rank highest.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Full-text search of refill stations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search words",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "Maximum number of stations, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repository.ScoredStation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}": {
+            "get": {
+                "description": "Get a refill station by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get a refill station by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing refill station",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Delete a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/active": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Set the active state of a refill station and record the change with its reason.\nHeartbeats of the station devices no longer change the state until the monitor deactivates it again.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Activate or deactivate a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Status",
+                        "name": "status",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.StationStatusUpdate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/devices": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all devices registered for the refill station, including revoked ones",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Devices"
+                ],
+                "summary": "List the devices of a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.Device"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Register the controller of a smart refill station and create its first API key.\nThe key is only returned once, the device sends it in the X-API-Key header.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Devices"
+                ],
+                "summary": "Register a device",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device",
+                        "name": "device",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DeviceRegistration"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/api.DeviceCredentials"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/dispenses": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "A smart station sends the NFC ID of a scanned bottle and gets the volume, the water type\nand the owner to fill it for. The water type is the one of the bottle if the station offers it,\notherwise the one of the station. The dispense has to be confirmed within 5 minutes.\nManual or inactive stations and inactive bottles are rejected with 409.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Devices"
+                ],
+                "summary": "Authorize a dispense",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Scanned NFC ID",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DispenseRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Dispense"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/dispenses/{dispenseId}/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "A smart station reports the volume it dispensed, which creates the water transaction of the dispense.\nEvery dispense is confirmed once, expired dispenses are rejected with 410.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Devices"
+                ],
+                "summary": "Confirm a dispense",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Dispense ID",
+                        "name": "dispenseId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dispensed volume",
+                        "name": "confirmation",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DispenseConfirmation"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/image": {
+            "get": {
+                "description": "Stream the current image of a refill station, revalidate cached copies with If-None-Match.",
+                "produces": [
+                    "image/jpeg",
+                    "image/png",
+                    "image/gif",
+                    "image/webp"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get a refill station image file",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "thumb",
+                            "marker"
+                        ],
+                        "type": "string",
+                        "description": "Variant, the original if omitted",
+                        "name": "size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the image of a refill station by a JPEG, PNG, GIF or WebP file of at most 10 MiB and 40 megapixels,\nanimations at most 200 frames and 40 megapixels in all.\nMetadata like EXIF is removed and thumbnails are generated.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Upload a refill station image",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Image file",
+                        "name": "image",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.RefillStation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/reviews": {
+            "get": {
+                "description": "Get the average review score for a refill station by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get the average review score for a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StationReviewAverage"
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/status_changes": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the activations and deactivations of a refill station from from until before to,\nby default the last 30 days, at most 366 days, ordered by time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get the status history of a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/database.StationStatusChange"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/telemetry": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the reports of the devices of a refill station recorded from from until before to,\nby default the last 24 hours, at most 31 days. With an interval like 15m or 1h the\nreports are averaged per interval, otherwise every report is a point.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Telemetry"
+                ],
+                "summary": "Get the telemetry of a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Aggregation interval, e.g. 15m or 1h",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.TelemetrySeries"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Report the measurements of a smart refill station, authenticated with the API key\nof one of its devices. Every report is a heartbeat, a report without measurements\nis only a heartbeat and not stored. Stations whose devices stop sending heartbeats\nare deactivated until the next heartbeat arrives.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Telemetry"
+                ],
+                "summary": "Report telemetry",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Telemetry",
+                        "name": "telemetry",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.Telemetry"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.Telemetry"
+                        }
+                    },
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/refill_stations/{id}/uptime": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the availability of a refill station from from until before to, by default the\nlast 30 days, at most 366 days, with the intervals in which it was inactive.\nThe range starts when the station was created and ends at the current time at the latest.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Refill Stations"
+                ],
+                "summary": "Get the uptime of a refill station",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Refill Station ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start time (RFC 3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End time (RFC 3339)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.StationUptime"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a page of users, or the user with the given ID. The email is only included\nfor the authenticated user and for admins.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Show all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, first_name, last_name, role",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ListPage-database_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update the name and email of an existing user, fields left out keep their value",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update a user",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Create a user",
+                "parameters": [
+                    {
+                        "description": "User",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/role": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Make a user a regular user, an operator or an admin, admins only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Set the role of a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Role",
+                        "name": "role",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/water_transactions": {
+            "get": {
+                "description": "Get a page of water transactions, or the transaction with the given ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Water Transactions"
+                ],
+                "summary": "Show all water transactions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Water transaction ID",
+                        "name": "id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 50,
+                        "description": "Page size, at most 200",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of items to skip, cannot be combined with cursor",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "next_cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "id",
+                        "description": "Sort field, descending with a leading -: id, station_id, volume, water_type, timestamp",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, the id is always included",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.ListPage-database_WaterTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update an existing water transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Water Transactions"
+                ],
+                "summary": "Update a water transaction",
+                "parameters": [
+                    {
+                        "description": "Water Transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Create a new water transaction reported by the device of a refill station.\nThe station ID defaults to the station of the device, other stations are rejected.\nStations retrying a request send the same Idempotency-Key header or idempotency_key,\ne.g. a UUID, and get the stored transaction with status 200 instead of a duplicate.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Water Transactions"
+                ],
+                "summary": "Create a water transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key of the transaction among those of the station",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Water Transaction",
+                        "name": "transaction",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/database.WaterTransaction"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete an existing water transaction",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Water Transactions"
+                ],
+                "summary": "Delete a water transaction",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Water Transaction ID",
+                        "name": "id",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/water_transactions/batch": {
+            "post": {
+                "security": [
+                    {
+                        "DeviceKey": []
+                    }
+                ],
+                "description": "Create the transactions a station buffered while it was offline, with their original timestamps.\nEvery transaction is checked on its own: the station has to be the one of the device and offer the\nwater type, the bottle has to exist and belong to the user, and the timestamp may be at most 30 days old.\nTransactions uploaded before with the same idempotency key are not created again, so failed uploads\ncan be retried as a whole. The response reports the result of every transaction.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Water Transactions"
+                ],
+                "summary": "Upload buffered water transactions",
+                "parameters": [
+                    {
+                        "description": "Buffered transactions",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.WaterTransactionBatch"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/api.BatchResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.BatchItemResult": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/database.WaterTransaction"
+                }
+            }
+        },
+        "api.BatchResult": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "duplicates": {
+                    "type": "integer"
+                },
+                "failed": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.BatchItemResult"
+                    }
+                }
+            }
+        },
+        "api.BottleImage": {
+            "type": "object",
+            "properties": {
+                "bottle_image": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "api.ContributionCommunityResponse": {
+            "type": "object",
+            "properties": {
+                "amountFillings": {
+                    "type": "integer"
+                },
+                "amountUser": {
+                    "type": "integer"
+                },
+                "amountWater": {
+                    "type": "integer"
+                },
+                "savedMoney": {
+                    "type": "number"
+                },
+                "savedTrash": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.ContributionKLResponse": {
+            "type": "object",
+            "properties": {
+                "amountRefillStationManual": {
+                    "type": "integer"
+                },
+                "amountRefillStationSmart": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ContributionUserResponse": {
+            "type": "object",
+            "properties": {
+                "amountFillings": {
+                    "type": "integer"
+                },
+                "amountWater": {
+                    "type": "integer"
+                },
+                "savedMoney": {
+                    "type": "number"
+                },
+                "savedTrash": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.DeviceCredentials": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string"
+                },
+                "device": {
+                    "$ref": "#/definitions/database.Device"
+                },
+                "key": {
+                    "$ref": "#/definitions/database.DeviceKey"
+                }
+            }
+        },
+        "api.DeviceRegistration": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "api.DispenseConfirmation": {
+            "type": "object",
+            "required": [
+                "volume"
+            ],
+            "properties": {
+                "volume": {
+                    "description": "Volume is the dispensed volume in milliliters, at most the authorized one",
+                    "type": "integer",
+                    "minimum": 1
+                }
+            }
+        },
+        "api.DispenseRequest": {
+            "type": "object",
+            "required": [
+                "nfc_id"
+            ],
+            "properties": {
+                "nfc_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.ListPage-database_Bottle": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Bottle"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_Like": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.Like"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_RefillStation": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.RefillStation"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_RefillStationProblem": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.RefillStationProblem"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_RefillStationReview": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.RefillStationReview"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_User": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.User"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.ListPage-database_WaterTransaction": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/database.WaterTransaction"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next_cursor": {
+                    "type": "string"
+                },
+                "offset": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.LoginRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.Marker": {
+            "type": "object",
+            "properties": {
+                "cluster": {
+                    "type": "boolean"
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "status": {
+                    "$ref": "#/definitions/database.NullBool"
+                }
+            }
+        },
+        "api.Outage": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "duration_seconds": {
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.PostRequestRefillStationProblem": {
+            "type": "object",
+            "properties": {
+                "deactivate_station": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "problem_image": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
                     }
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "api.BottleImage": {
+        },
+        "api.ProblemTransition": {
             "type": "object",
+            "required": [
+                "status"
+            ],
             "properties": {
-                "bottle_image": {
+                "comment": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "deactivate_station": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RefreshRequest": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "first_name",
+                "last_name",
+                "password"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "api.SetRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.StationImage": {
+            "type": "object",
+            "properties": {
+                "station_image": {
                     "type": "array",
                     "items": {
                         "type": "integer"
@@ -1337,137 +4792,360 @@ const docTemplate = `{
                 }
             }
         },
-        "api.ContributionCommunityResponse": {
+        "api.StationLikeCounter": {
             "type": "object",
             "properties": {
-                "amountFillings": {
+                "like_counter": {
                     "type": "integer"
                 },
-                "amountUser": {
+                "station_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "api.StationReviewAverage": {
+            "type": "object",
+            "properties": {
+                "accesibility": {
+                    "type": "number"
+                },
+                "cleanness": {
+                    "type": "number"
+                },
+                "waterQuality": {
+                    "type": "number"
+                }
+            }
+        },
+        "api.StationSearchPage": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.StationSearchResult"
+                    }
+                },
+                "next_cursor": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.StationSearchResult": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "$ref": "#/definitions/database.NullBool"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "average_accessibility": {
+                    "type": "number"
+                },
+                "average_cleanness": {
+                    "type": "number"
+                },
+                "average_rating": {
+                    "type": "number"
+                },
+                "average_water_quality": {
+                    "type": "number"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "distance_m": {
+                    "type": "number"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "amountWater": {
+                "image_id": {
                     "type": "integer"
                 },
-                "savedMoney": {
+                "is_open_now": {
+                    "type": "boolean"
+                },
+                "latitude": {
                     "type": "number"
                 },
-                "savedTrash": {
+                "like_count": {
+                    "type": "integer"
+                },
+                "liked_by_me": {
+                    "type": "boolean"
+                },
+                "longitude": {
                     "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_change_at": {
+                    "type": "string"
+                },
+                "offered_water_types": {
+                    "type": "string"
+                },
+                "offline_since": {
+                    "type": "string"
+                },
+                "opening_hours": {
+                    "$ref": "#/definitions/hours.Schedule"
+                },
+                "opening_times": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "review_count": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "water_source": {
+                    "type": "string"
                 }
             }
         },
-        "api.ContributionKLResponse": {
+        "api.StationStatusUpdate": {
             "type": "object",
+            "required": [
+                "active",
+                "reason"
+            ],
             "properties": {
-                "amountRefillStationManual": {
+                "active": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "api.StationUptime": {
+            "type": "object",
+            "properties": {
+                "availability": {
+                    "type": "number"
+                },
+                "downtime_seconds": {
                     "type": "integer"
                 },
-                "amountRefillStationSmart": {
+                "from": {
+                    "type": "string"
+                },
+                "outages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.Outage"
+                    }
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "uptime_seconds": {
                     "type": "integer"
                 }
             }
         },
-        "api.ContributionUserResponse": {
+        "api.TelemetryPoint": {
             "type": "object",
             "properties": {
-                "amountFillings": {
-                    "type": "integer"
+                "filter_status": {
+                    "type": "string"
                 },
-                "amountWater": {
+                "flow_rate": {
+                    "type": "number"
+                },
+                "samples": {
                     "type": "integer"
                 },
-                "savedMoney": {
+                "tank_level": {
                     "type": "number"
                 },
-                "savedTrash": {
+                "temperature": {
                     "type": "number"
+                },
+                "time": {
+                    "type": "string"
                 }
             }
         },
-        "api.PostRequestRefillStationProblem": {
+        "api.TelemetrySeries": {
             "type": "object",
             "properties": {
-                "description": {
+                "from": {
                     "type": "string"
                 },
-                "problem_image": {
+                "interval": {
+                    "type": "string"
+                },
+                "points": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/api.TelemetryPoint"
                     }
                 },
                 "station_id": {
                     "type": "integer"
                 },
-                "status": {
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.TokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
                     "type": "string"
                 },
-                "title": {
+                "expires_in": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "token_type": {
                     "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/database.User"
                 }
             }
         },
-        "api.StationImage": {
+        "api.WaterTransactionBatch": {
             "type": "object",
+            "required": [
+                "transactions"
+            ],
             "properties": {
-                "station_image": {
+                "transactions": {
                     "type": "array",
+                    "maxItems": 500,
+                    "minItems": 1,
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/database.WaterTransaction"
                     }
                 }
             }
         },
-        "api.StationLikeCounter": {
+        "database.Bottle": {
             "type": "object",
             "properties": {
-                "like_counter": {
+                "NFCID": {
+                    "type": "string"
+                },
+                "active": {
+                    "type": "boolean"
+                },
+                "bottle_image": {
+                    "type": "string"
+                },
+                "fill_volume": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_id": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "water_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "database.Device": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
                 "station_id": {
                     "type": "integer"
                 }
             }
         },
-        "api.StationReviewAverage": {
+        "database.DeviceKey": {
             "type": "object",
             "properties": {
-                "accesibility": {
-                    "type": "number"
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
                 },
-                "cleanness": {
-                    "type": "number"
+                "prefix": {
+                    "type": "string"
                 },
-                "waterQuality": {
-                    "type": "number"
+                "revoked_at": {
+                    "type": "string"
                 }
             }
         },
-        "database.Bottle": {
+        "database.Dispense": {
             "type": "object",
             "properties": {
-                "active": {
-                    "type": "boolean"
+                "bottle_id": {
+                    "type": "integer"
                 },
-                "bottle_image": {
+                "confirmed_at": {
                     "type": "string"
                 },
-                "fill_volume": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "nfcid": {
-                    "type": "string"
+                "station_id": {
+                    "type": "integer"
                 },
-                "title": {
-                    "type": "string"
+                "transaction_id": {
+                    "type": "integer"
                 },
                 "user_id": {
                     "type": "integer"
                 },
+                "volume": {
+                    "type": "integer"
+                },
                 "water_type": {
                     "type": "string"
                 }
@@ -1498,6 +5176,32 @@ const docTemplate = `{
                 }
             }
         },
+        "database.ProblemStatusChange": {
+            "type": "object",
+            "properties": {
+                "actor_id": {
+                    "type": "integer"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "from_status": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "problem_id": {
+                    "type": "integer"
+                },
+                "to_status": {
+                    "type": "string"
+                }
+            }
+        },
         "database.RefillStation": {
             "type": "object",
             "properties": {
@@ -1513,6 +5217,12 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "image_id": {
+                    "type": "integer"
+                },
+                "is_open_now": {
+                    "type": "boolean"
+                },
                 "latitude": {
                     "type": "number"
                 },
@@ -1522,12 +5232,24 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "next_change_at": {
+                    "type": "string"
+                },
                 "offered_water_types": {
                     "type": "string"
                 },
+                "offline_since": {
+                    "type": "string"
+                },
+                "opening_hours": {
+                    "$ref": "#/definitions/hours.Schedule"
+                },
                 "opening_times": {
                     "type": "string"
                 },
+                "owner_id": {
+                    "type": "integer"
+                },
                 "type": {
                     "type": "string"
                 },
@@ -1539,12 +5261,18 @@ const docTemplate = `{
         "database.RefillStationProblem": {
             "type": "object",
             "properties": {
+                "deactivate_station": {
+                    "type": "boolean"
+                },
                 "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "image_id": {
+                    "type": "integer"
+                },
                 "station_id": {
                     "type": "integer"
                 },
@@ -1585,6 +5313,73 @@ const docTemplate = `{
                 }
             }
         },
+        "database.StationStatusChange": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "problem_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "database.Telemetry": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "filter_status": {
+                    "type": "string"
+                },
+                "flow_rate": {
+                    "description": "FlowRate in liters per minute",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "station_id": {
+                    "type": "integer"
+                },
+                "tank_level": {
+                    "description": "TankLevel is the fill level of the tank in percent",
+                    "type": "number"
+                },
+                "temperature": {
+                    "description": "Temperature of the water in degrees Celsius",
+                    "type": "number"
+                }
+            }
+        },
         "database.User": {
             "type": "object",
             "properties": {
@@ -1599,6 +5394,9 @@ const docTemplate = `{
                 },
                 "last_name": {
                     "type": "string"
+                },
+                "role": {
+                    "type": "string"
                 }
             }
         },
@@ -1614,6 +5412,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "idempotency_key": {
+                    "type": "string"
+                },
                 "station_id": {
                     "type": "integer"
                 },
@@ -1630,6 +5431,218 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "hours.Exception": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2026-12-24"
+                },
+                "hours": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/hours.TimeRange"
+                    }
+                }
+            }
+        },
+        "hours.Interval": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "string",
+                    "example": "18:00"
+                },
+                "day": {
+                    "type": "string",
+                    "enum": [
+                        "Mo",
+                        "Tu",
+                        "We",
+                        "Th",
+                        "Fr",
+                        "Sa",
+                        "Su"
+                    ]
+                },
+                "open": {
+                    "type": "string",
+                    "example": "08:00"
+                }
+            }
+        },
+        "hours.Schedule": {
+            "type": "object",
+            "properties": {
+                "exceptions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/hours.Exception"
+                    }
+                },
+                "public_holidays": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/hours.TimeRange"
+                    }
+                },
+                "time_zone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                },
+                "weekly": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/hours.Interval"
+                    }
+                }
+            }
+        },
+        "hours.TimeRange": {
+            "type": "object",
+            "properties": {
+                "close": {
+                    "type": "string",
+                    "example": "18:00"
+                },
+                "open": {
+                    "type": "string",
+                    "example": "08:00"
+                }
+            }
+        },
+        "repository.NearbyStation": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "$ref": "#/definitions/database.NullBool"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "distance_m": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_id": {
+                    "type": "integer"
+                },
+                "is_open_now": {
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_change_at": {
+                    "type": "string"
+                },
+                "offered_water_types": {
+                    "type": "string"
+                },
+                "offline_since": {
+                    "type": "string"
+                },
+                "opening_hours": {
+                    "$ref": "#/definitions/hours.Schedule"
+                },
+                "opening_times": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "water_source": {
+                    "type": "string"
+                }
+            }
+        },
+        "repository.ScoredStation": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "$ref": "#/definitions/database.NullBool"
+                },
+                "address": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_id": {
+                    "type": "integer"
+                },
+                "is_open_now": {
+                    "type": "boolean"
+                },
+                "latitude": {
+                    "type": "number"
+                },
+                "longitude": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "next_change_at": {
+                    "type": "string"
+                },
+                "offered_water_types": {
+                    "type": "string"
+                },
+                "offline_since": {
+                    "type": "string"
+                },
+                "opening_hours": {
+                    "$ref": "#/definitions/hours.Schedule"
+                },
+                "opening_times": {
+                    "type": "string"
+                },
+                "owner_id": {
+                    "type": "integer"
+                },
+                "score": {
+                    "type": "number"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "water_source": {
+                    "type": "string"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Access token from /auth/login as \"Bearer \u003ctoken\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
+        "DeviceKey": {
+            "description": "API key of a refill station device",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,52 @@
+package geo
+
+import (
+	"math"
+	"sort"
+)
+
+// TileSize is the edge length in pixels of a map tile, the world is TileSize * 2^zoom pixels wide
+const TileSize = 256
+
+// maxMercatorLat is the latitude at which the web mercator projection is cut off
+const maxMercatorLat = 85.05112878
+
+// Pixel projects a position with web mercator to world pixel coordinates at the zoom level
+func Pixel(lat, lon float64, zoom int) (x, y float64) {
+	size := TileSize * math.Exp2(float64(zoom))
+	lat = math.Max(-maxMercatorLat, math.Min(maxMercatorLat, lat))
+	sinLat := math.Sin(radians(lat))
+	x = (lon + 180) / 360 * size
+	y = (0.5 - math.Log((1+sinLat)/(1-sinLat))/(4*math.Pi)) * size
+	return x, y
+}
+
+// GridCluster groups the items whose positions fall into the same square grid
+// cell of cellSize pixels at the zoom level. Items keep their input order within
+// a group and the groups are ordered by their first item.
+func GridCluster[T any](items []T, position func(*T) (lat, lon float64), zoom int, cellSize float64) [][]T {
+	type cell struct{ x, y int64 }
+	first := map[cell]int{}
+	groups := map[cell][]T{}
+	for i := range items {
+		lat, lon := position(&items[i])
+		x, y := Pixel(lat, lon, zoom)
+		key := cell{int64(math.Floor(x / cellSize)), int64(math.Floor(y / cellSize))}
+		if _, ok := groups[key]; !ok {
+			first[key] = i
+		}
+		groups[key] = append(groups[key], items[i])
+	}
+
+	keys := make([]cell, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return first[keys[i]] < first[keys[j]] })
+
+	clusters := make([][]T, 0, len(keys))
+	for _, key := range keys {
+		clusters = append(clusters, groups[key])
+	}
+	return clusters
+}
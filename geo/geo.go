@@ -0,0 +1,50 @@
+// Package geo computes great-circle distances and search areas on the earth
+package geo
+
+import "math"
+
+// EarthRadius is the mean earth radius in meters
+const EarthRadius = 6371008.8
+
+// Distance returns the great-circle distance in meters between two positions using the haversine formula
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Box is an area bounded by latitudes and longitudes in degrees
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Contains reports whether the position lies within the box
+func (b Box) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// BoundingBox returns a box containing every position within radius meters of the center.
+// Near the poles or across the antimeridian the box spans all longitudes.
+func BoundingBox(lat, lon, radius float64) Box {
+	dLat := degrees(radius / EarthRadius)
+	box := Box{MinLat: lat - dLat, MaxLat: lat + dLat, MinLon: -180, MaxLon: 180}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat, box.MaxLat = math.Max(box.MinLat, -90), math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	dLon := degrees(math.Asin(math.Sin(radius/EarthRadius) / math.Cos(radians(lat))))
+	if lon-dLon >= -180 && lon+dLon <= 180 {
+		box.MinLon, box.MaxLon = lon-dLon, lon+dLon
+	}
+	return box
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
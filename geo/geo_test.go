@@ -0,0 +1,100 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	cases := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64
+	}{
+		{"same position", 49.4375, 7.7614, 49.4375, 7.7614, 0},
+		{"one degree of latitude", 0, 0, 1, 0, 111195},
+		{"Kaiserslautern to Mannheim", 49.4447, 7.7690, 49.4875, 8.4660, 50600},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111195},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Distance(tc.lat1, tc.lon1, tc.lat2, tc.lon2)
+			if math.Abs(got-tc.want) > tc.want*0.01+1 {
+				t.Errorf("expected about %.0f m, got %.0f m", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	lat, lon, radius := 49.4375, 7.7614, 5000.0
+	box := BoundingBox(lat, lon, radius)
+
+	// Every point on the circle lies within the box
+	for bearing := 0.0; bearing < 360; bearing += 5 {
+		pLat, pLon := destination(lat, lon, radius*0.999, bearing)
+		if !box.Contains(pLat, pLon) {
+			t.Errorf("box %+v misses point at bearing %.0f", box, bearing)
+		}
+	}
+	if box.Contains(lat+0.1, lon) || box.Contains(lat, lon+0.1) {
+		t.Errorf("box %+v is too large", box)
+	}
+
+	if polar := BoundingBox(89.99, 0, radius); polar.MinLon != -180 || polar.MaxLon != 180 || polar.MaxLat != 90 {
+		t.Errorf("expected polar box to span all longitudes, got %+v", polar)
+	}
+	if wrapped := BoundingBox(0, 179.99, radius); wrapped.MinLon != -180 || wrapped.MaxLon != 180 {
+		t.Errorf("expected box across the antimeridian to span all longitudes, got %+v", wrapped)
+	}
+}
+
+// destination returns the position reached from the start after distance meters along the bearing in degrees
+func destination(lat, lon, distance, bearing float64) (float64, float64) {
+	phi, lambda, theta := radians(lat), radians(lon), radians(bearing)
+	delta := distance / EarthRadius
+	phi2 := math.Asin(math.Sin(phi)*math.Cos(delta) + math.Cos(phi)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := lambda + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi), math.Cos(delta)-math.Sin(phi)*math.Sin(phi2))
+	return degrees(phi2), degrees(lambda2)
+}
+
+func TestPixel(t *testing.T) {
+	if x, y := Pixel(0, 0, 0); x != 128 || y != 128 {
+		t.Errorf("expected the null island in the center of the world tile, got %v, %v", x, y)
+	}
+	if x, y := Pixel(maxMercatorLat, -180, 1); x != 0 || math.Abs(y) > 1e-6 {
+		t.Errorf("expected the top left corner, got %v, %v", x, y)
+	}
+	if x, _ := Pixel(0, 180, 2); x != 1024 {
+		t.Errorf("expected the right edge at 1024 px on zoom 2, got %v", x)
+	}
+}
+
+func TestGridCluster(t *testing.T) {
+	type place struct {
+		name     string
+		lat, lon float64
+	}
+	places := []place{
+		{"Stadtpark", 49.4375, 7.7614},
+		{"Berlin", 52.5200, 13.4050},
+		{"Rewe", 49.4449, 7.7675},
+		{"Potsdam", 52.3906, 13.0645},
+	}
+	position := func(p *place) (float64, float64) { return p.lat, p.lon }
+
+	clusters := GridCluster(places, position, 6, 60)
+	if len(clusters) != 2 || len(clusters[0]) != 2 || len(clusters[1]) != 2 {
+		t.Fatalf("expected two clusters of two places on zoom 6, got %v", clusters)
+	}
+	if clusters[0][0].name != "Stadtpark" || clusters[0][1].name != "Rewe" || clusters[1][0].name != "Berlin" {
+		t.Errorf("expected clusters in input order, got %v", clusters)
+	}
+
+	if clusters := GridCluster(places, position, 18, 60); len(clusters) != 4 {
+		t.Errorf("expected every place on its own on zoom 18, got %v", clusters)
+	}
+	if clusters := GridCluster([]place{}, position, 3, 60); len(clusters) != 0 {
+		t.Errorf("expected no clusters without places, got %v", clusters)
+	}
+}
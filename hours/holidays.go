@@ -0,0 +1,42 @@
+package hours
+
+import "time"
+
+// IsPublicHoliday reports whether the date is a public holiday in Rhineland-Palatinate,
+// where the refill stations are located
+func IsPublicHoliday(date time.Time) bool {
+	year, month, day := date.Date()
+	switch {
+	case month == time.January && day == 1,
+		month == time.May && day == 1,
+		month == time.October && day == 3,
+		month == time.November && day == 1,
+		month == time.December && (day == 25 || day == 26):
+		return true
+	}
+
+	easter := easterSunday(year)
+	offset := int(time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Sub(easter).Hours() / 24)
+	switch offset {
+	// Good Friday, Easter Monday, Ascension, Whit Monday and Corpus Christi
+	case -2, 1, 39, 50, 60:
+		return true
+	}
+	return false
+}
+
+// easterSunday computes the date of Easter Sunday in the Gregorian calendar
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b, c := year/100, year%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,301 @@
+package hours
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnknownFormat is returned when text is neither OSM opening_hours nor the legacy format
+var ErrUnknownFormat = errors.New("unknown opening hours format")
+
+// Parse reads OSM opening_hours syntax or the legacy format of the station data
+// like "Mo - Sa / 09:30 bis 20:00". The schedule uses the default time zone.
+func Parse(text string) (Schedule, error) {
+	if schedule, err := ParseOSM(text); err == nil {
+		return schedule, nil
+	}
+	if schedule, err := ParseLegacy(text); err == nil {
+		return schedule, nil
+	}
+	return Schedule{}, fmt.Errorf("%w: %q", ErrUnknownFormat, text)
+}
+
+var (
+	germanDays = map[string]Weekday{"mo": 1, "di": 2, "mi": 3, "do": 4, "fr": 5, "sa": 6, "so": 0}
+
+	legacyPattern = regexp.MustCompile(`(?i)^\s*(mo|di|mi|do|fr|sa|so)(?:\s*-\s*(mo|di|mi|do|fr|sa|so))?\s*[/,]\s*(\S+?)\s*(?:-|bis)\s*(\S+)\s*$`)
+	legacyClock   = regexp.MustCompile(`(?i)^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+)
+
+// ParseLegacy reads the free text format of the station data: German day
+// abbreviations, a slash or comma and a time range like "7:00 - 22:00",
+// "09:30 bis 20:00" or "8am - 6pm". A closing time of 23:59 ends the day.
+func ParseLegacy(text string) (Schedule, error) {
+	match := legacyPattern.FindStringSubmatch(text)
+	if match == nil {
+		return Schedule{}, fmt.Errorf("%w: %q", ErrUnknownFormat, text)
+	}
+	first := germanDays[strings.ToLower(match[1])]
+	last := first
+	if match[2] != "" {
+		last = germanDays[strings.ToLower(match[2])]
+	}
+	open, err := parseLegacyClock(match[3])
+	if err != nil {
+		return Schedule{}, err
+	}
+	closing, err := parseLegacyClock(match[4])
+	if err != nil {
+		return Schedule{}, err
+	}
+	if closing == EndOfDay-1 {
+		closing = EndOfDay
+	}
+
+	schedule := Schedule{TimeZone: DefaultTimeZone}
+	for _, day := range dayRange(first, last) {
+		schedule.Weekly = append(schedule.Weekly, Interval{Day: day, TimeRange: TimeRange{Open: open, Close: closing}})
+	}
+	return schedule, schedule.Validate()
+}
+
+func parseLegacyClock(text string) (Clock, error) {
+	match := legacyClock.FindStringSubmatch(text)
+	if match == nil {
+		return 0, fmt.Errorf("invalid time %q", text)
+	}
+	hour, _ := strconv.Atoi(match[1])
+	minute := 0
+	if match[2] != "" {
+		minute, _ = strconv.Atoi(match[2])
+	}
+	switch suffix := strings.ToLower(match[3]); {
+	case suffix != "" && (hour < 1 || hour > 12):
+		return 0, fmt.Errorf("invalid time %q", text)
+	case suffix == "am" && hour == 12:
+		hour = 0
+	case suffix == "pm" && hour != 12:
+		hour += 12
+	}
+	return ParseClock(fmt.Sprintf("%d:%02d", hour, minute))
+}
+
+var (
+	osmTimeRange = regexp.MustCompile(`^(\d{2}:\d{2})-(\d{2}:\d{2})$`)
+	osmDate      = regexp.MustCompile(`^(\d{4}) ([A-Z][a-z]{2}) (\d{1,2})$`)
+	months       = map[string]time.Month{"Jan": 1, "Feb": 2, "Mar": 3, "Apr": 4, "May": 5, "Jun": 6, "Jul": 7, "Aug": 8, "Sep": 9, "Oct": 10, "Nov": 11, "Dec": 12}
+)
+
+// ParseOSM reads the common subset of the OSM opening_hours syntax: "24/7" and
+// rules separated by semicolons, each with weekdays like "Mo-Fr,Su", "PH" or a
+// date like "2026 Dec 24" followed by time ranges or "off". Like in OSM a later
+// rule replaces the hours of an earlier rule for the same days.
+func ParseOSM(text string) (Schedule, error) {
+	schedule := Schedule{TimeZone: DefaultTimeZone}
+	text = strings.TrimSpace(text)
+	if text == "24/7" {
+		for _, day := range dayRange(1, 0) {
+			schedule.Weekly = append(schedule.Weekly, Interval{Day: day, TimeRange: TimeRange{Open: 0, Close: EndOfDay}})
+		}
+		return schedule, nil
+	}
+
+	weekly := map[Weekday][]TimeRange{}
+	for _, rule := range strings.Split(text, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		selector, ranges, err := splitOSMRule(rule)
+		if err != nil {
+			return Schedule{}, err
+		}
+		if match := osmDate.FindStringSubmatch(selector); match != nil {
+			date, err := parseOSMDate(match)
+			if err != nil {
+				return Schedule{}, err
+			}
+			schedule.setException(date, ranges)
+			continue
+		}
+		if selector == "" {
+			selector = "Mo-Su"
+		}
+		for _, part := range strings.Split(selector, ",") {
+			if part == "PH" {
+				holidays := ranges
+				schedule.PublicHolidays = &holidays
+				continue
+			}
+			days, err := parseOSMDays(part)
+			if err != nil {
+				return Schedule{}, err
+			}
+			for _, day := range days {
+				weekly[day] = ranges
+			}
+		}
+	}
+
+	for _, day := range dayRange(1, 0) {
+		for _, r := range weekly[day] {
+			schedule.Weekly = append(schedule.Weekly, Interval{Day: day, TimeRange: r})
+		}
+	}
+	return schedule, schedule.Validate()
+}
+
+// splitOSMRule separates the selector of a rule from its time ranges
+func splitOSMRule(rule string) (string, []TimeRange, error) {
+	fields := strings.Fields(rule)
+	i := 0
+	for i < len(fields) && !strings.Contains(fields[i], ":") && fields[i] != "off" && fields[i] != "closed" {
+		i++
+	}
+	if i == len(fields) {
+		return "", nil, fmt.Errorf("%w: rule %q has no times", ErrUnknownFormat, rule)
+	}
+	selector := strings.Join(fields[:i], " ")
+	times := strings.Join(fields[i:], "")
+	if times == "off" || times == "closed" {
+		return selector, []TimeRange{}, nil
+	}
+
+	ranges := []TimeRange{}
+	for _, part := range strings.Split(times, ",") {
+		match := osmTimeRange.FindStringSubmatch(part)
+		if match == nil {
+			return "", nil, fmt.Errorf("%w: invalid time range %q", ErrUnknownFormat, part)
+		}
+		open, err := ParseClock(match[1])
+		if err != nil {
+			return "", nil, err
+		}
+		closing, err := ParseClock(match[2])
+		if err != nil {
+			return "", nil, err
+		}
+		ranges = append(ranges, TimeRange{Open: open, Close: closing})
+	}
+	return selector, ranges, nil
+}
+
+func parseOSMDays(text string) ([]Weekday, error) {
+	first, last, isRange := strings.Cut(text, "-")
+	var start, end Weekday
+	if err := start.UnmarshalText([]byte(first)); err != nil || first != start.String() {
+		return nil, fmt.Errorf("%w: invalid weekday %q", ErrUnknownFormat, first)
+	}
+	end = start
+	if isRange {
+		if err := end.UnmarshalText([]byte(last)); err != nil || last != end.String() {
+			return nil, fmt.Errorf("%w: invalid weekday %q", ErrUnknownFormat, last)
+		}
+	}
+	return dayRange(start, end), nil
+}
+
+func parseOSMDate(match []string) (string, error) {
+	month, ok := months[match[2]]
+	if !ok {
+		return "", fmt.Errorf("%w: invalid month %q", ErrUnknownFormat, match[2])
+	}
+	year, _ := strconv.Atoi(match[1])
+	day, _ := strconv.Atoi(match[3])
+	date := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		return "", fmt.Errorf("%w: invalid date %q", ErrUnknownFormat, strings.Join(match[1:], " "))
+	}
+	return date.Format(dateLayout), nil
+}
+
+func (s *Schedule) setException(date string, ranges []TimeRange) {
+	for i := range s.Exceptions {
+		if s.Exceptions[i].Date == date {
+			s.Exceptions[i].Hours = ranges
+			return
+		}
+	}
+	s.Exceptions = append(s.Exceptions, Exception{Date: date, Hours: ranges})
+}
+
+// dayRange returns the days from first to last, wrapping around the end of the week
+func dayRange(first, last Weekday) []Weekday {
+	days := []Weekday{first}
+	for day := first; day != last; {
+		day = (day + 1) % 7
+		days = append(days, day)
+	}
+	return days
+}
+
+// String formats the schedule in OSM opening_hours syntax, joining consecutive
+// days with equal hours. The time zone is not part of the syntax.
+func (s Schedule) String() string {
+	byDay := map[Weekday]string{}
+	for _, interval := range s.Weekly {
+		if byDay[interval.Day] != "" {
+			byDay[interval.Day] += ","
+		}
+		byDay[interval.Day] += interval.TimeRange.String()
+	}
+
+	var rules []string
+	week := dayRange(1, 0)
+	if len(s.Exceptions) == 0 && s.PublicHolidays == nil && allDay(byDay, week) {
+		return "24/7"
+	}
+	for i := 0; i < len(week); {
+		j := i
+		for j+1 < len(week) && byDay[week[j+1]] == byDay[week[i]] {
+			j++
+		}
+		if hours := byDay[week[i]]; hours != "" {
+			days := week[i].String()
+			if j > i {
+				days += "-" + week[j].String()
+			}
+			rules = append(rules, days+" "+hours)
+		}
+		i = j + 1
+	}
+	if s.PublicHolidays != nil {
+		rules = append(rules, "PH "+formatRanges(*s.PublicHolidays))
+	}
+
+	exceptions := append([]Exception(nil), s.Exceptions...)
+	sort.Slice(exceptions, func(i, j int) bool { return exceptions[i].Date < exceptions[j].Date })
+	for _, exception := range exceptions {
+		date, err := time.Parse(dateLayout, exception.Date)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, date.Format("2006 Jan 2")+" "+formatRanges(exception.Hours))
+	}
+	return strings.Join(rules, "; ")
+}
+
+func allDay(byDay map[Weekday]string, week []Weekday) bool {
+	for _, day := range week {
+		if byDay[day] != (TimeRange{Open: 0, Close: EndOfDay}).String() {
+			return false
+		}
+	}
+	return true
+}
+
+func formatRanges(ranges []TimeRange) string {
+	if len(ranges) == 0 {
+		return "off"
+	}
+	parts := make([]string, len(ranges))
+	for i, r := range ranges {
+		parts[i] = r.String()
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,138 @@
+package hours
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func weekly(days []Weekday, open, closing Clock) []Interval {
+	var intervals []Interval
+	for _, day := range days {
+		intervals = append(intervals, Interval{Day: day, TimeRange: TimeRange{Open: open, Close: closing}})
+	}
+	return intervals
+}
+
+func TestParseLegacy(t *testing.T) {
+	cases := []struct {
+		text string
+		want []Interval
+	}{
+		{"Mo - So / 00:00 - 23:59", weekly(dayRange(1, 0), 0, EndOfDay)},
+		{"Mo - Sa / 7:00 - 22:00", weekly(dayRange(1, 6), 7*60, 22*60)},
+		{"Do / 07:00 - 13:59", weekly([]Weekday{4}, 7*60, 13*60+59)},
+		{"Mo - Sa / 09:30 bis 20:00", weekly(dayRange(1, 6), 9*60+30, 20*60)},
+		{"Mo - Fr, 8am - 6pm", weekly(dayRange(1, 5), 8*60, 18*60)},
+		{"Sa - Mo / 12pm - 12am", weekly([]Weekday{6, 0, 1}, 12*60, 0)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			schedule, err := ParseLegacy(tc.text)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if schedule.TimeZone != DefaultTimeZone || !reflect.DeepEqual(schedule.Weekly, tc.want) {
+				t.Errorf("expected %v, got %+v", tc.want, schedule)
+			}
+		})
+	}
+
+	for _, text := range []string{"", "immer", "Mo - Fr", "Mo - Xy / 08:00 - 10:00", "Mo / 25:00 - 26:00", "Mo / 13pm - 14pm"} {
+		if _, err := ParseLegacy(text); err == nil {
+			t.Errorf("expected an error for %q", text)
+		}
+	}
+}
+
+func TestParseOSM(t *testing.T) {
+	schedule, err := ParseOSM("Mo-Su 08:00-18:00; We off; Fr-Sa 08:00-12:00,20:00-02:00; PH 10:00-14:00; 2026 Dec 24 off")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := Schedule{
+		TimeZone: DefaultTimeZone,
+		Weekly: []Interval{
+			{Day: 1, TimeRange: TimeRange{Open: 8 * 60, Close: 18 * 60}},
+			{Day: 2, TimeRange: TimeRange{Open: 8 * 60, Close: 18 * 60}},
+			{Day: 4, TimeRange: TimeRange{Open: 8 * 60, Close: 18 * 60}},
+			{Day: 5, TimeRange: TimeRange{Open: 8 * 60, Close: 12 * 60}},
+			{Day: 5, TimeRange: TimeRange{Open: 20 * 60, Close: 2 * 60}},
+			{Day: 6, TimeRange: TimeRange{Open: 8 * 60, Close: 12 * 60}},
+			{Day: 6, TimeRange: TimeRange{Open: 20 * 60, Close: 2 * 60}},
+			{Day: 0, TimeRange: TimeRange{Open: 8 * 60, Close: 18 * 60}},
+		},
+		PublicHolidays: &[]TimeRange{{Open: 10 * 60, Close: 14 * 60}},
+		Exceptions:     []Exception{{Date: "2026-12-24", Hours: []TimeRange{}}},
+	}
+	if !reflect.DeepEqual(schedule, want) {
+		t.Errorf("expected %+v, got %+v", want, schedule)
+	}
+
+	if schedule, err := ParseOSM("24/7"); err != nil || !reflect.DeepEqual(schedule.Weekly, weekly(dayRange(1, 0), 0, EndOfDay)) {
+		t.Errorf("unexpected 24/7 schedule %+v: %v", schedule, err)
+	}
+
+	for _, text := range []string{"whenever", "Mo-Fr", "Mo-Xy 08:00-10:00", "mo 08:00-10:00", "Mo 8-10", "Mo 25:00-26:00", "2026 Feb 30 off", "Mo 10:00-10:00"} {
+		if _, err := ParseOSM(text); err == nil {
+			t.Errorf("expected an error for %q", text)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("Mo-Fr 08:00-18:00"); err != nil {
+		t.Errorf("expected OSM syntax to parse: %v", err)
+	}
+	if _, err := Parse("Di - Fr / 09:00 - 17:00"); err != nil {
+		t.Errorf("expected the legacy format to parse: %v", err)
+	}
+	if _, err := Parse("nach Vereinbarung"); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
+
+func TestScheduleString(t *testing.T) {
+	for _, text := range []string{
+		"24/7",
+		"Mo-Fr 08:00-18:00; Sa 09:00-12:00",
+		"Mo 08:00-12:00,13:00-17:00; We-Th 08:00-12:00; Su 10:00-02:00; PH off; 2026 Dec 24 10:00-12:00; 2026 Dec 31 off",
+	} {
+		schedule, err := ParseOSM(text)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", text, err)
+		}
+		if got := schedule.String(); got != text {
+			t.Errorf("expected %q, got %q", text, got)
+		}
+	}
+
+	legacy, _ := ParseLegacy("Mo - So / 00:00 - 23:59")
+	if got := legacy.String(); got != "24/7" {
+		t.Errorf("expected the whole week to format as 24/7, got %q", got)
+	}
+}
+
+func TestScheduleJSON(t *testing.T) {
+	schedule, _ := ParseOSM("Mo 08:00-24:00; PH off; 2026 Dec 24 off")
+	data, err := json.Marshal(schedule)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	want := `{"time_zone":"Europe/Berlin","weekly":[{"day":"Mo","open":"08:00","close":"24:00"}],"public_holidays":[],"exceptions":[{"date":"2026-12-24","hours":[]}]}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	var decoded Schedule
+	if err := json.Unmarshal(data, &decoded); err != nil || !reflect.DeepEqual(decoded, schedule) {
+		t.Errorf("expected %+v after decoding, got %+v: %v", schedule, decoded, err)
+	}
+	if err := json.Unmarshal([]byte(`{"weekly":[{"day":"Xy","open":"08:00","close":"10:00"}]}`), &decoded); err == nil {
+		t.Error("expected an invalid weekday to fail")
+	}
+	if err := json.Unmarshal([]byte(`{"weekly":[{"day":"Mo","open":"8","close":"10:00"}]}`), &decoded); err == nil {
+		t.Error("expected an invalid time to fail")
+	}
+}
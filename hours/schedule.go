@@ -0,0 +1,256 @@
+// Package hours models the weekly opening hours of a refill station, parses
+// them from text and computes whether a station is open at a given time.
+package hours
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	// Embeds the time zone database, the runtime image does not ship one
+	_ "time/tzdata"
+)
+
+// DefaultTimeZone applies to schedules without a time zone
+const DefaultTimeZone = "Europe/Berlin"
+
+// horizonDays bounds the search for the next opening or closing
+const horizonDays = 14
+
+const dateLayout = "2006-01-02"
+
+// Weekday is a day of the week encoded with its two letter OSM abbreviation
+type Weekday time.Weekday
+
+var weekdayNames = [7]string{"Su", "Mo", "Tu", "We", "Th", "Fr", "Sa"}
+
+func (d Weekday) String() string {
+	if d < 0 || int(d) >= len(weekdayNames) {
+		return "Weekday(" + strconv.Itoa(int(d)) + ")"
+	}
+	return weekdayNames[d]
+}
+
+func (d Weekday) MarshalText() ([]byte, error) {
+	if d < 0 || int(d) >= len(weekdayNames) {
+		return nil, fmt.Errorf("invalid weekday %d", int(d))
+	}
+	return []byte(d.String()), nil
+}
+
+func (d *Weekday) UnmarshalText(text []byte) error {
+	for i, name := range weekdayNames {
+		if strings.EqualFold(name, string(text)) {
+			*d = Weekday(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid weekday %q, expected one of %v", text, weekdayNames)
+}
+
+// Clock is a time of day in minutes since midnight encoded as "15:04", 24:00 is the end of a day
+type Clock int
+
+// EndOfDay is the clock at midnight closing a day
+const EndOfDay Clock = 24 * 60
+
+// ParseClock parses a time of day like 08:30 or 24:00
+func ParseClock(s string) (Clock, error) {
+	hourStr, minuteStr, ok := strings.Cut(s, ":")
+	hour, hourErr := strconv.Atoi(hourStr)
+	minute, minuteErr := strconv.Atoi(minuteStr)
+	if !ok || hourErr != nil || minuteErr != nil || len(minuteStr) != 2 || minute < 0 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", s)
+	}
+	clock := Clock(hour*60 + minute)
+	if hour < 0 || clock > EndOfDay {
+		return 0, fmt.Errorf("invalid time %q, must be between 00:00 and 24:00", s)
+	}
+	return clock, nil
+}
+
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", int(c)/60, int(c)%60)
+}
+
+func (c Clock) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+func (c *Clock) UnmarshalText(text []byte) error {
+	clock, err := ParseClock(string(text))
+	if err != nil {
+		return err
+	}
+	*c = clock
+	return nil
+}
+
+// TimeRange is an opening from Open to Close, a Close not after Open ends on the next day
+type TimeRange struct {
+	Open  Clock `json:"open" swaggertype:"string" example:"08:00"`
+	Close Clock `json:"close" swaggertype:"string" example:"18:00"`
+}
+
+func (r TimeRange) String() string {
+	return r.Open.String() + "-" + r.Close.String()
+}
+
+// Interval is a weekly opening on one day
+type Interval struct {
+	Day Weekday `json:"day" swaggertype:"string" enums:"Mo,Tu,We,Th,Fr,Sa,Su"`
+	TimeRange
+}
+
+// Exception replaces the regular hours on a date given as 2006-01-02, without hours the station is closed
+type Exception struct {
+	Date  string      `json:"date" example:"2026-12-24"`
+	Hours []TimeRange `json:"hours"`
+}
+
+// Schedule are the opening hours of a station. PublicHolidays replaces the weekly
+// hours on public holidays, nil keeps the weekly hours and an empty list closes.
+type Schedule struct {
+	TimeZone       string       `json:"time_zone" example:"Europe/Berlin"`
+	Weekly         []Interval   `json:"weekly"`
+	PublicHolidays *[]TimeRange `json:"public_holidays,omitempty"`
+	Exceptions     []Exception  `json:"exceptions,omitempty"`
+}
+
+// Validate checks the time zone, the times and the exception dates
+func (s *Schedule) Validate() error {
+	if s.TimeZone == "" {
+		s.TimeZone = DefaultTimeZone
+	}
+	if _, err := time.LoadLocation(s.TimeZone); err != nil {
+		return fmt.Errorf("invalid time zone %q", s.TimeZone)
+	}
+	for _, interval := range s.Weekly {
+		if interval.Day < 0 || int(interval.Day) >= len(weekdayNames) {
+			return fmt.Errorf("invalid weekday %d", int(interval.Day))
+		}
+		if err := validateRange(interval.TimeRange); err != nil {
+			return err
+		}
+	}
+	if s.PublicHolidays != nil {
+		for _, r := range *s.PublicHolidays {
+			if err := validateRange(r); err != nil {
+				return err
+			}
+		}
+	}
+	for _, exception := range s.Exceptions {
+		if _, err := time.Parse(dateLayout, exception.Date); err != nil {
+			return fmt.Errorf("invalid exception date %q, expected YYYY-MM-DD", exception.Date)
+		}
+		for _, r := range exception.Hours {
+			if err := validateRange(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateRange(r TimeRange) error {
+	if r.Open < 0 || r.Open >= EndOfDay || r.Close < 0 || r.Close > EndOfDay {
+		return fmt.Errorf("invalid time range %s", r)
+	}
+	if r.Open == r.Close {
+		return fmt.Errorf("empty time range %s", r)
+	}
+	return nil
+}
+
+// IsOpen reports whether the station is open at t
+func (s Schedule) IsOpen(t time.Time) bool {
+	open, _ := s.State(t)
+	return open
+}
+
+// State reports whether the station is open at t and when that changes next.
+// The next change is nil when the state stays the same for the next two weeks.
+func (s Schedule) State(t time.Time) (open bool, nextChange *time.Time) {
+	location, err := time.LoadLocation(s.TimeZone)
+	if err != nil || s.TimeZone == "" {
+		location, _ = time.LoadLocation(DefaultTimeZone)
+	}
+	local := t.In(location)
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+	limit := today.AddDate(0, 0, horizonDays)
+
+	for _, p := range s.periods(today.AddDate(0, 0, -1), horizonDays+2) {
+		if p.end.After(t) && !p.start.After(t) {
+			if !p.end.Before(limit) {
+				return true, nil
+			}
+			return true, &p.end
+		}
+		if p.start.After(t) {
+			if !p.start.Before(limit) {
+				return false, nil
+			}
+			return false, &p.start
+		}
+	}
+	return false, nil
+}
+
+type period struct {
+	start, end time.Time
+}
+
+// periods returns the merged openings of the given number of days starting at the midnight from
+func (s Schedule) periods(from time.Time, days int) []period {
+	var periods []period
+	for i := 0; i < days; i++ {
+		date := from.AddDate(0, 0, i)
+		for _, r := range s.hoursOn(date) {
+			start := atClock(date, r.Open)
+			end := atClock(date, r.Close)
+			if r.Close <= r.Open {
+				end = atClock(date.AddDate(0, 0, 1), r.Close)
+			}
+			periods = append(periods, period{start: start, end: end})
+		}
+	}
+	sort.Slice(periods, func(i, j int) bool { return periods[i].start.Before(periods[j].start) })
+
+	var merged []period
+	for _, p := range periods {
+		if last := len(merged) - 1; last >= 0 && !p.start.After(merged[last].end) {
+			if p.end.After(merged[last].end) {
+				merged[last].end = p.end
+			}
+			continue
+		}
+		merged = append(merged, p)
+	}
+	return merged
+}
+
+// hoursOn returns the openings starting on the date, exceptions take precedence over public holidays
+func (s Schedule) hoursOn(date time.Time) []TimeRange {
+	day := date.Format(dateLayout)
+	for _, exception := range s.Exceptions {
+		if exception.Date == day {
+			return exception.Hours
+		}
+	}
+	if s.PublicHolidays != nil && IsPublicHoliday(date) {
+		return *s.PublicHolidays
+	}
+	var ranges []TimeRange
+	for _, interval := range s.Weekly {
+		if time.Weekday(interval.Day) == date.Weekday() {
+			ranges = append(ranges, interval.TimeRange)
+		}
+	}
+	return ranges
+}
+
+func atClock(date time.Time, clock Clock) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), int(clock)/60, int(clock)%60, 0, 0, date.Location())
+}
@@ -0,0 +1,96 @@
+package hours
+
+import (
+	"testing"
+	"time"
+)
+
+var berlin, _ = time.LoadLocation(DefaultTimeZone)
+
+func at(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, berlin)
+}
+
+func TestScheduleState(t *testing.T) {
+	office, _ := ParseOSM("Mo-Fr 08:00-18:00; PH off; 2026 Oct 16 08:00-12:00")
+	bar, _ := ParseOSM("Fr-Sa 20:00-02:00")
+	always, _ := ParseOSM("24/7")
+	never, _ := ParseOSM("Mo-Su off")
+
+	cases := []struct {
+		name     string
+		schedule Schedule
+		at       time.Time
+		open     bool
+		next     time.Time
+	}{
+		{"open on a weekday", office, at(2026, 10, 14, 10, 0), true, at(2026, 10, 14, 18, 0)},
+		{"opening exactly now", office, at(2026, 10, 14, 8, 0), true, at(2026, 10, 14, 18, 0)},
+		{"closing exactly now", office, at(2026, 10, 14, 18, 0), false, at(2026, 10, 15, 8, 0)},
+		{"closed in the evening", office, at(2026, 10, 14, 20, 0), false, at(2026, 10, 15, 8, 0)},
+		{"shortened by an exception", office, at(2026, 10, 16, 13, 0), false, at(2026, 10, 19, 8, 0)},
+		{"given in another time zone", office, time.Date(2026, 10, 14, 6, 30, 0, 0, time.UTC), true, at(2026, 10, 14, 18, 0)},
+		{"closed over christmas", office, at(2026, 12, 24, 18, 30), false, at(2026, 12, 28, 8, 0)},
+		{"open after midnight", bar, at(2026, 10, 17, 1, 0), true, at(2026, 10, 17, 2, 0)},
+		{"open over midnight", bar, at(2026, 10, 16, 23, 0), true, at(2026, 10, 17, 2, 0)},
+		{"closed before the weekend", bar, at(2026, 10, 15, 23, 0), false, at(2026, 10, 16, 20, 0)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			open, next := tc.schedule.State(tc.at)
+			if open != tc.open || next == nil || !next.Equal(tc.next) {
+				t.Errorf("expected open %v until %v, got open %v until %v", tc.open, tc.next, open, next)
+			}
+			if tc.schedule.IsOpen(tc.at) != tc.open {
+				t.Errorf("expected IsOpen to be %v", tc.open)
+			}
+		})
+	}
+
+	// Across the end of daylight saving time on October 25th
+	if open, next := always.State(at(2026, 10, 24, 12, 0)); !open || next != nil {
+		t.Errorf("expected 24/7 to stay open, got open %v until %v", open, next)
+	}
+	if open, next := never.State(at(2026, 10, 24, 12, 0)); open || next != nil {
+		t.Errorf("expected an always closed station to stay closed, got open %v until %v", open, next)
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	schedule := Schedule{Weekly: weekly([]Weekday{1}, 8*60, 18*60)}
+	if err := schedule.Validate(); err != nil || schedule.TimeZone != DefaultTimeZone {
+		t.Errorf("expected the default time zone, got %q: %v", schedule.TimeZone, err)
+	}
+
+	invalid := []Schedule{
+		{TimeZone: "Mars/Olympus", Weekly: weekly([]Weekday{1}, 8*60, 18*60)},
+		{Weekly: weekly([]Weekday{7}, 8*60, 18*60)},
+		{Weekly: weekly([]Weekday{1}, EndOfDay, 60)},
+		{Weekly: weekly([]Weekday{1}, 60, 60)},
+		{PublicHolidays: &[]TimeRange{{Open: 0, Close: EndOfDay + 1}}},
+		{Exceptions: []Exception{{Date: "24.12.2026"}}},
+	}
+	for _, schedule := range invalid {
+		if err := schedule.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", schedule)
+		}
+	}
+}
+
+func TestIsPublicHoliday(t *testing.T) {
+	holidays := []time.Time{
+		at(2026, 1, 1, 0, 0), at(2026, 4, 3, 0, 0), at(2026, 4, 6, 0, 0), at(2026, 5, 1, 0, 0),
+		at(2026, 5, 14, 0, 0), at(2026, 5, 25, 0, 0), at(2026, 6, 4, 0, 0), at(2026, 10, 3, 0, 0),
+		at(2026, 11, 1, 0, 0), at(2026, 12, 25, 0, 0), at(2026, 12, 26, 0, 0), at(2027, 3, 26, 0, 0),
+	}
+	for _, date := range holidays {
+		if !IsPublicHoliday(date) {
+			t.Errorf("expected %s to be a public holiday", date.Format(dateLayout))
+		}
+	}
+	for _, date := range []time.Time{at(2026, 4, 5, 0, 0), at(2026, 10, 31, 0, 0), at(2026, 12, 24, 0, 0)} {
+		if IsPublicHoliday(date) {
+			t.Errorf("expected %s to be a working day", date.Format(dateLayout))
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package ingest_test
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+)
+
+// testBroker is a minimal MQTT 3.1.1 broker for the tests. It accepts every client,
+// forwards published messages at most once to the matching subscriptions and keeps no
+// sessions. Set MQTT_TEST_BROKER to test against a real broker instead.
+type testBroker struct {
+	listener net.Listener
+	mu       sync.Mutex
+	clients  map[*brokerClient]bool
+}
+
+type brokerClient struct {
+	conn    net.Conn
+	mu      sync.Mutex
+	filters []string
+}
+
+func newTestBroker(t *testing.T) *testBroker {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start test broker: %v", err)
+	}
+	broker := &testBroker{listener: listener, clients: map[*brokerClient]bool{}}
+	go broker.accept()
+	t.Cleanup(broker.close)
+	return broker
+}
+
+// URL returns the broker URL for the MQTT clients
+func (b *testBroker) URL() string {
+	return "tcp://" + b.listener.Addr().String()
+}
+
+func (b *testBroker) close() {
+	b.listener.Close()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for client := range b.clients {
+		client.conn.Close()
+	}
+}
+
+func (b *testBroker) accept() {
+	for {
+		conn, err := b.listener.Accept()
+		if err != nil {
+			return
+		}
+		client := &brokerClient{conn: conn}
+		b.mu.Lock()
+		b.clients[client] = true
+		b.mu.Unlock()
+		go b.serve(client)
+	}
+}
+
+func (b *testBroker) serve(client *brokerClient) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.clients, client)
+		b.mu.Unlock()
+		client.conn.Close()
+	}()
+	for {
+		packet, err := packets.ReadPacket(client.conn)
+		if err != nil {
+			return
+		}
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			client.write(packets.NewControlPacket(packets.Connack))
+		case *packets.SubscribePacket:
+			suback := packets.NewControlPacket(packets.Suback).(*packets.SubackPacket)
+			suback.MessageID = p.MessageID
+			client.mu.Lock()
+			for i, filter := range p.Topics {
+				client.filters = append(client.filters, filter)
+				suback.ReturnCodes = append(suback.ReturnCodes, min(p.Qoss[i], 1))
+			}
+			client.mu.Unlock()
+			client.write(suback)
+		case *packets.PublishPacket:
+			if p.Qos > 0 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = p.MessageID
+				client.write(puback)
+			}
+			b.forward(p)
+		case *packets.PingreqPacket:
+			client.write(packets.NewControlPacket(packets.Pingresp))
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+// forward delivers the message to every client with a matching subscription
+func (b *testBroker) forward(p *packets.PublishPacket) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for client := range b.clients {
+		client.mu.Lock()
+		subscribed := false
+		for _, filter := range client.filters {
+			subscribed = subscribed || topicMatches(filter, p.TopicName)
+		}
+		client.mu.Unlock()
+		if subscribed {
+			message := packets.NewControlPacket(packets.Publish).(*packets.PublishPacket)
+			message.TopicName = p.TopicName
+			message.Payload = p.Payload
+			client.write(message)
+		}
+	}
+}
+
+func (c *brokerClient) write(packet packets.ControlPacket) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	packet.Write(c.conn)
+}
+
+// topicMatches reports whether the topic matches the filter with + and # wildcards
+func topicMatches(filter, topic string) bool {
+	filterLevels, topicLevels := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, level := range filterLevels {
+		if level == "#" {
+			return true
+		}
+		if i >= len(topicLevels) || (level != "+" && level != topicLevels[i]) {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
@@ -0,0 +1,161 @@
+// Package ingest records the water transactions reported by the devices of the
+// smart stations. The HTTP API and the MQTT subscriber share its checks, so a
+// transaction is handled the same way whichever channel it arrives on.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+// Limits of the reported transactions
+const (
+	MaxIdempotencyKeyLength = 64
+	// MaxTransactionAge is how long a station may buffer a transaction
+	MaxTransactionAge = 30 * 24 * time.Hour
+	// MaxClockSkew is how far the clock of a station may run ahead
+	MaxClockSkew = 5 * time.Minute
+)
+
+var (
+	// ErrOtherStation is returned for a transaction of another station than the one of the device
+	ErrOtherStation = errors.New("device belongs to another refill station")
+	// ErrKeyReused is returned when the station used the idempotency key for another transaction
+	ErrKeyReused = errors.New("idempotency key was already used for another transaction")
+)
+
+// InvalidError describes why a buffered transaction is rejected
+type InvalidError struct {
+	Reason string
+}
+
+func (e *InvalidError) Error() string {
+	return e.Reason
+}
+
+func invalid(format string, args ...any) error {
+	return &InvalidError{Reason: fmt.Sprintf(format, args...)}
+}
+
+// Status maps the outcome of recording a transaction onto an HTTP status code, which
+// is reported for every transaction of a batch upload or an MQTT fill message
+func Status(created bool, err error) int {
+	var invalidErr *InvalidError
+	switch {
+	case err == nil && created:
+		return http.StatusCreated
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrKeyReused):
+		return http.StatusConflict
+	case errors.Is(err, ErrOtherStation), errors.As(err, &invalidErr):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+// Service records the transactions of the station devices
+type Service struct {
+	bottles      repository.BottleRepository
+	transactions repository.WaterTransactionRepository
+}
+
+func NewService(repos repository.Repositories) *Service {
+	return &Service{bottles: repos.Bottles, transactions: repos.Transactions}
+}
+
+// Record creates a transaction the device reports right after the filling, at the current
+// time. The station defaults to the one of the device. If the station reported a transaction
+// with the same idempotency key before, that one is returned and created is false.
+func (s *Service) Record(ctx context.Context, device *database.Device, transaction *database.WaterTransaction) (*database.WaterTransaction, bool, error) {
+	transaction.ID = 0
+	if transaction.StationID == 0 {
+		transaction.StationID = device.StationID
+	}
+	if transaction.StationID != device.StationID {
+		return nil, false, ErrOtherStation
+	}
+	transaction.Timestamp = time.Now()
+	return s.createOnce(ctx, transaction)
+}
+
+// RecordBuffered creates a transaction the device of the station buffered while it was
+// offline, with its original timestamp. It needs an idempotency key, so uploads can be
+// retried, and is checked more strictly than live transactions: the station has to offer
+// the water type, the bottle has to exist and belong to the user, and the timestamp has
+// to be at most MaxTransactionAge old at now.
+func (s *Service) RecordBuffered(ctx context.Context, station *database.RefillStation, transaction *database.WaterTransaction, now time.Time) (*database.WaterTransaction, bool, error) {
+	transaction.ID = 0
+	if transaction.StationID == 0 {
+		transaction.StationID = station.ID
+	}
+	if transaction.StationID != station.ID {
+		return nil, false, ErrOtherStation
+	}
+	if err := s.validateBuffered(ctx, station, transaction, now); err != nil {
+		return nil, false, err
+	}
+	return s.createOnce(ctx, transaction)
+}
+
+// createOnce creates the transaction unless the station has one with its idempotency key,
+// which has to report the same filling
+func (s *Service) createOnce(ctx context.Context, transaction *database.WaterTransaction) (*database.WaterTransaction, bool, error) {
+	stored, created, err := s.transactions.CreateOnce(ctx, transaction)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created && !stored.SamePayload(transaction) {
+		return nil, false, ErrKeyReused
+	}
+	return stored, created, nil
+}
+
+// validateBuffered checks a buffered transaction and normalizes its water type
+// and user, which defaults to the owner of the bottle
+func (s *Service) validateBuffered(ctx context.Context, station *database.RefillStation, transaction *database.WaterTransaction, now time.Time) error {
+	if transaction.IdempotencyKey == nil || *transaction.IdempotencyKey == "" || len(*transaction.IdempotencyKey) > MaxIdempotencyKeyLength {
+		return invalid("idempotency_key must have 1 to %d characters", MaxIdempotencyKeyLength)
+	}
+	if err := transaction.Validate(); err != nil {
+		return invalid("%v", err)
+	}
+	if !station.Offers(transaction.WaterType) {
+		return invalid("refill station does not offer %s water", transaction.WaterType)
+	}
+	if transaction.Volume <= 0 {
+		return invalid("volume must be positive")
+	}
+	switch {
+	case transaction.Timestamp.IsZero():
+		return invalid("timestamp is required")
+	case transaction.Timestamp.After(now.Add(MaxClockSkew)):
+		return invalid("timestamp is in the future")
+	case transaction.Timestamp.Before(now.Add(-MaxTransactionAge)):
+		return invalid("timestamp is older than %d days", int(MaxTransactionAge.Hours()/24))
+	}
+
+	if transaction.BottleID == nil {
+		return nil
+	}
+	bottle, err := s.bottles.Get(ctx, *transaction.BottleID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return invalid("bottle %d does not exist", *transaction.BottleID)
+	}
+	if err != nil {
+		return err
+	}
+	if transaction.UserID == nil {
+		transaction.UserID = &bottle.UserID
+	}
+	if *transaction.UserID != bottle.UserID {
+		return invalid("bottle %d does not belong to user %d", bottle.ID, *transaction.UserID)
+	}
+	return nil
+}
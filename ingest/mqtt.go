@@ -0,0 +1,228 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// The devices of the smart stations publish below the topic prefix, e.g. poseidon:
+//
+//	<prefix>/stations/<station ID>/devices/<device ID>/fills      a filling, see Subscriber
+//	<prefix>/stations/<station ID>/devices/<device ID>/telemetry  telemetry, see TelemetryHandler
+//	<prefix>/stations/<station ID>/devices/<device ID>/acks       the result of every filling, see Ack
+//
+// The broker authenticates the devices and enforces the topic ACL. A device logs in with
+// its device ID as user name, may publish to its fills and telemetry topics and subscribe
+// to its acks topic, e.g. in a mosquitto ACL file:
+//
+//	pattern write poseidon/stations/+/devices/%u/fills
+//	pattern write poseidon/stations/+/devices/%u/telemetry
+//	pattern read poseidon/stations/+/devices/%u/acks
+//
+// The backend reads all fills and telemetry topics and writes all acks topics. The ACL does
+// not cover the station ID, so the subscriber checks it against the station of the device
+// and drops messages of unknown or revoked devices.
+const (
+	fillsTopic     = "fills"
+	telemetryTopic = "telemetry"
+	acksTopic      = "acks"
+)
+
+// MQTT quality of service levels
+const (
+	atMostOnce  byte = 0
+	atLeastOnce byte = 1
+)
+
+// SubscriberOptions configure the connection to the MQTT broker
+type SubscriberOptions struct {
+	// Broker is the URL of the broker, e.g. tcp://localhost:1883 or ssl://broker:8883
+	Broker      string
+	ClientID    string
+	Username    string
+	Password    string
+	TopicPrefix string
+}
+
+// Ack is published on the acks topic of the device for every filling it published.
+// Status is the HTTP status code of the outcome, see Status, and Error describes failures.
+type Ack struct {
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Status         int    `json:"status"`
+	TransactionID  uint   `json:"transaction_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// TelemetryHandler processes a telemetry message of a device
+type TelemetryHandler func(ctx context.Context, device *database.Device, payload []byte) error
+
+// Subscriber receives the fillings and telemetry the station devices publish over MQTT.
+// A filling is a JSON water transaction with its timestamp and an idempotency key and is
+// recorded like a buffered transaction, see Service.RecordBuffered, since the broker may
+// deliver it late or more than once.
+type Subscriber struct {
+	options   SubscriberOptions
+	service   *Service
+	devices   repository.DeviceRepository
+	stations  repository.RefillStationRepository
+	telemetry TelemetryHandler
+	client    mqtt.Client
+	ctx       context.Context
+}
+
+func NewSubscriber(options SubscriberOptions, service *Service, repos repository.Repositories) *Subscriber {
+	return &Subscriber{options: options, service: service, devices: repos.Devices, stations: repos.Stations}
+}
+
+// HandleTelemetry subscribes the telemetry topics with the handler, it has to be called before Start
+func (s *Subscriber) HandleTelemetry(handler TelemetryHandler) {
+	s.telemetry = handler
+}
+
+// Start connects to the broker and subscribes to the topics of all devices, again after
+// every reconnect in case the broker lost the session. Messages are handled with the
+// context until Stop is called.
+func (s *Subscriber) Start(ctx context.Context) error {
+	s.ctx = ctx
+	options := mqtt.NewClientOptions().
+		AddBroker(s.options.Broker).
+		SetClientID(s.options.ClientID).
+		SetUsername(s.options.Username).
+		SetPassword(s.options.Password).
+		SetCleanSession(false).
+		SetAutoReconnect(true).
+		SetOrderMatters(false).
+		SetOnConnectHandler(func(client mqtt.Client) {
+			if err := s.subscribe(client); err != nil {
+				slog.Error("MQTT subscription failed", "error", err)
+			}
+		}).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			slog.Warn("MQTT connection lost", "error", err)
+		})
+	s.client = mqtt.NewClient(options)
+	token := s.client.Connect()
+	if !token.WaitTimeout(30 * time.Second) {
+		return fmt.Errorf("connecting to MQTT broker %s timed out", s.options.Broker)
+	}
+	if err := token.Error(); err != nil {
+		return err
+	}
+	// The connect handler subscribes concurrently, Start returns once the topics are subscribed
+	return s.subscribe(s.client)
+}
+
+// Stop disconnects from the broker after the messages in flight are handled
+func (s *Subscriber) Stop() {
+	if s.client != nil {
+		s.client.Disconnect(1000)
+	}
+}
+
+func (s *Subscriber) subscribe(client mqtt.Client) error {
+	filters := map[string]byte{s.deviceTopic("+", "+", fillsTopic): atLeastOnce}
+	if s.telemetry != nil {
+		filters[s.deviceTopic("+", "+", telemetryTopic)] = atMostOnce
+	}
+	token := client.SubscribeMultiple(filters, s.handleMessage)
+	token.Wait()
+	return token.Error()
+}
+
+// deviceTopic returns the topic of the device with the given suffix
+func (s *Subscriber) deviceTopic(stationID, deviceID, suffix string) string {
+	return strings.Join([]string{s.options.TopicPrefix, "stations", stationID, "devices", deviceID, suffix}, "/")
+}
+
+func (s *Subscriber) handleMessage(_ mqtt.Client, message mqtt.Message) {
+	ctx := s.ctx
+	stationID, deviceID, suffix, err := s.parseTopic(message.Topic())
+	if err != nil {
+		slog.Warn("Dropped MQTT message", "topic", message.Topic(), "error", err)
+		return
+	}
+	device, err := s.devices.Get(ctx, deviceID)
+	if err == nil && (device.RevokedAt != nil || device.StationID != stationID) {
+		err = errors.New("device is revoked or belongs to another station")
+	}
+	if err != nil {
+		slog.Warn("Dropped MQTT message", "topic", message.Topic(), "error", err)
+		return
+	}
+
+	switch suffix {
+	case fillsTopic:
+		s.publishAck(device, s.recordFill(ctx, device, message.Payload()))
+	case telemetryTopic:
+		if s.telemetry == nil {
+			return
+		}
+		if err := s.telemetry(ctx, device, message.Payload()); err != nil {
+			slog.Warn("Dropped MQTT telemetry", "topic", message.Topic(), "error", err)
+		}
+	}
+}
+
+// parseTopic returns the station and device ID of a topic and its suffix
+func (s *Subscriber) parseTopic(topic string) (stationID, deviceID uint, suffix string, err error) {
+	parts := strings.Split(strings.TrimPrefix(topic, s.options.TopicPrefix+"/"), "/")
+	if len(parts) != 5 || parts[0] != "stations" || parts[2] != "devices" {
+		return 0, 0, "", errors.New("unknown topic")
+	}
+	station, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, "", errors.New("invalid station ID")
+	}
+	device, err := strconv.ParseUint(parts[3], 10, 64)
+	if err != nil {
+		return 0, 0, "", errors.New("invalid device ID")
+	}
+	return uint(station), uint(device), parts[4], nil
+}
+
+// recordFill records the filling of a fills message
+func (s *Subscriber) recordFill(ctx context.Context, device *database.Device, payload []byte) Ack {
+	var transaction database.WaterTransaction
+	if err := json.Unmarshal(payload, &transaction); err != nil {
+		return failedAck(&transaction, invalid("invalid JSON: %v", err))
+	}
+	station, err := s.stations.Get(ctx, device.StationID)
+	if err != nil {
+		return failedAck(&transaction, err)
+	}
+	stored, created, err := s.service.RecordBuffered(ctx, station, &transaction, time.Now())
+	if err != nil {
+		return failedAck(&transaction, err)
+	}
+	return Ack{IdempotencyKey: *stored.IdempotencyKey, Status: Status(created, nil), TransactionID: stored.ID}
+}
+
+// failedAck reports why the transaction was not recorded
+func failedAck(transaction *database.WaterTransaction, err error) Ack {
+	ack := Ack{Status: Status(false, err), Error: err.Error()}
+	if transaction.IdempotencyKey != nil {
+		ack.IdempotencyKey = *transaction.IdempotencyKey
+	}
+	return ack
+}
+
+func (s *Subscriber) publishAck(device *database.Device, ack Ack) {
+	payload, err := json.Marshal(ack)
+	if err != nil {
+		slog.Error("Failed to encode MQTT ack", "error", err)
+		return
+	}
+	topic := s.deviceTopic(strconv.FormatUint(uint64(device.StationID), 10), strconv.FormatUint(uint64(device.ID), 10), acksTopic)
+	// Waiting for the token here would block the handler until the broker confirmed
+	s.client.Publish(topic, atLeastOnce, false, payload)
+}
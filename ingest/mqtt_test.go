@@ -0,0 +1,161 @@
+package ingest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/ingest"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const topicPrefix = "poseidon-test"
+
+// seededRepositories creates a smart station offering tap water with a device and a bottle
+func seededRepositories(t *testing.T) (repository.Repositories, *database.Device, *database.Bottle) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	user := database.User{FirstName: "Test", LastName: "User"}
+	station := database.RefillStation{Name: "Test", Latitude: 49.44, Longitude: 7.77, Type: "smart", OfferedWaterTypes: "tap", WaterSource: "test"}
+	device := database.Device{Name: "controller"}
+	must(t, repos.Users.Create(ctx, &user))
+	must(t, repos.Stations.Create(ctx, &station))
+	bottle := database.Bottle{UserID: user.ID, Title: "Bottle", FillVolume: 500, WaterType: "tap", NFCID: "04:00:00:00"}
+	must(t, repos.Bottles.Create(ctx, &bottle))
+	device.StationID = station.ID
+	must(t, repos.Devices.Create(ctx, &device, &database.DeviceKey{KeyHash: "hash", Prefix: "pdk_test"}))
+	return repos, &device, &bottle
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// brokerURL returns MQTT_TEST_BROKER or the URL of a new test broker
+func brokerURL(t *testing.T) string {
+	if url := os.Getenv("MQTT_TEST_BROKER"); url != "" {
+		return url
+	}
+	return newTestBroker(t).URL()
+}
+
+// connectDevice connects a client publishing as the device and returns the acks it receives
+func connectDevice(t *testing.T, broker string, device *database.Device) (mqtt.Client, <-chan ingest.Ack) {
+	t.Helper()
+	acks := make(chan ingest.Ack, 10)
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker(broker).SetClientID(fmt.Sprintf("device-%d", device.ID)))
+	if token := client.Connect(); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to connect the device: %v", token.Error())
+	}
+	t.Cleanup(func() { client.Disconnect(100) })
+	topic := fmt.Sprintf("%s/stations/%d/devices/%d/acks", topicPrefix, device.StationID, device.ID)
+	token := client.Subscribe(topic, 1, func(_ mqtt.Client, message mqtt.Message) {
+		var ack ingest.Ack
+		if err := json.Unmarshal(message.Payload(), &ack); err != nil {
+			t.Errorf("invalid ack %q: %v", message.Payload(), err)
+		}
+		acks <- ack
+	})
+	if !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to subscribe to the acks: %v", token.Error())
+	}
+	return client, acks
+}
+
+func publish(t *testing.T, client mqtt.Client, topic string, payload interface{}) {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token := client.Publish(topic, 1, false, data); !token.WaitTimeout(5*time.Second) || token.Error() != nil {
+		t.Fatalf("failed to publish to %s: %v", topic, token.Error())
+	}
+}
+
+func receiveAck(t *testing.T, acks <-chan ingest.Ack) ingest.Ack {
+	t.Helper()
+	select {
+	case ack := <-acks:
+		return ack
+	case <-time.After(5 * time.Second):
+		t.Fatal("no ack received")
+		return ingest.Ack{}
+	}
+}
+
+func TestSubscriberRecordsFills(t *testing.T) {
+	repos, device, bottle := seededRepositories(t)
+	broker := brokerURL(t)
+	telemetry := make(chan string, 1)
+	subscriber := ingest.NewSubscriber(ingest.SubscriberOptions{Broker: broker, ClientID: "backend-test", TopicPrefix: topicPrefix}, ingest.NewService(repos), repos)
+	subscriber.HandleTelemetry(func(ctx context.Context, reporter *database.Device, payload []byte) error {
+		if reporter.ID != device.ID {
+			t.Errorf("expected telemetry of device %d, got %d", device.ID, reporter.ID)
+		}
+		telemetry <- string(payload)
+		return nil
+	})
+	must(t, subscriber.Start(context.Background()))
+	defer subscriber.Stop()
+
+	client, acks := connectDevice(t, broker, device)
+	fills := fmt.Sprintf("%s/stations/%d/devices/%d/fills", topicPrefix, device.StationID, device.ID)
+	filledAt := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	fill := map[string]interface{}{"idempotency_key": "fill-1", "bottle_id": bottle.ID, "volume": 400, "water_type": "tap", "timestamp": filledAt}
+
+	publish(t, client, fills, fill)
+	created := receiveAck(t, acks)
+	if created.Status != http.StatusCreated || created.IdempotencyKey != "fill-1" || created.TransactionID == 0 {
+		t.Fatalf("expected the fill to be created, got %+v", created)
+	}
+	transaction, err := repos.Transactions.Get(context.Background(), created.TransactionID)
+	must(t, err)
+	if transaction.StationID != device.StationID || transaction.UserID == nil || *transaction.UserID != bottle.UserID || !transaction.Timestamp.Equal(filledAt) {
+		t.Errorf("unexpected transaction %+v", transaction)
+	}
+
+	// The broker delivers at least once, a redelivery is acknowledged without a new transaction
+	publish(t, client, fills, fill)
+	if replay := receiveAck(t, acks); replay.Status != http.StatusOK || replay.TransactionID != created.TransactionID {
+		t.Errorf("expected the redelivery to be recognized, got %+v", replay)
+	}
+
+	publish(t, client, fills, map[string]interface{}{"idempotency_key": "fill-2", "volume": 400, "water_type": "mineral", "timestamp": filledAt})
+	if rejected := receiveAck(t, acks); rejected.Status != http.StatusUnprocessableEntity || rejected.IdempotencyKey != "fill-2" || rejected.Error == "" {
+		t.Errorf("expected mineral water to be rejected, got %+v", rejected)
+	}
+
+	// Messages on the topics of another station are dropped
+	publish(t, client, fmt.Sprintf("%s/stations/%d/devices/%d/fills", topicPrefix, device.StationID+1, device.ID),
+		map[string]interface{}{"idempotency_key": "spoofed", "volume": 400, "water_type": "tap", "timestamp": filledAt, "guest": true})
+
+	publish(t, client, fmt.Sprintf("%s/stations/%d/devices/%d/telemetry", topicPrefix, device.StationID, device.ID), map[string]int{"temperature": 9})
+	select {
+	case payload := <-telemetry:
+		if payload != `{"temperature":9}` {
+			t.Errorf("unexpected telemetry %s", payload)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no telemetry received")
+	}
+
+	select {
+	case ack := <-acks:
+		t.Errorf("expected no ack for the other station, got %+v", ack)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if _, total, _ := repos.Transactions.List(context.Background(), repository.ListQuery{}); total != 1 {
+		t.Errorf("expected a single transaction, got %d", total)
+	}
+}
@@ -0,0 +1,101 @@
+package media
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+// legacyColumns are the TEXT columns that held base64 encoded images before the blob store
+var legacyColumns = []struct{ table, column string }{
+	{"bottle", "bottle_image"},
+	{"refill_stations", "refill_station_image"},
+	{"refill_station_problem", "refill_station_problem_image"},
+}
+
+// legacyBatchSize is the number of rows loaded at once, each holding a whole image
+const legacyBatchSize = 20
+
+// errInvalidBase64 reports a legacy value that cannot be decoded, it stays in its column
+var errInvalidBase64 = errors.New("invalid base64")
+
+// MoveLegacyImages moves the base64 images of the legacy columns into the store, sets
+// the image_id of their rows and clears the columns. Every row is updated on its own
+// after its image is saved, so an interrupted or failed move continues where it stopped
+// on the next run. The files are imported without the upload limits, see Images.Import.
+// Values that are no valid base64 are kept and logged. Moved images are not copied back,
+// the down migration of 0007_images refuses to run while rows reference one. It returns
+// the number of moved images and of kept values.
+func MoveLegacyImages(ctx context.Context, db *gorm.DB, images *Images) (moved, kept int, err error) {
+	for _, legacy := range legacyColumns {
+		if !db.Migrator().HasColumn(legacy.table, legacy.column) {
+			continue
+		}
+		// Kept values stay in the column, the rows are paged by their ID
+		var lastID uint
+		for {
+			var rows []struct {
+				ID   uint
+				Data string
+			}
+			err := db.WithContext(ctx).Table(legacy.table).
+				Select("id, "+legacy.column+" AS data").
+				Where(legacy.column+" IS NOT NULL AND id > ?", lastID).
+				Order("id").Limit(legacyBatchSize).Scan(&rows).Error
+			if err != nil {
+				return moved, kept, err
+			}
+			if len(rows) == 0 {
+				break
+			}
+
+			for _, row := range rows {
+				lastID = row.ID
+				image, err := saveLegacyImage(ctx, images, row.Data)
+				if errors.Is(err, errInvalidBase64) {
+					slog.Warn("keeping legacy image that is no valid base64", "table", legacy.table, "id", row.ID)
+					kept++
+					continue
+				}
+				if err != nil {
+					return moved, kept, fmt.Errorf("failed to move image of %s %d: %w", legacy.table, row.ID, err)
+				}
+
+				changes := map[string]any{legacy.column: nil}
+				if image != nil {
+					changes["image_id"] = *image
+				}
+				if err := db.WithContext(ctx).Table(legacy.table).Where("id = ?", row.ID).Updates(changes).Error; err != nil {
+					if image != nil {
+						images.Delete(ctx, *image)
+					}
+					return moved, kept, fmt.Errorf("failed to move image of %s %d: %w", legacy.table, row.ID, err)
+				}
+				if image != nil {
+					moved++
+				}
+			}
+		}
+	}
+	return moved, kept, nil
+}
+
+// saveLegacyImage decodes and imports a base64 image and returns its ID, nil for an empty value
+func saveLegacyImage(ctx context.Context, images *Images, encoded string) (*uint, error) {
+	if encoded == "" {
+		return nil, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errInvalidBase64
+	}
+	image, err := images.Import(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	return &image.ID, nil
+}
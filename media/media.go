@@ -0,0 +1,201 @@
+// Package media keeps image files in a blob store and their metadata in the database
+package media
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/PoseidonPSE2/code_backend/blob"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+// MaxImageSize is the largest image file accepted in bytes
+const MaxImageSize = 10 << 20
+
+// ContentTypes are the accepted image formats, detected from the file content
+var ContentTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+var (
+	ErrNotAnImage      = errors.New("file is not a JPEG, PNG, GIF or WebP image")
+	ErrImageTooLarge   = fmt.Errorf("image exceeds %d MiB", MaxImageSize>>20)
+	ErrImageDimensions = fmt.Errorf("image exceeds %d megapixels, counting every frame", MaxImagePixels/1_000_000)
+	ErrTooManyFrames   = fmt.Errorf("animation exceeds %d frames", MaxGIFFrames)
+)
+
+// Images stores image files under random keys and records their metadata
+type Images struct {
+	store  blob.Store
+	images repository.ImageRepository
+}
+
+func NewImages(store blob.Store, images repository.ImageRepository) *Images {
+	return &Images{store: store, images: images}
+}
+
+// Save checks that the data is an image within the size limits, strips its metadata
+// and stores it together with its variants
+func (m *Images) Save(ctx context.Context, data []byte) (*database.Image, error) {
+	original, err := process(data)
+	if err != nil {
+		return nil, err
+	}
+	image, err := m.put(ctx, original, nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, variant := range Variants {
+		if max(original.width, original.height) <= variant.Size {
+			continue
+		}
+		scaled, err := scale(original.decoded, variant.Size)
+		if err == nil {
+			_, err = m.put(ctx, scaled, &image.ID, variant.Name)
+		}
+		if err != nil {
+			m.Delete(ctx, image.ID)
+			return nil, fmt.Errorf("failed to create %s variant: %w", variant.Name, err)
+		}
+	}
+	return image, nil
+}
+
+// Import stores a file saved before the upload checks existed. Files the checks accept
+// are saved like uploads, all others are stored as they are without variants, so that
+// nothing is lost: files beyond the limits as well as other formats, which are served
+// as application/octet-stream.
+func (m *Images) Import(ctx context.Context, data []byte) (*database.Image, error) {
+	image, err := m.Save(ctx, data)
+	if !errors.Is(err, ErrNotAnImage) && !errors.Is(err, ErrImageTooLarge) && !errors.Is(err, ErrImageDimensions) && !errors.Is(err, ErrTooManyFrames) {
+		return image, err
+	}
+	return m.put(ctx, unprocessed(data), nil, "")
+}
+
+// put stores a file under a new key and creates its metadata
+func (m *Images) put(ctx context.Context, file *processed, originalID *uint, variant string) (*database.Image, error) {
+	key, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	checksum := sha256.Sum256(file.data)
+
+	if err := m.store.Put(ctx, key, bytes.NewReader(file.data), int64(len(file.data)), file.contentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+	image := &database.Image{
+		Key:         key,
+		ContentType: file.contentType,
+		Size:        int64(len(file.data)),
+		Checksum:    hex.EncodeToString(checksum[:]),
+		Width:       file.width,
+		Height:      file.height,
+		OriginalID:  originalID,
+		Variant:     variant,
+	}
+	if err := m.images.Create(ctx, image); err != nil {
+		m.store.Delete(ctx, key)
+		return nil, err
+	}
+	return image, nil
+}
+
+// detectContentType returns the accepted image type of the data, empty for other files
+func detectContentType(data []byte) string {
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(ContentTypes, contentType) {
+		return ""
+	}
+	return contentType
+}
+
+// Open returns the metadata and the file of an image, the caller closes the file
+func (m *Images) Open(ctx context.Context, id uint) (*database.Image, io.ReadCloser, error) {
+	image, err := m.images.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	file, err := m.OpenFile(ctx, image)
+	if err != nil {
+		return nil, nil, err
+	}
+	return image, file, nil
+}
+
+// Variant returns the metadata of the named variant of an image. The original is
+// returned for an empty name and if the image is too small for the variant or was
+// stored before variants existed.
+func (m *Images) Variant(ctx context.Context, id uint, name string) (*database.Image, error) {
+	original, err := m.images.Get(ctx, id)
+	if err != nil || name == "" {
+		return original, err
+	}
+	variants, err := m.images.Variants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for _, variant := range variants {
+		if variant.Variant == name {
+			return &variant, nil
+		}
+	}
+	return original, nil
+}
+
+// OpenFile returns the file of an image, the caller closes it
+func (m *Images) OpenFile(ctx context.Context, image *database.Image) (io.ReadCloser, error) {
+	return m.store.Get(ctx, image.Key)
+}
+
+// Read returns the content of an image
+func (m *Images) Read(ctx context.Context, id uint) ([]byte, error) {
+	_, file, err := m.Open(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// Delete removes the variants and then the metadata and the file of an image
+func (m *Images) Delete(ctx context.Context, id uint) error {
+	image, err := m.images.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	variants, err := m.images.Variants(ctx, id)
+	if err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if err := m.images.Delete(ctx, variant.ID); err != nil {
+			return err
+		}
+		if err := m.store.Delete(ctx, variant.Key); err != nil {
+			return err
+		}
+	}
+	if err := m.images.Delete(ctx, id); err != nil {
+		return err
+	}
+	return m.store.Delete(ctx, image.Key)
+}
+
+// newKey returns a random key below images/, spread over directories by its first byte
+func newKey() (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(random)
+	return "images/" + name[:2] + "/" + name, nil
+}
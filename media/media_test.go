@@ -0,0 +1,368 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/PoseidonPSE2/code_backend/blob"
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/media"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+// testImage returns a gradient of the given size
+func testImage(width, height int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+// pngHeader returns the start of a PNG file of the given size, enough to read its dimensions
+func pngHeader(width, height int) []byte {
+	ihdr := binary.BigEndian.AppendUint32([]byte("IHDR"), uint32(width))
+	ihdr = binary.BigEndian.AppendUint32(ihdr, uint32(height))
+	ihdr = append(ihdr, 8, 0, 0, 0, 0)
+	file := binary.BigEndian.AppendUint32([]byte("\x89PNG\r\n\x1a\n"), uint32(len(ihdr)-4))
+	file = append(file, ihdr...)
+	return binary.BigEndian.AppendUint32(file, crc32.ChecksumIEEE(ihdr))
+}
+
+// encodeGIF returns an animation of frames single pixel frames on a canvas of the given size
+func encodeGIF(t *testing.T, width, height, frames int) []byte {
+	t.Helper()
+	animation := &gif.GIF{Config: image.Config{Width: width, Height: height, ColorModel: color.Palette(palette.Plan9)}}
+	for i := 0; i < frames; i++ {
+		animation.Image = append(animation.Image, image.NewPaletted(image.Rect(0, 0, 1, 1), palette.Plan9))
+		animation.Delay = append(animation.Delay, 10)
+	}
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, animation); err != nil {
+		t.Fatal(err)
+	}
+	return out.Bytes()
+}
+
+func newImages() (*media.Images, *blob.MemoryStore, repository.ImageRepository) {
+	store := blob.NewMemoryStore()
+	repo := repository.NewMemoryRepositories().Images
+	return media.NewImages(store, repo), store, repo
+}
+
+func TestImages(t *testing.T) {
+	ctx := context.Background()
+	images, store, repo := newImages()
+
+	file := encodePNG(t, testImage(400, 200))
+	image, err := images.Save(ctx, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.ContentType != "image/png" || image.Width != 400 || image.Height != 200 || len(image.Checksum) != 64 {
+		t.Errorf("unexpected metadata %+v", image)
+	}
+	data, err := images.Read(ctx, image.ID)
+	if err != nil || !bytes.Equal(data, file) {
+		t.Fatalf("expected to read the saved image, got %d bytes, %v", len(data), err)
+	}
+
+	variants, err := repo.Variants(ctx, image.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sizes := map[string][2]int{}
+	for _, variant := range variants {
+		sizes[variant.Variant] = [2]int{variant.Width, variant.Height}
+		if variant.ContentType != "image/jpeg" {
+			t.Errorf("expected opaque variants as JPEG, got %s", variant.ContentType)
+		}
+	}
+	if sizes["thumb"] != [2]int{320, 160} || sizes["marker"] != [2]int{96, 48} || len(sizes) != 2 {
+		t.Errorf("unexpected variants %v", sizes)
+	}
+
+	if err := images.Delete(ctx, image.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := images.Read(ctx, image.ID); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("expected deleted image to be gone, got %v", err)
+	}
+	if store.Len() != 0 {
+		t.Errorf("expected the files to be deleted, %d left", store.Len())
+	}
+}
+
+func TestImagesSkipsLargerVariants(t *testing.T) {
+	ctx := context.Background()
+	images, _, repo := newImages()
+
+	image, err := images.Save(ctx, encodePNG(t, testImage(200, 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants, _ := repo.Variants(ctx, image.ID)
+	if len(variants) != 1 || variants[0].Variant != "marker" {
+		t.Errorf("expected only the marker variant, got %+v", variants)
+	}
+}
+
+func TestImagesRejectsInvalidFiles(t *testing.T) {
+	ctx := context.Background()
+	images, store, _ := newImages()
+
+	file := encodePNG(t, testImage(10, 10))
+	tooLarge := append(bytes.Clone(file), make([]byte, media.MaxImageSize)...)
+	cases := map[string]struct {
+		data []byte
+		want error
+	}{
+		"text":                   {[]byte("just some text"), media.ErrNotAnImage},
+		"truncated":              {file[:len(file)/2], media.ErrNotAnImage},
+		"too large":              {tooLarge, media.ErrImageTooLarge},
+		"too many pixels":        {pngHeader(8000, media.MaxImagePixels/8000+1), media.ErrImageDimensions},
+		"huge and narrow":        {pngHeader(media.MaxImagePixels+1, 1), media.ErrImageDimensions},
+		"too many frames":        {encodeGIF(t, 1, 1, media.MaxGIFFrames+1), media.ErrTooManyFrames},
+		"frames over the budget": {encodeGIF(t, 2000, 2000, media.MaxImagePixels/(2000*2000)+1), media.ErrImageDimensions},
+		"truncated animation":    {encodeGIF(t, 10, 10, 3)[:40], media.ErrNotAnImage},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := images.Save(ctx, tc.data); !errors.Is(err, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+	if store.Len() != 0 {
+		t.Errorf("expected nothing to be stored, got %d files", store.Len())
+	}
+}
+
+func TestImagesAcceptsAnimations(t *testing.T) {
+	ctx := context.Background()
+	images, _, _ := newImages()
+
+	image, err := images.Save(ctx, encodeGIF(t, 100, 100, media.MaxGIFFrames))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := images.Read(ctx, image.ID)
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil || len(animation.Image) != media.MaxGIFFrames {
+		t.Errorf("expected the animation to keep its frames, got %v", err)
+	}
+}
+
+func TestImagesStripMetadata(t *testing.T) {
+	ctx := context.Background()
+	images, _, _ := newImages()
+
+	t.Run("jpeg exif and comment", func(t *testing.T) {
+		// EXIF with orientation 6, the photo is stored rotated by 90°
+		tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01\x00\x06\x00\x00\x00\x00\x00\x00")
+		exif := append([]byte("Exif\x00\x00"), tiff...)
+		app1 := binary.BigEndian.AppendUint16([]byte{0xFF, 0xE1}, uint16(len(exif)+2))
+		app1 = append(app1, exif...)
+		comment := append([]byte{0xFF, 0xFE, 0x00, 0x0A}, "GPS 49.4"...)
+
+		file := encodeJPEG(t, testImage(40, 20))
+		file = append(append(append([]byte{0xFF, 0xD8}, app1...), comment...), file[2:]...)
+
+		image, err := images.Save(ctx, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := images.Read(ctx, image.ID)
+		if bytes.Contains(data, []byte("Exif")) || bytes.Contains(data, []byte("GPS")) {
+			t.Error("expected EXIF and comment to be removed")
+		}
+		if image.Width != 20 || image.Height != 40 {
+			t.Errorf("expected the photo to be turned upright, got %dx%d", image.Width, image.Height)
+		}
+	})
+
+	t.Run("png text", func(t *testing.T) {
+		text := []byte("tEXtAuthor\x00Erika Mustermann")
+		chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)-4))
+		chunk = append(chunk, text...)
+		chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(text))
+
+		file := encodePNG(t, testImage(20, 20))
+		// The text follows the signature and the IHDR chunk
+		file = append(append(bytes.Clone(file[:33]), chunk...), file[33:]...)
+
+		image, err := images.Save(ctx, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := images.Read(ctx, image.ID)
+		if bytes.Contains(data, []byte("Mustermann")) {
+			t.Error("expected the text chunk to be removed")
+		}
+		if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+			t.Errorf("expected a valid PNG, got %v", err)
+		}
+	})
+}
+
+// failingStore fails to store files while failing is set
+type failingStore struct {
+	*blob.MemoryStore
+	failing bool
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, content io.Reader, size int64, contentType string) error {
+	if s.failing {
+		return errors.New("storage unavailable")
+	}
+	return s.MemoryStore.Put(ctx, key, content, size, contentType)
+}
+
+func TestMoveLegacyImages(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.ConnectDatabase("sqlite::memory:", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+
+	file := encodePNG(t, testImage(20, 20))
+	encoded := base64.StdEncoding.EncodeToString(file)
+	// Files beyond the upload limits and of other formats are moved as they are
+	wide := pngHeader(media.MaxImagePixels+1, 1)
+	text := []byte("no image at all")
+	statements := []string{
+		`INSERT INTO users (id, first_name, last_name) VALUES (1, 'Erika', 'Mustermann')`,
+		`INSERT INTO bottle (id, user_id, fill_volume, water_type, title, bottle_image) VALUES (1, 1, 500, 'mineral', 'Valid', '` + encoded + `')`,
+		`INSERT INTO bottle (id, user_id, fill_volume, water_type, title, bottle_image) VALUES (2, 1, 500, 'mineral', 'Invalid', 'not base64!')`,
+		`INSERT INTO bottle (id, user_id, fill_volume, water_type, title) VALUES (3, 1, 500, 'mineral', 'None')`,
+		`INSERT INTO bottle (id, user_id, fill_volume, water_type, title, bottle_image) VALUES (4, 1, 500, 'mineral', 'Wide', '` + base64.StdEncoding.EncodeToString(wide) + `')`,
+		`INSERT INTO bottle (id, user_id, fill_volume, water_type, title, bottle_image) VALUES (5, 1, 500, 'mineral', 'Text', '` + base64.StdEncoding.EncodeToString(text) + `')`,
+		`INSERT INTO refill_stations (id, name, description, latitude, longitude, address, water_source, opening_times, type, offered_water_types, refill_station_image)
+			VALUES (1, 'Brunnen', '', 49.4, 7.7, 'Markt 1', 'Leitungswasser', '', 'manual', 'tap', '` + encoded + `')`,
+	}
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type bottleRow struct {
+		ID          uint
+		ImageID     *uint
+		BottleImage *string
+	}
+	loadBottles := func() map[uint]bottleRow {
+		var rows []bottleRow
+		if err := db.Table("bottle").Order("id").Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		bottles := map[uint]bottleRow{}
+		for _, row := range rows {
+			bottles[row.ID] = row
+		}
+		return bottles
+	}
+
+	repos := repository.NewGormRepositories(db)
+	store := &failingStore{MemoryStore: blob.NewMemoryStore(), failing: true}
+	images := media.NewImages(store, repos.Images)
+
+	// A failing store stops the move without touching the columns
+	if _, _, err := media.MoveLegacyImages(ctx, db, images); err == nil {
+		t.Fatal("expected the move to fail while the store fails")
+	}
+	for id, bottle := range loadBottles() {
+		if id != 3 && (bottle.BottleImage == nil || bottle.ImageID != nil) {
+			t.Errorf("expected bottle %d to keep its legacy image, got %+v", id, bottle)
+		}
+	}
+
+	store.failing = false
+	moved, kept, err := media.MoveLegacyImages(ctx, db, images)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved != 4 || kept != 1 {
+		t.Errorf("expected 4 moved images and 1 kept value, got %d and %d", moved, kept)
+	}
+
+	for id, bottle := range loadBottles() {
+		switch id {
+		case 2:
+			if bottle.BottleImage == nil || *bottle.BottleImage != "not base64!" || bottle.ImageID != nil {
+				t.Errorf("expected the invalid value to be kept, got %+v", bottle)
+			}
+		case 3:
+			if bottle.BottleImage != nil || bottle.ImageID != nil {
+				t.Errorf("expected bottle 3 to stay without an image, got %+v", bottle)
+			}
+		default:
+			if bottle.BottleImage != nil || bottle.ImageID == nil {
+				t.Errorf("expected the image of bottle %d to be moved, got %+v", id, bottle)
+			}
+		}
+	}
+	bottles := loadBottles()
+	for id, want := range map[uint][]byte{4: wide, 5: text} {
+		if data, err := images.Read(ctx, *bottles[id].ImageID); err != nil || !bytes.Equal(data, want) {
+			t.Errorf("expected the file of bottle %d to be moved as it is, got %d bytes, %v", id, len(data), err)
+		}
+	}
+	if moved, err := repos.Images.Get(ctx, *bottles[5].ImageID); err != nil || moved.ContentType != "application/octet-stream" {
+		t.Errorf("expected the text to be stored as a binary file, got %+v, %v", moved, err)
+	}
+
+	station, err := repos.Stations.Get(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if station.ImageID == nil {
+		t.Fatal("expected the station image to be moved")
+	}
+	if data, err := images.Read(ctx, *station.ImageID); err != nil || !bytes.Equal(data, file) {
+		t.Errorf("expected the moved station image, got %q, %v", data, err)
+	}
+
+	if moved, kept, err := media.MoveLegacyImages(ctx, db, images); err != nil || moved != 0 || kept != 1 {
+		t.Errorf("expected a second move to only keep the invalid value, got %d, %d, %v", moved, kept, err)
+	}
+}
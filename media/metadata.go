@@ -0,0 +1,193 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image/gif"
+	"slices"
+)
+
+// errCorrupt reports a file that looks like an image but cannot be parsed
+var errCorrupt = errors.New("corrupt image file")
+
+// stripMetadata removes EXIF, XMP, IPTC, comments and text chunks from an image file,
+// which may reveal the location, the camera or the author of a photo. The pixel data
+// is kept as it is. It returns the EXIF orientation of JPEG files, 1 for all others.
+func stripMetadata(contentType string, data []byte) ([]byte, int, error) {
+	switch contentType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		stripped, err := stripPNG(data)
+		return stripped, 1, err
+	case "image/webp":
+		stripped, err := stripWebP(data)
+		return stripped, 1, err
+	case "image/gif":
+		stripped, err := stripGIF(data)
+		return stripped, 1, err
+	}
+	return nil, 0, ErrNotAnImage
+}
+
+// stripJPEG drops the APP1 (EXIF, XMP), APP3 to APP13 and APP15 segments and comments.
+// JFIF (APP0), the ICC profile (APP2) and the Adobe color transform (APP14) are kept.
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errCorrupt
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:2])
+	orientation := 1
+	for i := 2; i+4 <= len(data); {
+		if data[i] != 0xFF {
+			return nil, 0, errCorrupt
+		}
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before a marker
+			i++
+			continue
+		case marker == 0xDA:
+			// The scans follow the start of scan marker up to the end of the file
+			out.Write(data[i:])
+			return out.Bytes(), orientation, nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without a length
+			out.Write(data[i : i+2])
+			i += 2
+			continue
+		}
+
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end < i+4 || end > len(data) {
+			return nil, 0, errCorrupt
+		}
+		payload := data[i+4 : end]
+		switch {
+		case marker == 0xE1:
+			if exif, ok := bytes.CutPrefix(payload, []byte("Exif\x00\x00")); ok {
+				orientation = exifOrientation(exif)
+			}
+		case marker >= 0xE3 && marker <= 0xEF && marker != 0xEE, marker == 0xFE:
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	return nil, 0, errCorrupt
+}
+
+// exifOrientation reads the orientation tag of the first IFD of EXIF data, 1 if it is missing
+func exifOrientation(exif []byte) int {
+	if len(exif) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(exif[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	offset := int(order.Uint32(exif[4:]))
+	if offset < 8 || offset+2 > len(exif) {
+		return 1
+	}
+	entries := int(order.Uint16(exif[offset:]))
+	for n := 0; n < entries; n++ {
+		entry := offset + 2 + n*12
+		if entry+12 > len(exif) {
+			break
+		}
+		// The orientation is a single SHORT stored in the value field
+		if order.Uint16(exif[entry:]) == 0x0112 && order.Uint16(exif[entry+2:]) == 3 {
+			if orientation := int(order.Uint16(exif[entry+8:])); orientation >= 1 && orientation <= 8 {
+				return orientation
+			}
+		}
+	}
+	return 1
+}
+
+// pngMetadataChunks hold EXIF data, text like author and comments and the modification time
+var pngMetadataChunks = []string{"eXIf", "tEXt", "zTXt", "iTXt", "tIME"}
+
+func stripPNG(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, errCorrupt
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.WriteString(signature)
+	for i := len(signature); i+12 <= len(data); {
+		// Length, type, data and CRC
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end < i+12 || end > len(data) {
+			return nil, errCorrupt
+		}
+		chunkType := string(data[i+4 : i+8])
+		if !slices.Contains(pngMetadataChunks, chunkType) {
+			out.Write(data[i:end])
+		}
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+		i = end
+	}
+	return nil, errCorrupt
+}
+
+// stripWebP drops the EXIF and XMP chunks and clears their flags in the extended header
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errCorrupt
+	}
+	out := bytes.NewBuffer(make([]byte, 0, len(data)))
+	out.Write(data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errCorrupt
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		// Chunks are padded to an even size
+		end := i + 8 + size + size%2
+		if end < i+8 || end > len(data) {
+			return nil, errCorrupt
+		}
+		switch string(data[i : i+4]) {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			chunk := bytes.Clone(data[i:end])
+			if len(chunk) > 8 {
+				const exifFlag, xmpFlag = 0x08, 0x04
+				chunk[8] &^= exifFlag | xmpFlag
+			}
+			out.Write(chunk)
+		default:
+			out.Write(data[i:end])
+		}
+		i = end
+	}
+	stripped := out.Bytes()
+	binary.LittleEndian.PutUint32(stripped[4:], uint32(len(stripped)-8))
+	return stripped, nil
+}
+
+// stripGIF encodes the frames again, which drops comments and application extensions
+// like XMP while the animation and its loop count stay
+func stripGIF(data []byte) ([]byte, error) {
+	animation, err := gif.DecodeAll(bytes.NewReader(data))
+	if err != nil {
+		return nil, errCorrupt
+	}
+	var out bytes.Buffer
+	if err := gif.EncodeAll(&out, animation); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -0,0 +1,243 @@
+package media
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	// Decoders of the accepted formats
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Limits of the decoded bitmaps, they keep small files that decode into huge bitmaps out
+const (
+	// MaxImagePixels is the largest accepted width times height, summed over all frames
+	MaxImagePixels = 40_000_000
+	// MaxGIFFrames is the largest accepted number of frames of an animation
+	MaxGIFFrames = 200
+)
+
+// Variant is a downscaled copy of images fitting into a square of Size pixels
+type Variant struct {
+	Name string
+	Size int
+}
+
+// Variants are generated for every saved image that is larger than their size,
+// thumb for lists and marker for map markers
+var Variants = []Variant{
+	{Name: "thumb", Size: 320},
+	{Name: "marker", Size: 96},
+}
+
+// JPEG quality of re-encoded originals and of the variants
+const (
+	originalQuality = 90
+	variantQuality  = 85
+)
+
+// processed is an image file ready to be stored
+type processed struct {
+	data          []byte
+	contentType   string
+	width, height int
+	// decoded is the first frame, oriented like data
+	decoded image.Image
+}
+
+// process checks an uploaded file and removes its metadata. Photos with an EXIF
+// orientation are rotated, as the orientation is lost with the metadata.
+func process(data []byte) (*processed, error) {
+	if len(data) > MaxImageSize {
+		return nil, ErrImageTooLarge
+	}
+	contentType := detectContentType(data)
+	if contentType == "" {
+		return nil, ErrNotAnImage
+	}
+	// The header tells the dimensions before the bitmap is allocated
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAnImage, err)
+	}
+	if config.Width < 1 || config.Height < 1 {
+		return nil, ErrImageDimensions
+	}
+	pixels := int64(config.Width) * int64(config.Height)
+	if contentType == "image/gif" {
+		// Every frame of an animation is decoded into a bitmap of up to the full size
+		frames, err := gifFrames(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotAnImage, err)
+		}
+		if frames > MaxGIFFrames {
+			return nil, ErrTooManyFrames
+		}
+		pixels *= int64(max(frames, 1))
+	}
+	if pixels > MaxImagePixels {
+		return nil, ErrImageDimensions
+	}
+
+	stripped, orientation, err := stripMetadata(contentType, data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAnImage, err)
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotAnImage, err)
+	}
+	if orientation > 1 {
+		decoded = orient(decoded, orientation)
+		var out bytes.Buffer
+		if err := jpeg.Encode(&out, decoded, &jpeg.Options{Quality: originalQuality}); err != nil {
+			return nil, err
+		}
+		stripped = out.Bytes()
+	}
+
+	bounds := decoded.Bounds()
+	return &processed{
+		data:        stripped,
+		contentType: contentType,
+		width:       bounds.Dx(),
+		height:      bounds.Dy(),
+		decoded:     decoded,
+	}, nil
+}
+
+// gifFrames counts the frames of a GIF file by walking its blocks without decompressing
+// them, it stops counting beyond MaxGIFFrames
+func gifFrames(data []byte) (int, error) {
+	// Header and logical screen descriptor
+	if len(data) < 13 {
+		return 0, errCorrupt
+	}
+	i := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		i += 3 << (flags&0x07 + 1)
+	}
+	frames := 0
+	for i < len(data) && frames <= MaxGIFFrames {
+		switch data[i] {
+		case 0x21:
+			// Extension introducer and label
+			i += 2
+		case 0x2C:
+			// Image descriptor, local color table and LZW minimum code size
+			if i+10 > len(data) {
+				return 0, errCorrupt
+			}
+			frames++
+			flags := data[i+9]
+			i += 10
+			if flags&0x80 != 0 {
+				i += 3 << (flags&0x07 + 1)
+			}
+			i++
+		case 0x3B:
+			// Trailer
+			return frames, nil
+		default:
+			return 0, errCorrupt
+		}
+		// Data sub-blocks up to the empty block
+		for {
+			if i >= len(data) {
+				return 0, errCorrupt
+			}
+			size := int(data[i])
+			i += 1 + size
+			if size == 0 {
+				break
+			}
+		}
+	}
+	return frames, nil
+}
+
+// unprocessed returns the file as it is, with the dimensions its header tells if it has one
+func unprocessed(data []byte) *processed {
+	file := &processed{data: data, contentType: detectContentType(data)}
+	if file.contentType == "" {
+		file.contentType = "application/octet-stream"
+	}
+	if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		file.width, file.height = config.Width, config.Height
+	}
+	return file
+}
+
+// scale fits the image into a square of size pixels and encodes it as JPEG,
+// or as PNG if it has transparent pixels
+func scale(img image.Image, size int) (*processed, error) {
+	bounds := img.Bounds()
+	width, height := size, size
+	if bounds.Dx() > bounds.Dy() {
+		height = max(1, bounds.Dy()*size/bounds.Dx())
+	} else {
+		width = max(1, bounds.Dx()*size/bounds.Dy())
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Src, nil)
+
+	var out bytes.Buffer
+	contentType := "image/jpeg"
+	if scaled.Opaque() {
+		if err := jpeg.Encode(&out, scaled, &jpeg.Options{Quality: variantQuality}); err != nil {
+			return nil, err
+		}
+	} else {
+		contentType = "image/png"
+		if err := png.Encode(&out, scaled); err != nil {
+			return nil, err
+		}
+	}
+	return &processed{data: out.Bytes(), contentType: contentType, width: width, height: height}, nil
+}
+
+// orient turns the image upright according to an EXIF orientation between 2 and 8
+func orient(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	src := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		// Orientations 5 to 8 swap width and height
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// The cases name how the photo was stored
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated by 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated by 90° counterclockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated by 90° clockwise
+				sx, sy = w-1-y, x
+			default:
+				sx, sy = x, y
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):][:4], src.Pix[src.PixOffset(sx, sy):][:4])
+		}
+	}
+	return dst
+}
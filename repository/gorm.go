@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// notFound translates gorm's missing record error into ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// gormList counts all rows of the model and loads the window of the query
+func gormList[T any](ctx context.Context, db *gorm.DB, query ListQuery) ([]T, int64, error) {
+	db = db.WithContext(ctx)
+	var total int64
+	if err := db.Model(new(T)).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	column := query.sortColumn()
+	if _, err := columnField[T](column); err != nil {
+		return nil, 0, err
+	}
+	if query.After != nil {
+		value, err := cursorValue[T](query)
+		if err != nil {
+			return nil, 0, err
+		}
+		db = db.Where(keysetCondition(column, value.Interface(), query.After.ID, query.Desc))
+	} else if query.Offset > 0 {
+		db = db.Offset(query.Offset)
+	}
+	if query.Limit > 0 {
+		db = db.Limit(query.Limit)
+	}
+	db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: query.Desc})
+	if column != "id" {
+		db = db.Order(clause.OrderByColumn{Column: clause.Column{Name: "id"}, Desc: query.Desc})
+	}
+
+	rows := []T{}
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, 0, err
+	}
+	return rows, total, nil
+}
+
+// keysetCondition selects the rows after the one with the sort value and ID
+func keysetCondition(column string, value any, id uint, desc bool) clause.Expression {
+	after := func(column string, value any) clause.Expression {
+		if desc {
+			return clause.Lt{Column: clause.Column{Name: column}, Value: value}
+		}
+		return clause.Gt{Column: clause.Column{Name: column}, Value: value}
+	}
+	if column == "id" {
+		return after("id", id)
+	}
+	return clause.Or(
+		after(column, value),
+		clause.And(clause.Eq{Column: clause.Column{Name: column}, Value: value}, after("id", id)),
+	)
+}
+
+func gormGet[T any](ctx context.Context, db *gorm.DB, id uint) (*T, error) {
+	var row T
+	if err := db.WithContext(ctx).First(&row, id).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &row, nil
+}
+
+// gormUpdate loads the record with the given ID and applies the non-zero fields of changes
+func gormUpdate[T any](ctx context.Context, db *gorm.DB, id uint, changes *T) (*T, error) {
+	stored, err := gormGet[T](ctx, db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.WithContext(ctx).Model(stored).Updates(changes).Error; err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func gormDelete[T any](ctx context.Context, db *gorm.DB, id uint) error {
+	result := db.WithContext(ctx).Delete(new(T), id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormBottleRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormBottleRepository) List(ctx context.Context, query ListQuery) ([]database.Bottle, int64, error) {
+	return gormList[database.Bottle](ctx, r.db, query)
+}
+
+func (r *gormBottleRepository) ListByUser(ctx context.Context, userID uint) ([]database.Bottle, error) {
+	var bottles []database.Bottle
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&bottles).Error; err != nil {
+		return nil, err
+	}
+	return bottles, nil
+}
+
+func (r *gormBottleRepository) Get(ctx context.Context, id uint) (*database.Bottle, error) {
+	return gormGet[database.Bottle](ctx, r.db, id)
+}
+
+func (r *gormBottleRepository) GetByNFCID(ctx context.Context, nfcID string) (*database.Bottle, error) {
+	var bottle database.Bottle
+	if err := r.db.WithContext(ctx).Where("nfc_id = ?", nfcID).First(&bottle).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &bottle, nil
+}
+
+func (r *gormBottleRepository) Create(ctx context.Context, bottle *database.Bottle) error {
+	return r.db.WithContext(ctx).Create(bottle).Error
+}
+
+func (r *gormBottleRepository) Update(ctx context.Context, changes *database.Bottle) (*database.Bottle, error) {
+	bottle, err := gormUpdate(ctx, r.db, changes.ID, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Workaround to ensure saving of an empty nfc-id
+	if changes.NFCID == "" {
+		if err := r.db.WithContext(ctx).Model(bottle).Select("NFCID").Updates(map[string]interface{}{"NFCID": ""}).Error; err != nil {
+			return nil, err
+		}
+	}
+	return bottle, nil
+}
+
+func (r *gormBottleRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.Bottle](ctx, r.db, id)
+}
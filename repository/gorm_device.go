@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormDeviceRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormDeviceRepository) Get(ctx context.Context, id uint) (*database.Device, error) {
+	return gormGet[database.Device](ctx, r.db, id)
+}
+
+func (r *gormDeviceRepository) ListByStation(ctx context.Context, stationID uint) ([]database.Device, error) {
+	var devices []database.Device
+	err := r.db.WithContext(ctx).Where("station_id = ?", stationID).Order("id").Find(&devices).Error
+	return devices, err
+}
+
+func (r *gormDeviceRepository) Create(ctx context.Context, device *database.Device, key *database.DeviceKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(device).Error; err != nil {
+			return err
+		}
+		key.DeviceID = device.ID
+		return tx.Create(key).Error
+	})
+}
+
+func (r *gormDeviceRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&database.Device{}).
+		Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *gormDeviceRepository) GetKeyByHash(ctx context.Context, hash string) (*database.DeviceKey, error) {
+	var key database.DeviceKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &key, nil
+}
+
+func (r *gormDeviceRepository) ListKeys(ctx context.Context, deviceID uint) ([]database.DeviceKey, error) {
+	var keys []database.DeviceKey
+	err := r.db.WithContext(ctx).Where("device_id = ?", deviceID).Order("id").Find(&keys).Error
+	return keys, err
+}
+
+func (r *gormDeviceRepository) RotateKey(ctx context.Context, key *database.DeviceKey, graceUntil time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&database.DeviceKey{}).
+			Where("device_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", key.DeviceID, graceUntil).
+			Update("expires_at", graceUntil).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(key).Error
+	})
+}
+
+func (r *gormDeviceRepository) RevokeKey(ctx context.Context, deviceID, keyID uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&database.DeviceKey{}).
+		Where("id = ? AND device_id = ? AND revoked_at IS NULL", keyID, deviceID).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var key database.DeviceKey
+		if err := r.db.WithContext(ctx).Where("id = ? AND device_id = ?", keyID, deviceID).First(&key).Error; err != nil {
+			return notFound(err)
+		}
+	}
+	return nil
+}
+
+func (r *gormDeviceRepository) ListActive(ctx context.Context) ([]database.Device, error) {
+	var devices []database.Device
+	err := r.db.WithContext(ctx).Where("revoked_at IS NULL").Order("id").Find(&devices).Error
+	return devices, err
+}
+
+func (r *gormDeviceRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&database.Device{}).Where("id = ?", id).Update("last_seen_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormDispenseRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormDispenseRepository) Get(ctx context.Context, id uint) (*database.Dispense, error) {
+	return gormGet[database.Dispense](ctx, r.db, id)
+}
+
+func (r *gormDispenseRepository) Create(ctx context.Context, dispense *database.Dispense) error {
+	return r.db.WithContext(ctx).Create(dispense).Error
+}
+
+func (r *gormDispenseRepository) Confirm(ctx context.Context, id uint, transaction *database.WaterTransaction) (*database.Dispense, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Claiming the open dispense first lets only one of concurrent confirmations pass
+		result := tx.Model(&database.Dispense{}).
+			Where("id = ? AND confirmed_at IS NULL AND expires_at > ?", id, transaction.Timestamp).
+			Update("confirmed_at", transaction.Timestamp)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if _, err := gormGet[database.Dispense](ctx, tx, id); err != nil {
+				return err
+			}
+			return ErrDispenseClosed
+		}
+		if err := tx.Create(transaction).Error; err != nil {
+			return err
+		}
+		return tx.Model(&database.Dispense{}).Where("id = ?", id).Update("transaction_id", transaction.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, id)
+}
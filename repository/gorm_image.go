@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormImageRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormImageRepository) Get(ctx context.Context, id uint) (*database.Image, error) {
+	return gormGet[database.Image](ctx, r.db, id)
+}
+
+func (r *gormImageRepository) Variants(ctx context.Context, originalID uint) ([]database.Image, error) {
+	var variants []database.Image
+	err := r.db.WithContext(ctx).Where("original_id = ?", originalID).Order("id").Find(&variants).Error
+	return variants, err
+}
+
+func (r *gormImageRepository) Create(ctx context.Context, image *database.Image) error {
+	return r.db.WithContext(ctx).Create(image).Error
+}
+
+func (r *gormImageRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.Image](ctx, r.db, id)
+}
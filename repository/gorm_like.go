@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormLikeRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormLikeRepository) List(ctx context.Context, query ListQuery) ([]database.Like, int64, error) {
+	return gormList[database.Like](ctx, r.db, query)
+}
+
+func (r *gormLikeRepository) Get(ctx context.Context, id uint) (*database.Like, error) {
+	return gormGet[database.Like](ctx, r.db, id)
+}
+
+func (r *gormLikeRepository) GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.Like, error) {
+	var like database.Like
+	if err := r.db.WithContext(ctx).Where("station_id = ? AND user_id = ?", stationID, userID).First(&like).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &like, nil
+}
+
+func (r *gormLikeRepository) CountByStation(ctx context.Context, stationID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.Like{}).Where("station_id = ?", stationID).Count(&count).Error
+	return count, err
+}
+
+func (r *gormLikeRepository) Create(ctx context.Context, like *database.Like) error {
+	return r.db.WithContext(ctx).Create(like).Error
+}
+
+func (r *gormLikeRepository) Update(ctx context.Context, changes *database.Like) (*database.Like, error) {
+	return gormUpdate(ctx, r.db, changes.ID, changes)
+}
+
+func (r *gormLikeRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.Like](ctx, r.db, id)
+}
+
+func (r *gormLikeRepository) CountsByStation(ctx context.Context) (map[uint]int64, error) {
+	var rows []struct {
+		StationID uint
+		Count     int64
+	}
+	err := r.db.WithContext(ctx).Model(&database.Like{}).Select("station_id, COUNT(*) AS count").Group("station_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.StationID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *gormLikeRepository) ListStationIDsByUser(ctx context.Context, userID uint) ([]uint, error) {
+	var stationIDs []uint
+	err := r.db.WithContext(ctx).Model(&database.Like{}).Where("user_id = ?", userID).Order("station_id").Pluck("station_id", &stationIDs).Error
+	return stationIDs, err
+}
@@ -0,0 +1,266 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+	"github.com/PoseidonPSE2/code_backend/search"
+	"gorm.io/gorm"
+)
+
+type gormRefillStationRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRefillStationRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStation, int64, error) {
+	return gormList[database.RefillStation](ctx, r.db, query)
+}
+
+func (r *gormRefillStationRepository) ListMarkers(ctx context.Context, box *geo.Box) ([]database.RefillStation, error) {
+	query := r.db.WithContext(ctx).Select("id, longitude, latitude, active")
+	if box != nil {
+		query = query.Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat).
+			Where("longitude BETWEEN ? AND ?", box.MinLon, box.MaxLon)
+	}
+	var stations []database.RefillStation
+	if err := query.Order("id").Find(&stations).Error; err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// ListNearby loads the stations in the bounding box of the radius using the location
+// index and leaves the exact distance check and ordering to nearest
+func (r *gormRefillStationRepository) ListNearby(ctx context.Context, query NearbyQuery) ([]NearbyStation, error) {
+	box := geo.BoundingBox(query.Latitude, query.Longitude, query.RadiusMeters)
+	var candidates []database.RefillStation
+	err := r.db.WithContext(ctx).
+		Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat).
+		Where("longitude BETWEEN ? AND ?", box.MinLon, box.MaxLon).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+	return nearest(candidates, query), nil
+}
+
+func (r *gormRefillStationRepository) Get(ctx context.Context, id uint) (*database.RefillStation, error) {
+	return gormGet[database.RefillStation](ctx, r.db, id)
+}
+
+func (r *gormRefillStationRepository) Create(ctx context.Context, station *database.RefillStation) error {
+	return r.db.WithContext(ctx).Create(station).Error
+}
+
+func (r *gormRefillStationRepository) Install(ctx context.Context, station *database.RefillStation, change *database.StationStatusChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(station).Error; err != nil {
+			return err
+		}
+		change.StationID = station.ID
+		// Column default of the active flag
+		change.Active = !station.Active.Valid || station.Active.Bool
+		return tx.Create(change).Error
+	})
+}
+
+func (r *gormRefillStationRepository) Update(ctx context.Context, changes *database.RefillStation) (*database.RefillStation, error) {
+	return r.UpdateWithStatus(ctx, changes, nil)
+}
+
+func (r *gormRefillStationRepository) UpdateWithStatus(ctx context.Context, changes *database.RefillStation, status *database.StationStatusChange) (*database.RefillStation, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := gormUpdate(ctx, tx, changes.ID, changes); err != nil {
+			return err
+		}
+		// Opening times without opening hours did not parse, the stored hours belong to the old ones
+		if changes.OpeningTimes != "" && changes.OpeningHours == nil {
+			if err := tx.Model(&database.RefillStation{}).Where("id = ?", changes.ID).Update("opening_hours", nil).Error; err != nil {
+				return err
+			}
+		}
+		if status == nil {
+			return nil
+		}
+		_, err := recordStatus(tx, status, setActive(status))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, changes.ID)
+}
+
+func (r *gormRefillStationRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.RefillStation](ctx, r.db, id)
+}
+
+func (r *gormRefillStationRepository) CountByType(ctx context.Context, stationType string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.RefillStation{}).Where("type = ?", stationType).Count(&count).Error
+	return count, err
+}
+
+func (r *gormRefillStationRepository) SetActive(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(ctx, change, setActive(change))
+}
+
+// setActive returns the update of SetActive for recordStatus
+func setActive(change *database.StationStatusChange) func(tx *gorm.DB) (bool, error) {
+	return func(tx *gorm.DB) (bool, error) {
+		result := tx.Model(&database.RefillStation{}).
+			Where("id = ? AND (active IS NULL OR active <> ?)", change.StationID, change.Active).
+			Updates(map[string]any{"active": change.Active, "offline_since": nil})
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.RowsAffected > 0, result.Error
+		}
+		// The state is unchanged, only stop the heartbeats from changing it
+		return false, tx.Model(&database.RefillStation{}).Where("id = ?", change.StationID).Update("offline_since", nil).Error
+	}
+}
+
+func (r *gormRefillStationRepository) MarkOffline(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(ctx, change, func(tx *gorm.DB) (bool, error) {
+		result := tx.Model(&database.RefillStation{}).
+			Where("id = ? AND active = ?", change.StationID, true).
+			Updates(map[string]any{"active": false, "offline_since": change.ChangedAt})
+		return result.RowsAffected > 0, result.Error
+	})
+}
+
+func (r *gormRefillStationRepository) MarkOnline(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(ctx, change, func(tx *gorm.DB) (bool, error) {
+		result := tx.Model(&database.RefillStation{}).
+			Where("id = ? AND offline_since IS NOT NULL", change.StationID).
+			Updates(map[string]any{"active": true, "offline_since": nil})
+		return result.RowsAffected > 0, result.Error
+	})
+}
+
+// changeStatus runs recordStatus in a transaction. If nothing changed, it checks that the
+// station exists.
+func (r *gormRefillStationRepository) changeStatus(ctx context.Context, change *database.StationStatusChange, update func(tx *gorm.DB) (bool, error)) (bool, error) {
+	var changed bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		changed, err = recordStatus(tx, change, update)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if !changed {
+		if _, err := r.Get(ctx, change.StationID); err != nil {
+			return false, err
+		}
+	}
+	return changed, nil
+}
+
+// recordStatus runs the conditional update of the station of the change within the
+// transaction tx and records the change if the update reports that the active state changed
+func recordStatus(tx *gorm.DB, change *database.StationStatusChange, update func(tx *gorm.DB) (bool, error)) (bool, error) {
+	changed, err := update(tx)
+	if err != nil || !changed {
+		return false, err
+	}
+	return true, tx.Create(change).Error
+}
+
+func (r *gormRefillStationRepository) ListStatusChanges(ctx context.Context, stationID uint, from, to time.Time) ([]database.StationStatusChange, error) {
+	var changes []database.StationStatusChange
+	err := r.db.WithContext(ctx).
+		Where("station_id = ? AND changed_at >= ? AND changed_at < ?", stationID, from, to).
+		Order("changed_at, id").Find(&changes).Error
+	return changes, err
+}
+
+func (r *gormRefillStationRepository) LastStatusChangeBefore(ctx context.Context, stationID uint, before time.Time) (*database.StationStatusChange, error) {
+	var change database.StationStatusChange
+	err := r.db.WithContext(ctx).Where("station_id = ? AND changed_at < ?", stationID, before).
+		Order("changed_at DESC, id DESC").First(&change).Error
+	if err != nil {
+		return nil, notFound(err)
+	}
+	return &change, nil
+}
+
+func (r *gormRefillStationRepository) Search(ctx context.Context, filter StationFilter) ([]database.RefillStation, error) {
+	query := r.db.WithContext(ctx)
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.WaterType != "" {
+		query = query.Where("offered_water_types IN ?", offeringWaterType(filter.WaterType))
+	}
+	if filter.Active != nil {
+		query = query.Where("active = ?", *filter.Active)
+	}
+	if filter.Text != "" {
+		pattern := "%" + likeEscaper.Replace(strings.ToLower(filter.Text)) + "%"
+		query = query.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\' OR LOWER(address) LIKE ? ESCAPE '\')`, pattern, pattern, pattern)
+	}
+	if box := filter.Box; box != nil {
+		query = query.Where("latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat).
+			Where("longitude BETWEEN ? AND ?", box.MinLon, box.MaxLon)
+	}
+	var stations []database.RefillStation
+	if err := query.Order("id").Find(&stations).Error; err != nil {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// TextSearch uses the German full-text vector on Postgres and falls back to the in-process
+// index elsewhere or if nothing matches, which also tolerates typos
+func (r *gormRefillStationRepository) TextSearch(ctx context.Context, query string, limit int) ([]ScoredStation, error) {
+	if r.db.Dialector.Name() == database.DriverPostgres {
+		stations, err := r.fullTextSearch(ctx, query, limit)
+		if err != nil || len(stations) > 0 {
+			return stations, err
+		}
+	}
+	var stations []database.RefillStation
+	if err := r.db.WithContext(ctx).Find(&stations).Error; err != nil {
+		return nil, err
+	}
+	return searchStations(stations, query, limit), nil
+}
+
+// fullTextSearch ranks the stations by the search_vector column matching every word
+// of the query as a prefix
+func (r *gormRefillStationRepository) fullTextSearch(ctx context.Context, query string, limit int) ([]ScoredStation, error) {
+	words := search.Tokenize(query)
+	if len(words) == 0 {
+		return []ScoredStation{}, nil
+	}
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	tsQuery := strings.Join(words, " & ")
+
+	var hits []search.Hit
+	err := r.db.WithContext(ctx).Model(&database.RefillStation{}).
+		Select("id, ts_rank(search_vector, to_tsquery('german', ?)) AS score", tsQuery).
+		Where("search_vector @@ to_tsquery('german', ?)", tsQuery).
+		Order("score DESC, id").Limit(limit).Scan(&hits).Error
+	if err != nil || len(hits) == 0 {
+		return nil, err
+	}
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var stations []database.RefillStation
+	if err := r.db.WithContext(ctx).Find(&stations, ids).Error; err != nil {
+		return nil, err
+	}
+	return rankStations(stations, hits), nil
+}
+
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
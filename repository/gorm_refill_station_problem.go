@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormRefillStationProblemRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRefillStationProblemRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStationProblem, int64, error) {
+	return gormList[database.RefillStationProblem](ctx, r.db, query)
+}
+
+func (r *gormRefillStationProblemRepository) Get(ctx context.Context, id uint) (*database.RefillStationProblem, error) {
+	return gormGet[database.RefillStationProblem](ctx, r.db, id)
+}
+
+func (r *gormRefillStationProblemRepository) Create(ctx context.Context, problem *database.RefillStationProblem) error {
+	return r.db.WithContext(ctx).Create(problem).Error
+}
+
+func (r *gormRefillStationProblemRepository) Report(ctx context.Context, problem *database.RefillStationProblem, actorID *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(problem).Error; err != nil {
+			return err
+		}
+		err := tx.Create(&database.ProblemStatusChange{
+			ProblemID: problem.ID,
+			ToStatus:  problem.Status,
+			ActorID:   actorID,
+			ChangedAt: problem.Timestamp,
+		}).Error
+		if err != nil || !problem.DeactivateStation {
+			return err
+		}
+		change := problem.StationStatusChange(actorID, problem.Timestamp)
+		_, err = recordStatus(tx, change, setActive(change))
+		return err
+	})
+}
+
+func (r *gormRefillStationProblemRepository) Update(ctx context.Context, changes *database.RefillStationProblem) (*database.RefillStationProblem, error) {
+	return gormUpdate(ctx, r.db, changes.ID, changes)
+}
+
+func (r *gormRefillStationProblemRepository) Transition(ctx context.Context, change *database.ProblemStatusChange, changes *database.RefillStationProblem) (*database.RefillStationProblem, error) {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var problem database.RefillStationProblem
+		if err := tx.First(&problem, change.ProblemID).Error; err != nil {
+			return notFound(err)
+		}
+		fields, station := transition(&problem, change, changes)
+		result := tx.Model(&database.RefillStationProblem{}).
+			Where("id = ? AND status = ?", change.ProblemID, change.FromStatus).
+			Updates(map[string]any{"status": change.ToStatus, "deactivate_station": problem.DeactivateStation})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrProblemStatusChanged
+		}
+		if fields != nil {
+			if err := tx.Model(&problem).Updates(fields).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(change).Error; err != nil {
+			return err
+		}
+		if station == nil {
+			return nil
+		}
+		if station.Active {
+			released, err := stationReleased(tx, &problem)
+			if err != nil || !released {
+				return err
+			}
+		}
+		_, err := recordStatus(tx, station, setActive(station))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.Get(ctx, change.ProblemID)
+}
+
+// stationReleased reports whether the station of the solved or closed problem was deactivated
+// by problems and no other open problem keeps it deactivated
+func stationReleased(tx *gorm.DB, problem *database.RefillStationProblem) (bool, error) {
+	var last database.StationStatusChange
+	err := tx.Where("station_id = ?", problem.StationID).Order("changed_at DESC, id DESC").First(&last).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil || last.Active || last.Source != database.StatusSourceProblem {
+		return false, err
+	}
+	var others int64
+	err = tx.Model(&database.RefillStationProblem{}).
+		Where("station_id = ? AND id <> ? AND deactivate_station = ? AND status IN ?", problem.StationID, problem.ID, true, database.OpenProblemStatuses).
+		Count(&others).Error
+	return others == 0, err
+}
+
+func (r *gormRefillStationProblemRepository) ListStatusChanges(ctx context.Context, problemID uint) ([]database.ProblemStatusChange, error) {
+	var changes []database.ProblemStatusChange
+	err := r.db.WithContext(ctx).Where("problem_id = ?", problemID).Order("changed_at, id").Find(&changes).Error
+	return changes, err
+}
+
+func (r *gormRefillStationProblemRepository) Delete(ctx context.Context, id uint, actorID *uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var problem database.RefillStationProblem
+		if err := tx.First(&problem, id).Error; err != nil {
+			return notFound(err)
+		}
+		if err := tx.Delete(&problem).Error; err != nil {
+			return err
+		}
+		station := deletion(&problem, actorID)
+		if station == nil {
+			return nil
+		}
+		released, err := stationReleased(tx, &problem)
+		if err != nil || !released {
+			return err
+		}
+		_, err = recordStatus(tx, station, setActive(station))
+		return err
+	})
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormRefillStationReviewRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRefillStationReviewRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStationReview, int64, error) {
+	return gormList[database.RefillStationReview](ctx, r.db, query)
+}
+
+func (r *gormRefillStationReviewRepository) ListByStation(ctx context.Context, stationID uint) ([]database.RefillStationReview, error) {
+	var reviews []database.RefillStationReview
+	if err := r.db.WithContext(ctx).Where("station_id = ?", stationID).Find(&reviews).Error; err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *gormRefillStationReviewRepository) Get(ctx context.Context, id uint) (*database.RefillStationReview, error) {
+	return gormGet[database.RefillStationReview](ctx, r.db, id)
+}
+
+func (r *gormRefillStationReviewRepository) GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.RefillStationReview, error) {
+	var review database.RefillStationReview
+	if err := r.db.WithContext(ctx).Where("user_id = ? AND station_id = ?", userID, stationID).First(&review).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &review, nil
+}
+
+func (r *gormRefillStationReviewRepository) Create(ctx context.Context, review *database.RefillStationReview) error {
+	return r.db.WithContext(ctx).Create(review).Error
+}
+
+func (r *gormRefillStationReviewRepository) Save(ctx context.Context, review *database.RefillStationReview) error {
+	return r.db.WithContext(ctx).Save(review).Error
+}
+
+func (r *gormRefillStationReviewRepository) Update(ctx context.Context, changes *database.RefillStationReview) (*database.RefillStationReview, error) {
+	return gormUpdate(ctx, r.db, changes.ID, changes)
+}
+
+func (r *gormRefillStationReviewRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.RefillStationReview](ctx, r.db, id)
+}
+
+func (r *gormRefillStationReviewRepository) AveragesByStation(ctx context.Context) (map[uint]ReviewAverages, error) {
+	var rows []struct {
+		StationID uint
+		ReviewAverages
+	}
+	err := r.db.WithContext(ctx).Model(&database.RefillStationReview{}).
+		Select("station_id, COUNT(*) AS count, AVG(cleanness) AS cleanness, AVG(accessibility) AS accessibility, AVG(water_quality) AS water_quality").
+		Group("station_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	averages := make(map[uint]ReviewAverages, len(rows))
+	for _, row := range rows {
+		averages[row.StationID] = row.ReviewAverages
+	}
+	return averages, nil
+}
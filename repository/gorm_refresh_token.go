@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormRefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormRefreshTokenRepository) Get(ctx context.Context, id uint) (*database.RefreshToken, error) {
+	return gormGet[database.RefreshToken](ctx, r.db, id)
+}
+
+func (r *gormRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*database.RefreshToken, error) {
+	var token database.RefreshToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", hash).First(&token).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &token, nil
+}
+
+func (r *gormRefreshTokenRepository) Create(ctx context.Context, token *database.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *gormRefreshTokenRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&database.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.Get(ctx, id); err != nil {
+			return err
+		}
+		return ErrTokenRevoked
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormTelemetryRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormTelemetryRepository) Create(ctx context.Context, telemetry *database.Telemetry) error {
+	return r.db.WithContext(ctx).Create(telemetry).Error
+}
+
+func (r *gormTelemetryRepository) ListByStation(ctx context.Context, stationID uint, from, to time.Time, limit int) ([]database.Telemetry, error) {
+	var reports []database.Telemetry
+	err := r.db.WithContext(ctx).
+		Where("station_id = ? AND recorded_at >= ? AND recorded_at < ?", stationID, from, to).
+		Order("recorded_at, id").Limit(limit).Find(&reports).Error
+	return reports, err
+}
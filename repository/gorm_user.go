@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+)
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormUserRepository) List(ctx context.Context, query ListQuery) ([]database.User, int64, error) {
+	return gormList[database.User](ctx, r.db, query)
+}
+
+func (r *gormUserRepository) Get(ctx context.Context, id uint) (*database.User, error) {
+	return gormGet[database.User](ctx, r.db, id)
+}
+
+func (r *gormUserRepository) GetByEmail(ctx context.Context, email string) (*database.User, error) {
+	var user database.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &user, nil
+}
+
+func (r *gormUserRepository) Create(ctx context.Context, user *database.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *gormUserRepository) Update(ctx context.Context, changes *database.User) (*database.User, error) {
+	// Zero fields are skipped, the password and the role have their own routes
+	profile := *changes
+	profile.PasswordHash, profile.Role = "", ""
+	return gormUpdate(ctx, r.db, profile.ID, &profile)
+}
+
+func (r *gormUserRepository) SetRole(ctx context.Context, id uint, role string) error {
+	user := database.User{ID: id, Role: role}
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	result := r.db.WithContext(ctx).Model(&database.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *gormUserRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.User](ctx, r.db, id)
+}
+
+func (r *gormUserRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&database.User{}).Count(&count).Error
+	return count, err
+}
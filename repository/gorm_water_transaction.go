@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type gormWaterTransactionRepository struct {
+	db *gorm.DB
+}
+
+func (r *gormWaterTransactionRepository) List(ctx context.Context, query ListQuery) ([]database.WaterTransaction, int64, error) {
+	return gormList[database.WaterTransaction](ctx, r.db, query)
+}
+
+func (r *gormWaterTransactionRepository) Get(ctx context.Context, id uint) (*database.WaterTransaction, error) {
+	return gormGet[database.WaterTransaction](ctx, r.db, id)
+}
+
+func (r *gormWaterTransactionRepository) Create(ctx context.Context, transaction *database.WaterTransaction) error {
+	return r.db.WithContext(ctx).Create(transaction).Error
+}
+
+func (r *gormWaterTransactionRepository) CreateOnce(ctx context.Context, transaction *database.WaterTransaction) (*database.WaterTransaction, bool, error) {
+	if transaction.IdempotencyKey == nil {
+		return transaction, true, r.Create(ctx, transaction)
+	}
+	// The unique index decides between concurrent requests with the same key
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(transaction)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return transaction, true, nil
+	}
+	var stored database.WaterTransaction
+	err := r.db.WithContext(ctx).Where("station_id = ? AND idempotency_key = ?", transaction.StationID, *transaction.IdempotencyKey).
+		First(&stored).Error
+	if err != nil {
+		return nil, false, notFound(err)
+	}
+	return &stored, false, nil
+}
+
+func (r *gormWaterTransactionRepository) Save(ctx context.Context, transaction *database.WaterTransaction) error {
+	return r.db.WithContext(ctx).Save(transaction).Error
+}
+
+func (r *gormWaterTransactionRepository) Delete(ctx context.Context, id uint) error {
+	return gormDelete[database.WaterTransaction](ctx, r.db, id)
+}
+
+func (r *gormWaterTransactionRepository) Totals(ctx context.Context, userID *uint) (int64, int64, error) {
+	query := r.db.WithContext(ctx).Model(&database.WaterTransaction{})
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+
+	var totals struct {
+		Fillings int64
+		Volume   int64
+	}
+	if err := query.Select("count(*) AS fillings, coalesce(sum(volume), 0) AS volume").Scan(&totals).Error; err != nil {
+		return 0, 0, err
+	}
+	return totals.Fillings, totals.Volume, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// ListQuery orders a list by a column and the ID and selects a window of it, either
+// by Offset or, for stable paging of changing data, by the row After which to continue.
+// The zero value lists all rows ordered by ID.
+type ListQuery struct {
+	// Sort is a column name, callers only pass columns they allow sorting by
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+	After  *Cursor
+}
+
+// Cursor is the position of a row in the sort order of a list
+type Cursor struct {
+	// Value is the JSON encoded sort column of the row
+	Value json.RawMessage `json:"v"`
+	ID    uint            `json:"id"`
+}
+
+// sortColumn returns the ordering column of the query, the ID if none is given
+func (query ListQuery) sortColumn() string {
+	if query.Sort == "" {
+		return "id"
+	}
+	return query.Sort
+}
+
+var schemaCache sync.Map
+
+// columnField looks up the field of a model by its column name
+func columnField[T any](column string) (*schema.Field, error) {
+	s, err := schema.Parse(new(T), &schemaCache, schema.NamingStrategy{})
+	if err != nil {
+		return nil, err
+	}
+	field := s.LookUpField(column)
+	if field == nil || field.DBName == "" {
+		return nil, fmt.Errorf("unknown column %s", column)
+	}
+	return field, nil
+}
+
+// CursorOf returns the position of the row in the sort order of the query
+func CursorOf[T any](row *T, query ListQuery) (*Cursor, error) {
+	field, err := columnField[T](query.sortColumn())
+	if err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(field.ReflectValueOf(context.Background(), reflect.ValueOf(row).Elem()).Interface())
+	if err != nil {
+		return nil, err
+	}
+	id, _ := reflect.ValueOf(row).Elem().FieldByName("ID").Interface().(uint)
+	return &Cursor{Value: value, ID: id}, nil
+}
+
+// CheckCursor reports whether the cursor of the query has a value of the sort column type
+func CheckCursor[T any](query ListQuery) error {
+	if query.After == nil {
+		return nil
+	}
+	_, err := cursorValue[T](query)
+	return err
+}
+
+// cursorValue decodes the sort value of the cursor into the type of the sort column
+func cursorValue[T any](query ListQuery) (reflect.Value, error) {
+	field, err := columnField[T](query.sortColumn())
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	value := reflect.New(field.FieldType)
+	if err := json.Unmarshal(query.After.Value, value.Interface()); err != nil {
+		return reflect.Value{}, fmt.Errorf("invalid cursor value for %s: %w", field.DBName, err)
+	}
+	return value.Elem(), nil
+}
+
+// PageRows applies the query to rows ordered by ID and returns the window and the
+// number of all rows, like the database does for gormList
+func PageRows[T any](rows []T, query ListQuery) ([]T, int64, error) {
+	field, err := columnField[T](query.sortColumn())
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx := context.Background()
+	value := func(row *T) reflect.Value { return field.ReflectValueOf(ctx, reflect.ValueOf(row).Elem()) }
+	id := func(row *T) uint { return reflect.ValueOf(row).Elem().FieldByName("ID").Interface().(uint) }
+	// compare orders by the sort column and then the ID, both in the direction of the query
+	compare := func(aValue reflect.Value, aID uint, bValue reflect.Value, bID uint) int {
+		order := compareValues(aValue, bValue)
+		if order == 0 {
+			order = compareValues(reflect.ValueOf(aID), reflect.ValueOf(bID))
+		}
+		if query.Desc {
+			return -order
+		}
+		return order
+	}
+
+	total := int64(len(rows))
+	sorted := append([]T(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compare(value(&sorted[i]), id(&sorted[i]), value(&sorted[j]), id(&sorted[j])) < 0
+	})
+
+	start := min(query.Offset, len(sorted))
+	if query.After != nil {
+		after, err := cursorValue[T](query)
+		if err != nil {
+			return nil, 0, err
+		}
+		start = sort.Search(len(sorted), func(i int) bool {
+			return compare(value(&sorted[i]), id(&sorted[i]), after, query.After.ID) > 0
+		})
+	}
+	end := len(sorted)
+	if query.Limit > 0 {
+		end = min(start+query.Limit, end)
+	}
+	return sorted[start:end], total, nil
+}
+
+// compareValues orders two values of the same sortable type, nil pointers first
+func compareValues(a, b reflect.Value) int {
+	if a.Kind() == reflect.Pointer {
+		switch {
+		case a.IsNil() && b.IsNil():
+			return 0
+		case a.IsNil():
+			return -1
+		case b.IsNil():
+			return 1
+		}
+		return compareValues(a.Elem(), b.Elem())
+	}
+	if t, ok := a.Interface().(time.Time); ok {
+		return t.Compare(b.Interface().(time.Time))
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmp.Compare(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return cmp.Compare(a.Float(), b.Float())
+	case reflect.Bool:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+	}
+	panic(fmt.Sprintf("cannot sort by values of type %s", a.Type()))
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+// table is an in-memory table of records with auto incremented IDs
+type table[T any] struct {
+	mu     sync.RWMutex
+	rows   map[uint]T
+	nextID uint
+	id     func(*T) *uint
+}
+
+func newTable[T any](id func(*T) *uint) *table[T] {
+	return &table[T]{rows: map[uint]T{}, nextID: 1, id: id}
+}
+
+func userID(user *database.User) *uint                                 { return &user.ID }
+func bottleID(bottle *database.Bottle) *uint                           { return &bottle.ID }
+func stationID(station *database.RefillStation) *uint                  { return &station.ID }
+func reviewID(review *database.RefillStationReview) *uint              { return &review.ID }
+func problemID(problem *database.RefillStationProblem) *uint           { return &problem.ID }
+func transactionID(transaction *database.WaterTransaction) *uint       { return &transaction.ID }
+func likeID(like *database.Like) *uint                                 { return &like.ID }
+func refreshTokenID(token *database.RefreshToken) *uint                { return &token.ID }
+func imageID(image *database.Image) *uint                              { return &image.ID }
+func dispenseID(dispense *database.Dispense) *uint                     { return &dispense.ID }
+func deviceID(device *database.Device) *uint                           { return &device.ID }
+func deviceKeyID(key *database.DeviceKey) *uint                        { return &key.ID }
+func telemetryID(telemetry *database.Telemetry) *uint                  { return &telemetry.ID }
+func statusChangeID(change *database.StationStatusChange) *uint        { return &change.ID }
+func problemStatusChangeID(change *database.ProblemStatusChange) *uint { return &change.ID }
+
+// list returns copies of all rows accepted by match ordered by ID, a nil match accepts all rows
+func (t *table[T]) list(match func(*T) bool) []T {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.listLocked(match)
+}
+
+func (t *table[T]) listLocked(match func(*T) bool) []T {
+	rows := []T{}
+	for _, row := range t.rows {
+		if match == nil || match(&row) {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return *t.id(&rows[i]) < *t.id(&rows[j]) })
+	return rows
+}
+
+func (t *table[T]) get(id uint) (*T, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	row, ok := t.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &row, nil
+}
+
+// find returns the row with the lowest ID accepted by match
+func (t *table[T]) find(match func(*T) bool) (*T, error) {
+	rows := t.list(match)
+	if len(rows) == 0 {
+		return nil, ErrNotFound
+	}
+	return &rows[0], nil
+}
+
+func (t *table[T]) count(match func(*T) bool) int64 {
+	return int64(len(t.list(match)))
+}
+
+// insert assigns a new ID unless one is set and stores the row. check is
+// called with all stored rows under the write lock to enforce uniqueness.
+func (t *table[T]) insert(row *T, check func(existing []T) error) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if check != nil {
+		if err := check(t.listLocked(nil)); err != nil {
+			return err
+		}
+	}
+	t.storeLocked(row)
+	return nil
+}
+
+// save replaces the row with the same ID or inserts it if the ID is zero
+func (t *table[T]) save(row *T) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.storeLocked(row)
+}
+
+func (t *table[T]) storeLocked(row *T) {
+	id := t.id(row)
+	if *id == 0 {
+		*id = t.nextID
+	}
+	if *id >= t.nextID {
+		t.nextID = *id + 1
+	}
+	t.rows[*id] = *row
+}
+
+// update applies the non-zero fields of changes to the stored row with the same ID
+func (t *table[T]) update(changes *T, validate func(*T) error) (*T, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	row, ok := t.rows[*t.id(changes)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	mergeNonZero(&row, changes)
+	if validate != nil {
+		if err := validate(&row); err != nil {
+			return nil, err
+		}
+	}
+	t.rows[*t.id(&row)] = row
+	return &row, nil
+}
+
+func (t *table[T]) delete(id uint) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.rows[id]; !ok {
+		return ErrNotFound
+	}
+	delete(t.rows, id)
+	return nil
+}
+
+// mergeNonZero copies all non-zero fields except associations from src to dst
+func mergeNonZero[T any](dst, src *T) {
+	dstValue := reflect.ValueOf(dst).Elem()
+	srcValue := reflect.ValueOf(src).Elem()
+	for i := 0; i < srcValue.NumField(); i++ {
+		field := srcValue.Field(i)
+		if field.Kind() == reflect.Slice || field.IsZero() {
+			continue
+		}
+		dstValue.Field(i).Set(field)
+	}
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryBottleRepository struct {
+	table *table[database.Bottle]
+}
+
+func (r *memoryBottleRepository) List(ctx context.Context, query ListQuery) ([]database.Bottle, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryBottleRepository) ListByUser(ctx context.Context, userID uint) ([]database.Bottle, error) {
+	return r.table.list(func(bottle *database.Bottle) bool { return bottle.UserID == userID }), nil
+}
+
+func (r *memoryBottleRepository) Get(ctx context.Context, id uint) (*database.Bottle, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryBottleRepository) GetByNFCID(ctx context.Context, nfcID string) (*database.Bottle, error) {
+	return r.table.find(func(bottle *database.Bottle) bool { return bottle.NFCID == nfcID })
+}
+
+func (r *memoryBottleRepository) Create(ctx context.Context, bottle *database.Bottle) error {
+	if err := bottle.Validate(); err != nil {
+		return err
+	}
+	// Column default of the active flag, which also replaces false on insert
+	bottle.Active = true
+	return r.table.insert(bottle, func(existing []database.Bottle) error {
+		for _, other := range existing {
+			if bottle.NFCID != "" && other.NFCID == bottle.NFCID {
+				return database.ErrNFCIDExists(bottle.NFCID)
+			}
+		}
+		return nil
+	})
+}
+
+func (r *memoryBottleRepository) Update(ctx context.Context, changes *database.Bottle) (*database.Bottle, error) {
+	return r.table.update(changes, func(bottle *database.Bottle) error {
+		bottle.NFCID = changes.NFCID
+		return nil
+	})
+}
+
+func (r *memoryBottleRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
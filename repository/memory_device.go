@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryDeviceRepository struct {
+	table *table[database.Device]
+	keys  *table[database.DeviceKey]
+}
+
+func (r *memoryDeviceRepository) Get(ctx context.Context, id uint) (*database.Device, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryDeviceRepository) ListByStation(ctx context.Context, stationID uint) ([]database.Device, error) {
+	return r.table.list(func(device *database.Device) bool {
+		return device.StationID == stationID
+	}), nil
+}
+
+func (r *memoryDeviceRepository) Create(ctx context.Context, device *database.Device, key *database.DeviceKey) error {
+	now := time.Now()
+	if device.CreatedAt.IsZero() {
+		device.CreatedAt = now
+	}
+	if err := r.table.insert(device, nil); err != nil {
+		return err
+	}
+	key.DeviceID = device.ID
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = now
+	}
+	return r.keys.insert(key, nil)
+}
+
+func (r *memoryDeviceRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	device, ok := r.table.rows[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if device.RevokedAt == nil {
+		device.RevokedAt = &at
+		r.table.rows[id] = device
+	}
+	return nil
+}
+
+func (r *memoryDeviceRepository) GetKeyByHash(ctx context.Context, hash string) (*database.DeviceKey, error) {
+	return r.keys.find(func(key *database.DeviceKey) bool {
+		return key.KeyHash == hash
+	})
+}
+
+func (r *memoryDeviceRepository) ListKeys(ctx context.Context, deviceID uint) ([]database.DeviceKey, error) {
+	return r.keys.list(func(key *database.DeviceKey) bool {
+		return key.DeviceID == deviceID
+	}), nil
+}
+
+func (r *memoryDeviceRepository) RotateKey(ctx context.Context, key *database.DeviceKey, graceUntil time.Time) error {
+	if _, err := r.table.get(key.DeviceID); err != nil {
+		return err
+	}
+	if key.CreatedAt.IsZero() {
+		key.CreatedAt = time.Now()
+	}
+	r.keys.mu.Lock()
+	defer r.keys.mu.Unlock()
+	for id, other := range r.keys.rows {
+		if other.DeviceID == key.DeviceID && other.RevokedAt == nil && (other.ExpiresAt == nil || other.ExpiresAt.After(graceUntil)) {
+			other.ExpiresAt = &graceUntil
+			r.keys.rows[id] = other
+		}
+	}
+	r.keys.storeLocked(key)
+	return nil
+}
+
+func (r *memoryDeviceRepository) RevokeKey(ctx context.Context, deviceID, keyID uint, at time.Time) error {
+	r.keys.mu.Lock()
+	defer r.keys.mu.Unlock()
+	key, ok := r.keys.rows[keyID]
+	if !ok || key.DeviceID != deviceID {
+		return ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		r.keys.rows[keyID] = key
+	}
+	return nil
+}
+
+func (r *memoryDeviceRepository) ListActive(ctx context.Context) ([]database.Device, error) {
+	return r.table.list(func(device *database.Device) bool {
+		return device.RevokedAt == nil
+	}), nil
+}
+
+func (r *memoryDeviceRepository) Touch(ctx context.Context, id uint, at time.Time) error {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	device, ok := r.table.rows[id]
+	if !ok {
+		return ErrNotFound
+	}
+	device.LastSeenAt = &at
+	r.table.rows[id] = device
+	return nil
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryDispenseRepository struct {
+	table        *table[database.Dispense]
+	transactions *memoryWaterTransactionRepository
+}
+
+func (r *memoryDispenseRepository) Get(ctx context.Context, id uint) (*database.Dispense, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryDispenseRepository) Create(ctx context.Context, dispense *database.Dispense) error {
+	if dispense.CreatedAt.IsZero() {
+		dispense.CreatedAt = time.Now()
+	}
+	return r.table.insert(dispense, nil)
+}
+
+func (r *memoryDispenseRepository) Confirm(ctx context.Context, id uint, transaction *database.WaterTransaction) (*database.Dispense, error) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	dispense, ok := r.table.rows[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !dispense.Open(transaction.Timestamp) {
+		return nil, ErrDispenseClosed
+	}
+	if err := r.transactions.Create(ctx, transaction); err != nil {
+		return nil, err
+	}
+	confirmedAt := transaction.Timestamp
+	dispense.ConfirmedAt = &confirmedAt
+	dispense.TransactionID = &transaction.ID
+	r.table.rows[id] = dispense
+	return &dispense, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryImageRepository struct {
+	table *table[database.Image]
+}
+
+func (r *memoryImageRepository) Get(ctx context.Context, id uint) (*database.Image, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryImageRepository) Variants(ctx context.Context, originalID uint) ([]database.Image, error) {
+	return r.table.list(func(image *database.Image) bool {
+		return image.OriginalID != nil && *image.OriginalID == originalID
+	}), nil
+}
+
+func (r *memoryImageRepository) Create(ctx context.Context, image *database.Image) error {
+	if image.CreatedAt.IsZero() {
+		image.CreatedAt = time.Now()
+	}
+	return r.table.insert(image, nil)
+}
+
+func (r *memoryImageRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
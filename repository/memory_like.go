@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"slices"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryLikeRepository struct {
+	table *table[database.Like]
+}
+
+func (r *memoryLikeRepository) List(ctx context.Context, query ListQuery) ([]database.Like, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryLikeRepository) Get(ctx context.Context, id uint) (*database.Like, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryLikeRepository) GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.Like, error) {
+	return r.table.find(func(like *database.Like) bool {
+		return like.UserID == userID && like.StationID == stationID
+	})
+}
+
+func (r *memoryLikeRepository) CountByStation(ctx context.Context, stationID uint) (int64, error) {
+	return r.table.count(func(like *database.Like) bool { return like.StationID == stationID }), nil
+}
+
+func (r *memoryLikeRepository) Create(ctx context.Context, like *database.Like) error {
+	return r.table.insert(like, func(existing []database.Like) error {
+		for _, other := range existing {
+			if other.UserID == like.UserID && other.StationID == like.StationID {
+				return database.ErrLikeExists
+			}
+		}
+		return nil
+	})
+}
+
+func (r *memoryLikeRepository) Update(ctx context.Context, changes *database.Like) (*database.Like, error) {
+	return r.table.update(changes, nil)
+}
+
+func (r *memoryLikeRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
+
+func (r *memoryLikeRepository) CountsByStation(ctx context.Context) (map[uint]int64, error) {
+	counts := map[uint]int64{}
+	for _, like := range r.table.list(nil) {
+		counts[like.StationID]++
+	}
+	return counts, nil
+}
+
+func (r *memoryLikeRepository) ListStationIDsByUser(ctx context.Context, userID uint) ([]uint, error) {
+	stationIDs := []uint{}
+	for _, like := range r.table.list(func(like *database.Like) bool { return like.UserID == userID }) {
+		stationIDs = append(stationIDs, like.StationID)
+	}
+	slices.Sort(stationIDs)
+	return stationIDs, nil
+}
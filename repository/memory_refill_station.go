@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+)
+
+type memoryRefillStationRepository struct {
+	table   *table[database.RefillStation]
+	changes *table[database.StationStatusChange]
+}
+
+func (r *memoryRefillStationRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStation, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryRefillStationRepository) ListMarkers(ctx context.Context, box *geo.Box) ([]database.RefillStation, error) {
+	var markers []database.RefillStation
+	for _, station := range r.table.list(func(station *database.RefillStation) bool {
+		return box == nil || box.Contains(station.Latitude, station.Longitude)
+	}) {
+		markers = append(markers, database.RefillStation{
+			ID:        station.ID,
+			Latitude:  station.Latitude,
+			Longitude: station.Longitude,
+			Active:    station.Active,
+		})
+	}
+	return markers, nil
+}
+
+func (r *memoryRefillStationRepository) ListNearby(ctx context.Context, query NearbyQuery) ([]NearbyStation, error) {
+	return nearest(r.table.list(nil), query), nil
+}
+
+func (r *memoryRefillStationRepository) Get(ctx context.Context, id uint) (*database.RefillStation, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryRefillStationRepository) Create(ctx context.Context, station *database.RefillStation) error {
+	if err := station.Validate(); err != nil {
+		return err
+	}
+	// Column default of the active flag
+	if !station.Active.Valid {
+		station.Active = database.NullBool{Bool: true, Valid: true}
+	}
+	return r.table.insert(station, nil)
+}
+
+func (r *memoryRefillStationRepository) Install(ctx context.Context, station *database.RefillStation, change *database.StationStatusChange) error {
+	if err := r.Create(ctx, station); err != nil {
+		return err
+	}
+	change.StationID = station.ID
+	change.Active = station.Active.Bool
+	r.changes.save(change)
+	return nil
+}
+
+func (r *memoryRefillStationRepository) Update(ctx context.Context, changes *database.RefillStation) (*database.RefillStation, error) {
+	return r.UpdateWithStatus(ctx, changes, nil)
+}
+
+func (r *memoryRefillStationRepository) UpdateWithStatus(ctx context.Context, changes *database.RefillStation, status *database.StationStatusChange) (*database.RefillStation, error) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	station, ok := r.table.rows[changes.ID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	mergeNonZero(&station, changes)
+	// Opening times without opening hours did not parse, the stored hours belong to the old ones
+	if changes.OpeningTimes != "" && changes.OpeningHours == nil {
+		station.OpeningHours = nil
+	}
+	if status != nil && applyActive(status)(&station) {
+		r.changes.save(status)
+	}
+	r.table.rows[station.ID] = station
+	return &station, nil
+}
+
+func (r *memoryRefillStationRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
+
+func (r *memoryRefillStationRepository) CountByType(ctx context.Context, stationType string) (int64, error) {
+	return r.table.count(func(station *database.RefillStation) bool { return station.Type == stationType }), nil
+}
+
+func (r *memoryRefillStationRepository) SetActive(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(change, applyActive(change))
+}
+
+// applyActive returns the apply function of SetActive for changeStatus
+func applyActive(change *database.StationStatusChange) func(*database.RefillStation) bool {
+	return func(station *database.RefillStation) bool {
+		changed := station.Active != database.NullBool{Bool: change.Active, Valid: true}
+		station.Active = database.NullBool{Bool: change.Active, Valid: true}
+		station.OfflineSince = nil
+		return changed
+	}
+}
+
+func (r *memoryRefillStationRepository) MarkOffline(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(change, func(station *database.RefillStation) bool {
+		if !station.Active.Bool {
+			return false
+		}
+		station.Active = database.NullBool{Bool: false, Valid: true}
+		station.OfflineSince = &change.ChangedAt
+		return true
+	})
+}
+
+func (r *memoryRefillStationRepository) MarkOnline(ctx context.Context, change *database.StationStatusChange) (bool, error) {
+	return r.changeStatus(change, func(station *database.RefillStation) bool {
+		if station.OfflineSince == nil {
+			return false
+		}
+		station.Active = database.NullBool{Bool: true, Valid: true}
+		station.OfflineSince = nil
+		return true
+	})
+}
+
+// changeStatus applies apply to the station of the change and records the change if
+// apply reports that the active state changed
+func (r *memoryRefillStationRepository) changeStatus(change *database.StationStatusChange, apply func(*database.RefillStation) bool) (bool, error) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	station, ok := r.table.rows[change.StationID]
+	if !ok {
+		return false, ErrNotFound
+	}
+	changed := apply(&station)
+	r.table.rows[station.ID] = station
+	if changed {
+		r.changes.save(change)
+	}
+	return changed, nil
+}
+
+func (r *memoryRefillStationRepository) ListStatusChanges(ctx context.Context, stationID uint, from, to time.Time) ([]database.StationStatusChange, error) {
+	changes := r.changes.list(func(change *database.StationStatusChange) bool {
+		return change.StationID == stationID && !change.ChangedAt.Before(from) && change.ChangedAt.Before(to)
+	})
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].ChangedAt.Before(changes[j].ChangedAt) })
+	return changes, nil
+}
+
+func (r *memoryRefillStationRepository) LastStatusChangeBefore(ctx context.Context, stationID uint, before time.Time) (*database.StationStatusChange, error) {
+	changes, _ := r.ListStatusChanges(ctx, stationID, time.Time{}, before)
+	if len(changes) == 0 {
+		return nil, ErrNotFound
+	}
+	return &changes[len(changes)-1], nil
+}
+
+func (r *memoryRefillStationRepository) Search(ctx context.Context, filter StationFilter) ([]database.RefillStation, error) {
+	text := strings.ToLower(filter.Text)
+	stations := r.table.list(func(station *database.RefillStation) bool {
+		return (filter.Type == "" || station.Type == filter.Type) &&
+			(filter.WaterType == "" || slices.Contains(offeringWaterType(filter.WaterType), station.OfferedWaterTypes)) &&
+			(filter.Active == nil || (station.Active.Valid && station.Active.Bool == *filter.Active)) &&
+			(text == "" || strings.Contains(strings.ToLower(station.Name), text) ||
+				strings.Contains(strings.ToLower(station.Description), text) ||
+				strings.Contains(strings.ToLower(station.Address), text)) &&
+			(filter.Box == nil || filter.Box.Contains(station.Latitude, station.Longitude))
+	})
+	return stations, nil
+}
+
+func (r *memoryRefillStationRepository) TextSearch(ctx context.Context, query string, limit int) ([]ScoredStation, error) {
+	return searchStations(r.table.list(nil), query, limit), nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryRefillStationProblemRepository struct {
+	table   *table[database.RefillStationProblem]
+	changes *table[database.ProblemStatusChange]
+	// stations are deactivated and activated again by their problems
+	stations *memoryRefillStationRepository
+}
+
+func (r *memoryRefillStationProblemRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStationProblem, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryRefillStationProblemRepository) Get(ctx context.Context, id uint) (*database.RefillStationProblem, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryRefillStationProblemRepository) Create(ctx context.Context, problem *database.RefillStationProblem) error {
+	if err := problem.Validate(); err != nil {
+		return err
+	}
+	if problem.Timestamp.IsZero() {
+		problem.Timestamp = time.Now()
+	}
+	return r.table.insert(problem, nil)
+}
+
+func (r *memoryRefillStationProblemRepository) Report(ctx context.Context, problem *database.RefillStationProblem, actorID *uint) error {
+	if problem.DeactivateStation {
+		if _, err := r.stations.Get(ctx, problem.StationID); err != nil {
+			return err
+		}
+	}
+	if err := r.Create(ctx, problem); err != nil {
+		return err
+	}
+	r.changes.save(&database.ProblemStatusChange{
+		ProblemID: problem.ID,
+		ToStatus:  problem.Status,
+		ActorID:   actorID,
+		ChangedAt: problem.Timestamp,
+	})
+	if problem.DeactivateStation {
+		change := problem.StationStatusChange(actorID, problem.Timestamp)
+		r.stations.changeStatus(change, applyActive(change))
+	}
+	return nil
+}
+
+func (r *memoryRefillStationProblemRepository) Update(ctx context.Context, changes *database.RefillStationProblem) (*database.RefillStationProblem, error) {
+	return r.table.update(changes, nil)
+}
+
+func (r *memoryRefillStationProblemRepository) Transition(ctx context.Context, change *database.ProblemStatusChange, changes *database.RefillStationProblem) (*database.RefillStationProblem, error) {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	problem, ok := r.table.rows[change.ProblemID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if problem.Status != change.FromStatus {
+		return nil, ErrProblemStatusChanged
+	}
+	_, station := transition(&problem, change, changes)
+	if station != nil {
+		if _, err := r.stations.Get(ctx, station.StationID); err != nil {
+			return nil, err
+		}
+		if station.Active && !r.stationReleased(&problem) {
+			station = nil
+		}
+	}
+	r.table.rows[problem.ID] = problem
+	r.changes.save(change)
+	if station != nil {
+		r.stations.changeStatus(station, applyActive(station))
+	}
+	return &problem, nil
+}
+
+// stationReleased reports whether the station of the solved or closed problem was deactivated
+// by problems and no other open problem keeps it deactivated, the problems have to be locked
+func (r *memoryRefillStationProblemRepository) stationReleased(problem *database.RefillStationProblem) bool {
+	changes := r.stations.changes.list(func(change *database.StationStatusChange) bool {
+		return change.StationID == problem.StationID
+	})
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].ChangedAt.Before(changes[j].ChangedAt) })
+	if len(changes) == 0 || changes[len(changes)-1].Active || changes[len(changes)-1].Source != database.StatusSourceProblem {
+		return false
+	}
+	for _, other := range r.table.rows {
+		if other.StationID == problem.StationID && other.ID != problem.ID && other.DeactivateStation && other.IsOpen() {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *memoryRefillStationProblemRepository) ListStatusChanges(ctx context.Context, problemID uint) ([]database.ProblemStatusChange, error) {
+	changes := r.changes.list(func(change *database.ProblemStatusChange) bool {
+		return change.ProblemID == problemID
+	})
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].ChangedAt.Before(changes[j].ChangedAt) })
+	return changes, nil
+}
+
+func (r *memoryRefillStationProblemRepository) Delete(ctx context.Context, id uint, actorID *uint) error {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	problem, ok := r.table.rows[id]
+	if !ok {
+		return ErrNotFound
+	}
+	delete(r.table.rows, id)
+	if station := deletion(&problem, actorID); station != nil && r.stationReleased(&problem) {
+		r.stations.changeStatus(station, applyActive(station))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryRefillStationReviewRepository struct {
+	table *table[database.RefillStationReview]
+}
+
+func (r *memoryRefillStationReviewRepository) List(ctx context.Context, query ListQuery) ([]database.RefillStationReview, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryRefillStationReviewRepository) ListByStation(ctx context.Context, stationID uint) ([]database.RefillStationReview, error) {
+	return r.table.list(func(review *database.RefillStationReview) bool { return review.StationID == stationID }), nil
+}
+
+func (r *memoryRefillStationReviewRepository) Get(ctx context.Context, id uint) (*database.RefillStationReview, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryRefillStationReviewRepository) GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.RefillStationReview, error) {
+	return r.table.find(func(review *database.RefillStationReview) bool {
+		return review.UserID == userID && review.StationID == stationID
+	})
+}
+
+func (r *memoryRefillStationReviewRepository) Create(ctx context.Context, review *database.RefillStationReview) error {
+	if err := review.Validate(); err != nil {
+		return err
+	}
+	if review.Timestamp.IsZero() {
+		review.Timestamp = time.Now()
+	}
+	return r.table.insert(review, nil)
+}
+
+func (r *memoryRefillStationReviewRepository) Save(ctx context.Context, review *database.RefillStationReview) error {
+	if err := review.Validate(); err != nil {
+		return err
+	}
+	r.table.save(review)
+	return nil
+}
+
+func (r *memoryRefillStationReviewRepository) Update(ctx context.Context, changes *database.RefillStationReview) (*database.RefillStationReview, error) {
+	return r.table.update(changes, func(review *database.RefillStationReview) error { return review.Validate() })
+}
+
+func (r *memoryRefillStationReviewRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
+
+func (r *memoryRefillStationReviewRepository) AveragesByStation(ctx context.Context) (map[uint]ReviewAverages, error) {
+	averages := map[uint]ReviewAverages{}
+	for _, review := range r.table.list(nil) {
+		average := averages[review.StationID]
+		average.Count++
+		average.Cleanness += float64(review.Cleanness)
+		average.Accessibility += float64(review.Accessibility)
+		average.WaterQuality += float64(review.WaterQuality)
+		averages[review.StationID] = average
+	}
+	for stationID, average := range averages {
+		count := float64(average.Count)
+		average.Cleanness /= count
+		average.Accessibility /= count
+		average.WaterQuality /= count
+		averages[stationID] = average
+	}
+	return averages, nil
+}
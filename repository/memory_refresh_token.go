@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryRefreshTokenRepository struct {
+	table *table[database.RefreshToken]
+}
+
+func (r *memoryRefreshTokenRepository) Get(ctx context.Context, id uint) (*database.RefreshToken, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryRefreshTokenRepository) GetByHash(ctx context.Context, hash string) (*database.RefreshToken, error) {
+	return r.table.find(func(token *database.RefreshToken) bool {
+		return token.TokenHash == hash
+	})
+}
+
+func (r *memoryRefreshTokenRepository) Create(ctx context.Context, token *database.RefreshToken) error {
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = time.Now()
+	}
+	return r.table.insert(token, nil)
+}
+
+func (r *memoryRefreshTokenRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	token, ok := r.table.rows[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if token.RevokedAt != nil {
+		return ErrTokenRevoked
+	}
+	token.RevokedAt = &at
+	r.table.rows[id] = token
+	return nil
+}
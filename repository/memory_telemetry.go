@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryTelemetryRepository struct {
+	table *table[database.Telemetry]
+}
+
+func (r *memoryTelemetryRepository) Create(ctx context.Context, telemetry *database.Telemetry) error {
+	if telemetry.CreatedAt.IsZero() {
+		telemetry.CreatedAt = time.Now()
+	}
+	return r.table.insert(telemetry, nil)
+}
+
+func (r *memoryTelemetryRepository) ListByStation(ctx context.Context, stationID uint, from, to time.Time, limit int) ([]database.Telemetry, error) {
+	reports := r.table.list(func(telemetry *database.Telemetry) bool {
+		return telemetry.StationID == stationID && !telemetry.RecordedAt.Before(from) && telemetry.RecordedAt.Before(to)
+	})
+	sort.SliceStable(reports, func(i, j int) bool { return reports[i].RecordedAt.Before(reports[j].RecordedAt) })
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+	return reports, nil
+}
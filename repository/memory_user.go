@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryUserRepository struct {
+	table *table[database.User]
+}
+
+func (r *memoryUserRepository) List(ctx context.Context, query ListQuery) ([]database.User, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryUserRepository) Get(ctx context.Context, id uint) (*database.User, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*database.User, error) {
+	return r.table.find(func(user *database.User) bool {
+		return user.Email != nil && *user.Email == email
+	})
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *database.User) error {
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	return r.table.insert(user, func(existing []database.User) error {
+		return checkUniqueEmail(existing, user)
+	})
+}
+
+func (r *memoryUserRepository) Update(ctx context.Context, changes *database.User) (*database.User, error) {
+	profile := *changes
+	profile.PasswordHash, profile.Role = "", ""
+	return r.table.update(&profile, func(user *database.User) error {
+		return checkUniqueEmail(r.table.listLocked(nil), user)
+	})
+}
+
+func (r *memoryUserRepository) SetRole(ctx context.Context, id uint, role string) error {
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	user, ok := r.table.rows[id]
+	if !ok {
+		return ErrNotFound
+	}
+	user.Role = role
+	if err := user.Validate(); err != nil {
+		return err
+	}
+	r.table.rows[id] = user
+	return nil
+}
+
+// checkUniqueEmail enforces the unique constraint on the email column
+func checkUniqueEmail(existing []database.User, user *database.User) error {
+	if user.Email == nil {
+		return nil
+	}
+	for _, other := range existing {
+		if other.ID != user.ID && other.Email != nil && *other.Email == *user.Email {
+			return database.ErrEmailExists
+		}
+	}
+	return nil
+}
+
+func (r *memoryUserRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
+
+func (r *memoryUserRepository) Count(ctx context.Context) (int64, error) {
+	return r.table.count(nil), nil
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+type memoryWaterTransactionRepository struct {
+	table *table[database.WaterTransaction]
+}
+
+func (r *memoryWaterTransactionRepository) List(ctx context.Context, query ListQuery) ([]database.WaterTransaction, int64, error) {
+	return PageRows(r.table.list(nil), query)
+}
+
+func (r *memoryWaterTransactionRepository) Get(ctx context.Context, id uint) (*database.WaterTransaction, error) {
+	return r.table.get(id)
+}
+
+func (r *memoryWaterTransactionRepository) Create(ctx context.Context, transaction *database.WaterTransaction) error {
+	if err := transaction.Validate(); err != nil {
+		return err
+	}
+	if transaction.Timestamp.IsZero() {
+		transaction.Timestamp = time.Now()
+	}
+	return r.table.insert(transaction, nil)
+}
+
+func (r *memoryWaterTransactionRepository) CreateOnce(ctx context.Context, transaction *database.WaterTransaction) (*database.WaterTransaction, bool, error) {
+	if err := transaction.Validate(); err != nil {
+		return nil, false, err
+	}
+	if transaction.Timestamp.IsZero() {
+		transaction.Timestamp = time.Now()
+	}
+	r.table.mu.Lock()
+	defer r.table.mu.Unlock()
+	if transaction.IdempotencyKey != nil {
+		for _, stored := range r.table.rows {
+			if stored.StationID == transaction.StationID && stored.IdempotencyKey != nil && *stored.IdempotencyKey == *transaction.IdempotencyKey {
+				return &stored, false, nil
+			}
+		}
+	}
+	r.table.storeLocked(transaction)
+	return transaction, true, nil
+}
+
+func (r *memoryWaterTransactionRepository) Save(ctx context.Context, transaction *database.WaterTransaction) error {
+	if transaction.ID == 0 {
+		return r.Create(ctx, transaction)
+	}
+	r.table.save(transaction)
+	return nil
+}
+
+func (r *memoryWaterTransactionRepository) Delete(ctx context.Context, id uint) error {
+	return r.table.delete(id)
+}
+
+func (r *memoryWaterTransactionRepository) Totals(ctx context.Context, userID *uint) (int64, int64, error) {
+	transactions := r.table.list(func(transaction *database.WaterTransaction) bool {
+		return userID == nil || (transaction.UserID != nil && *transaction.UserID == *userID)
+	})
+	var volume int64
+	for _, transaction := range transactions {
+		volume += int64(transaction.Volume)
+	}
+	return int64(len(transactions)), volume, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+)
+
+// nearest measures the distance of the candidate stations to the queried position
+// and returns those within the radius, nearest first and at most query.Limit
+func nearest(candidates []database.RefillStation, query NearbyQuery) []NearbyStation {
+	stations := []NearbyStation{}
+	for _, station := range candidates {
+		distance := geo.Distance(query.Latitude, query.Longitude, station.Latitude, station.Longitude)
+		if distance <= query.RadiusMeters {
+			stations = append(stations, NearbyStation{RefillStation: station, DistanceMeters: distance})
+		}
+	}
+	sort.SliceStable(stations, func(i, j int) bool {
+		if stations[i].DistanceMeters != stations[j].DistanceMeters {
+			return stations[i].DistanceMeters < stations[j].DistanceMeters
+		}
+		return stations[i].ID < stations[j].ID
+	})
+	if query.Limit > 0 && len(stations) > query.Limit {
+		stations = stations[:query.Limit]
+	}
+	return stations
+}
+
+// offeringWaterType returns the offered water types of the stations providing the water type
+func offeringWaterType(waterType string) []string {
+	if waterType == "both" {
+		return []string{"both"}
+	}
+	return []string{waterType, "both"}
+}
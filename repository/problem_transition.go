@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+)
+
+// transition applies the change and the other non-zero fields of changes to the problem as
+// loaded before the change. It returns the fields left to store and the change of the station,
+// if the problem deactivates it or, once solved or closed, might activate it again.
+func transition(problem *database.RefillStationProblem, change *database.ProblemStatusChange, changes *database.RefillStationProblem) (*database.RefillStationProblem, *database.StationStatusChange) {
+	var fields *database.RefillStationProblem
+	if changes != nil {
+		copied := *changes
+		copied.ID, copied.Status, copied.DeactivateStation = problem.ID, "", false
+		mergeNonZero(problem, &copied)
+		fields = &copied
+	}
+	requested := changes != nil && changes.DeactivateStation
+	// An open problem keeps the station deactivated until it is solved or closed
+	deactivated := problem.DeactivateStation && problem.IsOpen()
+	problem.Status = change.ToStatus
+	problem.DeactivateStation = problem.IsOpen() && (requested || deactivated)
+
+	if problem.IsOpen() && requested || !problem.IsOpen() && deactivated {
+		return fields, problem.StationStatusChange(change.ActorID, change.ChangedAt)
+	}
+	return fields, nil
+}
+
+// deletion returns the activation of the station of the problem deleted by the actor, if the
+// problem kept it deactivated. The problem is gone, so the change does not refer to it.
+func deletion(problem *database.RefillStationProblem, actorID *uint) *database.StationStatusChange {
+	if !problem.DeactivateStation || !problem.IsOpen() {
+		return nil
+	}
+	return &database.StationStatusChange{
+		StationID: problem.StationID,
+		Active:    true,
+		Source:    database.StatusSourceProblem,
+		Reason:    fmt.Sprintf("Problem %d deleted", problem.ID),
+		ActorID:   actorID,
+		ChangedAt: time.Now(),
+	}
+}
@@ -0,0 +1,304 @@
+// Package repository hides the storage of the models behind one interface per
+// aggregate. The gorm implementations back the production server, the memory
+// implementations allow running the complete API without a database.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/geo"
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+// Repositories bundles the repositories of all aggregates
+type Repositories struct {
+	Users        UserRepository
+	Bottles      BottleRepository
+	Stations     RefillStationRepository
+	Reviews      RefillStationReviewRepository
+	Problems     RefillStationProblemRepository
+	Transactions WaterTransactionRepository
+	Likes        LikeRepository
+	Tokens       RefreshTokenRepository
+	Images       ImageRepository
+	Dispenses    DispenseRepository
+	Devices      DeviceRepository
+	Telemetry    TelemetryRepository
+}
+
+// NewGormRepositories creates repositories backed by the given database
+func NewGormRepositories(db *gorm.DB) Repositories {
+	return Repositories{
+		Users:        &gormUserRepository{db: db},
+		Bottles:      &gormBottleRepository{db: db},
+		Stations:     &gormRefillStationRepository{db: db},
+		Reviews:      &gormRefillStationReviewRepository{db: db},
+		Problems:     &gormRefillStationProblemRepository{db: db},
+		Transactions: &gormWaterTransactionRepository{db: db},
+		Likes:        &gormLikeRepository{db: db},
+		Tokens:       &gormRefreshTokenRepository{db: db},
+		Images:       &gormImageRepository{db: db},
+		Dispenses:    &gormDispenseRepository{db: db},
+		Devices:      &gormDeviceRepository{db: db},
+		Telemetry:    &gormTelemetryRepository{db: db},
+	}
+}
+
+// NewMemoryRepositories creates empty repositories that keep all records in memory
+func NewMemoryRepositories() Repositories {
+	transactions := &memoryWaterTransactionRepository{table: newTable(transactionID)}
+	stations := &memoryRefillStationRepository{table: newTable(stationID), changes: newTable(statusChangeID)}
+	return Repositories{
+		Users:        &memoryUserRepository{table: newTable(userID)},
+		Bottles:      &memoryBottleRepository{table: newTable(bottleID)},
+		Stations:     stations,
+		Reviews:      &memoryRefillStationReviewRepository{table: newTable(reviewID)},
+		Problems:     &memoryRefillStationProblemRepository{table: newTable(problemID), changes: newTable(problemStatusChangeID), stations: stations},
+		Transactions: transactions,
+		Likes:        &memoryLikeRepository{table: newTable(likeID)},
+		Tokens:       &memoryRefreshTokenRepository{table: newTable(refreshTokenID)},
+		Images:       &memoryImageRepository{table: newTable(imageID)},
+		Dispenses:    &memoryDispenseRepository{table: newTable(dispenseID), transactions: transactions},
+		Devices:      &memoryDeviceRepository{table: newTable(deviceID), keys: newTable(deviceKeyID)},
+		Telemetry:    &memoryTelemetryRepository{table: newTable(telemetryID)},
+	}
+}
+
+// Update methods apply all non-zero fields of the given record to the stored
+// record with the same ID, matching gorm's Updates, and return the stored record.
+
+type UserRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.User, int64, error)
+	Get(ctx context.Context, id uint) (*database.User, error)
+	GetByEmail(ctx context.Context, email string) (*database.User, error)
+	Create(ctx context.Context, user *database.User) error
+	// Update applies the non-zero fields of the changes except the password hash and the role
+	Update(ctx context.Context, changes *database.User) (*database.User, error)
+	SetRole(ctx context.Context, id uint, role string) error
+	Delete(ctx context.Context, id uint) error
+	Count(ctx context.Context) (int64, error)
+}
+
+type BottleRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.Bottle, int64, error)
+	ListByUser(ctx context.Context, userID uint) ([]database.Bottle, error)
+	Get(ctx context.Context, id uint) (*database.Bottle, error)
+	GetByNFCID(ctx context.Context, nfcID string) (*database.Bottle, error)
+	Create(ctx context.Context, bottle *database.Bottle) error
+	// Update also stores an empty NFC ID, which unassigns the chip from the bottle
+	Update(ctx context.Context, bottle *database.Bottle) (*database.Bottle, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+type RefillStationRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.RefillStation, int64, error)
+	// ListMarkers returns the stations within the box, or all if it is nil, with only ID, position and active state loaded
+	ListMarkers(ctx context.Context, box *geo.Box) ([]database.RefillStation, error)
+	// ListNearby returns the stations within the radius of the query ordered by distance, nearest first
+	ListNearby(ctx context.Context, query NearbyQuery) ([]NearbyStation, error)
+	// Search returns the stations matching the filter ordered by ID
+	Search(ctx context.Context, filter StationFilter) ([]database.RefillStation, error)
+	// TextSearch returns at most limit stations matching every word of the query in their
+	// name, address, water source or description, best match first
+	TextSearch(ctx context.Context, query string, limit int) ([]ScoredStation, error)
+	Get(ctx context.Context, id uint) (*database.RefillStation, error)
+	Create(ctx context.Context, station *database.RefillStation) error
+	// Install creates the station and starts its status history with the change, which gets
+	// the ID and the initial active state of the station
+	Install(ctx context.Context, station *database.RefillStation, change *database.StationStatusChange) error
+	Update(ctx context.Context, station *database.RefillStation) (*database.RefillStation, error)
+	// UpdateWithStatus applies the non-zero fields of the station and, unless status is nil,
+	// sets the active state like SetActive in one transaction
+	UpdateWithStatus(ctx context.Context, station *database.RefillStation, status *database.StationStatusChange) (*database.RefillStation, error)
+	Delete(ctx context.Context, id uint) error
+	CountByType(ctx context.Context, stationType string) (int64, error)
+	// SetActive sets the active state of the station of the change and clears its offline
+	// time, so heartbeats no longer activate it. The change is recorded if the state changed.
+	SetActive(ctx context.Context, change *database.StationStatusChange) (changed bool, err error)
+	// MarkOffline deactivates the station of the change unless it is inactive already, sets
+	// its offline time to the time of the change and records the change
+	MarkOffline(ctx context.Context, change *database.StationStatusChange) (changed bool, err error)
+	// MarkOnline activates the station of the change if MarkOffline deactivated it, clears
+	// its offline time and records the change
+	MarkOnline(ctx context.Context, change *database.StationStatusChange) (changed bool, err error)
+	// ListStatusChanges returns the status changes of the station from from until before
+	// to ordered by time
+	ListStatusChanges(ctx context.Context, stationID uint, from, to time.Time) ([]database.StationStatusChange, error)
+	// LastStatusChangeBefore returns the last status change of the station before the
+	// given time, ErrNotFound if there is none
+	LastStatusChangeBefore(ctx context.Context, stationID uint, before time.Time) (*database.StationStatusChange, error)
+}
+
+// NearbyQuery selects at most Limit stations within RadiusMeters of a position
+type NearbyQuery struct {
+	Latitude     float64
+	Longitude    float64
+	RadiusMeters float64
+	Limit        int
+}
+
+// NearbyStation is a station with its great-circle distance to the queried position
+type NearbyStation struct {
+	database.RefillStation
+	DistanceMeters float64 `json:"distance_m"`
+}
+
+// ScoredStation is a station with the relevance for a text search, scores are only
+// comparable within the results of one search
+type ScoredStation struct {
+	database.RefillStation
+	Score float64 `json:"score"`
+}
+
+// StationFilter selects stations by their own columns, zero fields match every station
+type StationFilter struct {
+	Type string
+	// WaterType matches the stations offering it, those offering both match mineral and tap
+	WaterType string
+	Active    *bool
+	// Text matches the name, description or address case insensitively
+	Text string
+	Box  *geo.Box
+}
+
+// ReviewAverages are the number of reviews of a station and their mean ratings
+type ReviewAverages struct {
+	Count         int64
+	Cleanness     float64
+	Accessibility float64
+	WaterQuality  float64
+}
+
+type RefillStationReviewRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.RefillStationReview, int64, error)
+	ListByStation(ctx context.Context, stationID uint) ([]database.RefillStationReview, error)
+	Get(ctx context.Context, id uint) (*database.RefillStationReview, error)
+	GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.RefillStationReview, error)
+	// AveragesByStation returns the review averages of every reviewed station by station ID
+	AveragesByStation(ctx context.Context) (map[uint]ReviewAverages, error)
+	Create(ctx context.Context, review *database.RefillStationReview) error
+	// Save stores all fields of an existing review
+	Save(ctx context.Context, review *database.RefillStationReview) error
+	Update(ctx context.Context, review *database.RefillStationReview) (*database.RefillStationReview, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// ErrProblemStatusChanged is returned when the status of a problem changed before a transition
+var ErrProblemStatusChanged = errors.New("problem status changed in the meantime")
+
+type RefillStationProblemRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.RefillStationProblem, int64, error)
+	Get(ctx context.Context, id uint) (*database.RefillStationProblem, error)
+	Create(ctx context.Context, problem *database.RefillStationProblem) error
+	// Report creates the problem and starts its timeline with its status, reported by the actor.
+	// A problem with DeactivateStation deactivates its station.
+	Report(ctx context.Context, problem *database.RefillStationProblem, actorID *uint) error
+	Update(ctx context.Context, problem *database.RefillStationProblem) (*database.RefillStationProblem, error)
+	// Transition moves the problem of the change to its new status, records the change and
+	// applies the other non-zero fields of changes, which may be nil, in one transaction.
+	// DeactivateStation of changes deactivates the station while the problem is open. Once the
+	// problem is solved or closed, the station deactivated by problems is activated again if no
+	// other open problem keeps it deactivated. ErrProblemStatusChanged is returned if the
+	// problem is no longer in the old status.
+	Transition(ctx context.Context, change *database.ProblemStatusChange, changes *database.RefillStationProblem) (*database.RefillStationProblem, error)
+	// ListStatusChanges returns the timeline of the problem ordered by time
+	ListStatusChanges(ctx context.Context, problemID uint) ([]database.ProblemStatusChange, error)
+	// Delete deletes the problem and, like solving it, activates the station it kept
+	// deactivated again if no other open problem keeps it deactivated
+	Delete(ctx context.Context, id uint, actorID *uint) error
+}
+
+type WaterTransactionRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.WaterTransaction, int64, error)
+	Get(ctx context.Context, id uint) (*database.WaterTransaction, error)
+	Create(ctx context.Context, transaction *database.WaterTransaction) error
+	// CreateOnce creates the transaction unless the station already has one with the same
+	// idempotency key, which is returned instead. Created reports whether it is new.
+	CreateOnce(ctx context.Context, transaction *database.WaterTransaction) (stored *database.WaterTransaction, created bool, err error)
+	// Save stores all fields of the transaction, creating it if the ID is zero
+	Save(ctx context.Context, transaction *database.WaterTransaction) error
+	Delete(ctx context.Context, id uint) error
+	// Totals returns the number of fillings and the summed volume, of one user if userID is set
+	Totals(ctx context.Context, userID *uint) (fillings int64, volume int64, err error)
+}
+
+type LikeRepository interface {
+	List(ctx context.Context, query ListQuery) ([]database.Like, int64, error)
+	Get(ctx context.Context, id uint) (*database.Like, error)
+	GetByUserAndStation(ctx context.Context, userID, stationID uint) (*database.Like, error)
+	CountByStation(ctx context.Context, stationID uint) (int64, error)
+	// CountsByStation returns the number of likes of every liked station by station ID
+	CountsByStation(ctx context.Context) (map[uint]int64, error)
+	ListStationIDsByUser(ctx context.Context, userID uint) ([]uint, error)
+	Create(ctx context.Context, like *database.Like) error
+	Update(ctx context.Context, like *database.Like) (*database.Like, error)
+	Delete(ctx context.Context, id uint) error
+}
+
+// ErrTokenRevoked is returned when a refresh token is revoked a second time
+var ErrTokenRevoked = errors.New("refresh token is already revoked")
+
+type RefreshTokenRepository interface {
+	Get(ctx context.Context, id uint) (*database.RefreshToken, error)
+	GetByHash(ctx context.Context, hash string) (*database.RefreshToken, error)
+	Create(ctx context.Context, token *database.RefreshToken) error
+	// Revoke marks the token as revoked at the given time, ErrTokenRevoked is returned if
+	// it already is, so only one of concurrent revocations succeeds
+	Revoke(ctx context.Context, id uint, at time.Time) error
+}
+
+// ImageRepository keeps the metadata of the images in the blob store
+type ImageRepository interface {
+	Get(ctx context.Context, id uint) (*database.Image, error)
+	// Variants lists the downscaled variants of an image
+	Variants(ctx context.Context, originalID uint) ([]database.Image, error)
+	Create(ctx context.Context, image *database.Image) error
+	Delete(ctx context.Context, id uint) error
+}
+
+// ErrDispenseClosed is returned when a dispense is confirmed after it expired or a second time
+var ErrDispenseClosed = errors.New("dispense is already confirmed or expired")
+
+// DispenseRepository keeps the dispense authorizations of smart stations
+type DispenseRepository interface {
+	Get(ctx context.Context, id uint) (*database.Dispense, error)
+	Create(ctx context.Context, dispense *database.Dispense) error
+	// Confirm creates the transaction and marks the dispense as confirmed by it in one
+	// step, ErrDispenseClosed is returned if it is not open at the transaction timestamp
+	Confirm(ctx context.Context, id uint, transaction *database.WaterTransaction) (*database.Dispense, error)
+}
+
+// DeviceRepository keeps the devices of the smart stations and their API keys
+type DeviceRepository interface {
+	Get(ctx context.Context, id uint) (*database.Device, error)
+	ListByStation(ctx context.Context, stationID uint) ([]database.Device, error)
+	// Create creates the device together with its first key
+	Create(ctx context.Context, device *database.Device, key *database.DeviceKey) error
+	// Revoke marks the device as revoked at the given time unless it already is
+	Revoke(ctx context.Context, id uint, at time.Time) error
+	// ListActive returns the devices of all stations that are not revoked
+	ListActive(ctx context.Context) ([]database.Device, error)
+	// Touch sets the time of the last heartbeat of the device
+	Touch(ctx context.Context, id uint, at time.Time) error
+	GetKeyByHash(ctx context.Context, hash string) (*database.DeviceKey, error)
+	ListKeys(ctx context.Context, deviceID uint) ([]database.DeviceKey, error)
+	// RotateKey adds the key to its device and lets the other keys of the device
+	// expire at graceUntil unless they expire or are revoked earlier
+	RotateKey(ctx context.Context, key *database.DeviceKey, graceUntil time.Time) error
+	// RevokeKey marks the key of the device as revoked at the given time unless it already is
+	RevokeKey(ctx context.Context, deviceID, keyID uint, at time.Time) error
+}
+
+// TelemetryRepository keeps the measurements reported by the station devices
+type TelemetryRepository interface {
+	Create(ctx context.Context, telemetry *database.Telemetry) error
+	// ListByStation returns at most limit reports of the station recorded from from
+	// until before to, ordered by the time they were recorded
+	ListByStation(ctx context.Context, stationID uint, from, to time.Time, limit int) ([]database.Telemetry, error)
+}
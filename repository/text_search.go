@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/search"
+)
+
+// Weights of the searchable station texts, the name matters most
+const (
+	nameWeight        = 4
+	addressWeight     = 3
+	waterSourceWeight = 2
+	descriptionWeight = 1
+)
+
+// searchStations ranks the stations with an in-process index
+func searchStations(stations []database.RefillStation, query string, limit int) []ScoredStation {
+	docs := make([]search.Document, len(stations))
+	for i, station := range stations {
+		docs[i] = search.Document{ID: station.ID, Fields: []search.Field{
+			{Text: station.Name, Weight: nameWeight},
+			{Text: station.Address, Weight: addressWeight},
+			{Text: station.WaterSource, Weight: waterSourceWeight},
+			{Text: station.Description, Weight: descriptionWeight},
+		}}
+	}
+	return rankStations(stations, search.NewIndex(docs).Search(query, limit))
+}
+
+// rankStations returns the stations of the hits in their order
+func rankStations(stations []database.RefillStation, hits []search.Hit) []ScoredStation {
+	byID := make(map[uint]database.RefillStation, len(stations))
+	for _, station := range stations {
+		byID[station.ID] = station
+	}
+	ranked := []ScoredStation{}
+	for _, hit := range hits {
+		if station, ok := byID[hit.ID]; ok {
+			ranked = append(ranked, ScoredStation{RefillStation: station, Score: hit.Score})
+		}
+	}
+	return ranked
+}
@@ -0,0 +1,42 @@
+// Package search is an in-process full-text index for short German texts like
+// station names and addresses. It ranks documents by weighted fields and
+// matches stems, prefixes, parts of compound words and words with typos.
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are frequent German words that carry no meaning for a search
+var stopWords = map[string]bool{
+	"der": true, "die": true, "das": true, "den": true, "dem": true, "des": true,
+	"ein": true, "eine": true, "einer": true, "eines": true, "einem": true, "einen": true,
+	"und": true, "oder": true, "in": true, "im": true, "an": true, "am": true, "auf": true,
+	"mit": true, "von": true, "vom": true, "zu": true, "zum": true, "zur": true, "bei": true,
+	"für": true, "ist": true, "sind": true, "es": true, "sie": true, "wir": true, "nicht": true,
+	"sich": true, "auch": true, "als": true, "wie": true, "so": true, "bis": true,
+}
+
+// Tokenize splits text into lower case words of letters and digits, without stop words
+func Tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != 'ß'
+	})
+	tokens := words[:0]
+	for _, word := range words {
+		if !stopWords[word] {
+			tokens = append(tokens, word)
+		}
+	}
+	return tokens
+}
+
+// Analyze tokenizes and stems the text
+func Analyze(text string) []string {
+	tokens := Tokenize(text)
+	for i, token := range tokens {
+		tokens[i] = Stem(token)
+	}
+	return tokens
+}
@@ -0,0 +1,180 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Field is a text of a document, matches in fields with a higher weight rank higher
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Document is an indexed record identified by its ID
+type Document struct {
+	ID     uint
+	Fields []Field
+}
+
+// Hit is a document matching a query with its relevance
+type Hit struct {
+	ID    uint
+	Score float64
+}
+
+// Factors of the ways a query term matches an indexed term
+const (
+	exactMatch    = 1.0
+	prefixMatch   = 0.8
+	compoundMatch = 0.6
+	typoMatch     = 0.5
+)
+
+// Index maps stems to the weighted documents containing them
+type Index struct {
+	postings map[string]map[uint]float64
+	docs     int
+}
+
+// NewIndex indexes the documents
+func NewIndex(docs []Document) *Index {
+	index := &Index{postings: map[string]map[uint]float64{}, docs: len(docs)}
+	for _, doc := range docs {
+		for _, field := range doc.Fields {
+			for _, term := range Analyze(field.Text) {
+				if index.postings[term] == nil {
+					index.postings[term] = map[uint]float64{}
+				}
+				index.postings[term][doc.ID] += field.Weight
+			}
+		}
+	}
+	return index
+}
+
+// Search returns at most limit documents matching every word of the query,
+// ordered by relevance and then by ID. Each word matches indexed words with the
+// same stem, words it starts, compound words containing it and words with a typo.
+func (index *Index) Search(query string, limit int) []Hit {
+	terms := Analyze(query)
+	if len(terms) == 0 {
+		return []Hit{}
+	}
+
+	var scores map[uint]float64
+	for _, term := range terms {
+		termScores := index.match(term)
+		if scores == nil {
+			scores = termScores
+			continue
+		}
+		for id := range scores {
+			if score, ok := termScores[id]; ok {
+				scores[id] += score
+			} else {
+				delete(scores, id)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+// match scores the documents containing an indexed word matching the query term,
+// each document by its best matching word
+func (index *Index) match(term string) map[uint]float64 {
+	_, known := index.postings[term]
+	scores := map[uint]float64{}
+	for indexed, postings := range index.postings {
+		factor := matchFactor(term, indexed, !known)
+		if factor == 0 {
+			continue
+		}
+		idf := math.Log(1 + float64(index.docs)/float64(len(postings)))
+		for id, weight := range postings {
+			if score := factor * idf * weight; score > scores[id] {
+				scores[id] = score
+			}
+		}
+	}
+	return scores
+}
+
+// matchFactor rates how well the query term matches the indexed term, 0 if it does not.
+// Typos are only tolerated for terms that are not indexed words themselves.
+func matchFactor(term, indexed string, typos bool) float64 {
+	switch {
+	case term == indexed:
+		return exactMatch
+	case len(term) >= 3 && strings.HasPrefix(indexed, term):
+		return prefixMatch
+	case len(term) >= 4 && strings.Contains(indexed, term):
+		return compoundMatch
+	}
+	if distance := maxTypos(len(term)); typos && distance > 0 && editDistance(term, indexed, distance) <= distance {
+		return typoMatch
+	}
+	return 0
+}
+
+// maxTypos is the number of typos tolerated in a word of the given length
+func maxTypos(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// editDistance returns the optimal string alignment distance of a and b, counting
+// insertions, deletions, substitutions and swaps of adjacent letters. Distances
+// above limit are reported as limit + 1.
+func editDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if diff := len(s) - len(t); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, curr[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return min(prev[len(t)], limit+1)
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Trippstadter Str. 2, 67663 Kaiserslautern – die Straße im Park")
+	want := []string{"trippstadter", "str", "2", "67663", "kaiserslautern", "straße", "park"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"stadtpark", "stadtpark", 0},
+		{"stadpark", "stadtpark", 1},
+		{"stadtprak", "stadtpark", 1},
+		{"stadtpork", "stadtpark", 1},
+		{"statpork", "stadtpark", 2},
+		{"brunnen", "quelle", 3},
+	}
+	for _, tc := range cases {
+		if got := editDistance(tc.a, tc.b, 2); got != tc.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestIndexSearch(t *testing.T) {
+	index := NewIndex([]Document{
+		{ID: 1, Fields: []Field{{"Stadtpark KL", 4}, {"Trippstadter Str. 2, 67663 Kaiserslautern", 2}, {"Spitzrainbrunnen", 1}}},
+		{ID: 2, Fields: []Field{{"Rewe Station", 4}, {"Fruchthallstraße 29, 67655 Kaiserslautern", 2}, {"Spitzrainbrunnen", 1}}},
+		{ID: 3, Fields: []Field{{"Gartenschau KL", 4}, {"Lauterstraße 51, 67659 Kaiserslautern", 2}, {"St. Georgsbrunnen", 1}}},
+		{ID: 4, Fields: []Field{{"Tourist Information", 4}, {"Fruchthallstraße 14, 67655 Kaiserslautern", 2}, {"Stadtwerke", 1}}},
+	})
+
+	cases := []struct {
+		name  string
+		query string
+		want  []uint
+	}{
+		{"by name", "Stadtpark", []uint{1}},
+		{"by address", "Trippstadter Str.", []uint{1}},
+		{"by inflected word", "Fruchthallstrassen", []uint{2, 4}},
+		{"by prefix", "Frucht", []uint{2, 4}},
+		{"by part of a compound word", "brunnen", []uint{3, 1, 2}},
+		{"with a typo", "Stadpark", []uint{1}},
+		{"with swapped letters", "Gartneschau", []uint{3}},
+		{"every word must match", "Rewe Lauterstraße", []uint{}},
+		{"name ranks above water source", "spitzrain", []uint{1, 2}},
+		{"only stop words", "die und das", []uint{}},
+		{"no match", "Berlin", []uint{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ids := []uint{}
+			for _, hit := range index.Search(tc.query, 10) {
+				ids = append(ids, hit.ID)
+			}
+			if !reflect.DeepEqual(ids, tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, ids)
+			}
+		})
+	}
+
+	if hits := index.Search("Kaiserslautern", 2); len(hits) != 2 {
+		t.Errorf("expected the limit to apply, got %v", hits)
+	}
+}
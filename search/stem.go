@@ -0,0 +1,178 @@
+package search
+
+import "strings"
+
+// Stem reduces a lower case German word to its stem with the Snowball German
+// stemmer, including the variant's handling of ae, oe and ue as umlauts.
+// Umlauts are folded into plain vowels, so "Häuser" and "Hauses" share "haus".
+func Stem(word string) string {
+	w := []rune(strings.ReplaceAll(word, "ß", "ss"))
+	w = replaceUmlautDigraphs(w)
+	markConsonantVowels(w)
+	r1, r2 := regions(w)
+
+	w = stemStep1(w, r1)
+	w = stemStep2(w, r1)
+	w = stemStep3(w, r1, r2)
+
+	for i, c := range w {
+		switch c {
+		case 'U':
+			w[i] = 'u'
+		case 'Y':
+			w[i] = 'y'
+		case 'ä':
+			w[i] = 'a'
+		case 'ö':
+			w[i] = 'o'
+		case 'ü':
+			w[i] = 'u'
+		}
+	}
+	return string(w)
+}
+
+func isVowel(c rune) bool {
+	return strings.ContainsRune("aeiouyäöü", c)
+}
+
+// replaceUmlautDigraphs turns ae, oe and ue into umlauts, except ue after q
+func replaceUmlautDigraphs(w []rune) []rune {
+	out := make([]rune, 0, len(w))
+	for i := 0; i < len(w); i++ {
+		if i+1 < len(w) && w[i+1] == 'e' {
+			switch {
+			case w[i] == 'a':
+				out = append(out, 'ä')
+				i++
+				continue
+			case w[i] == 'o':
+				out = append(out, 'ö')
+				i++
+				continue
+			case w[i] == 'u' && (i == 0 || w[i-1] != 'q'):
+				out = append(out, 'ü')
+				i++
+				continue
+			}
+		}
+		out = append(out, w[i])
+	}
+	return out
+}
+
+// markConsonantVowels upper cases u and y between vowels so they count as consonants
+func markConsonantVowels(w []rune) {
+	for i := 1; i+1 < len(w); i++ {
+		if (w[i] == 'u' || w[i] == 'y') && isVowel(w[i-1]) && isVowel(w[i+1]) {
+			w[i] = w[i] - 'a' + 'A'
+		}
+	}
+}
+
+// regions returns the starts of R1 and R2, R1 is adjusted to leave at least three letters before it
+func regions(w []rune) (int, int) {
+	// after returns the position following the first non-vowel after a vowel at or behind start
+	after := func(start int) int {
+		for i := start + 1; i < len(w); i++ {
+			if isVowel(w[i-1]) && !isVowel(w[i]) {
+				return i + 1
+			}
+		}
+		return len(w)
+	}
+	r1 := after(0)
+	r2 := after(r1)
+	return max(r1, 3), r2
+}
+
+func hasSuffix(w []rune, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+// inRegion reports whether the suffix of the given length lies in the region starting at start
+func inRegion(w []rune, length, start int) bool {
+	return len(w)-length >= start
+}
+
+func stemStep1(w []rune, r1 int) []rune {
+	for _, suffix := range []string{"ern", "em", "er"} {
+		if hasSuffix(w, suffix) {
+			if inRegion(w, len(suffix), r1) {
+				return w[:len(w)-len(suffix)]
+			}
+			return w
+		}
+	}
+	for _, suffix := range []string{"en", "es", "e"} {
+		if hasSuffix(w, suffix) {
+			if inRegion(w, len([]rune(suffix)), r1) {
+				w = w[:len(w)-len(suffix)]
+				if hasSuffix(w, "niss") {
+					w = w[:len(w)-1]
+				}
+			}
+			return w
+		}
+	}
+	if hasSuffix(w, "s") && inRegion(w, 1, r1) && len(w) >= 2 && strings.ContainsRune("bdfghklmnrt", w[len(w)-2]) {
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func stemStep2(w []rune, r1 int) []rune {
+	for _, suffix := range []string{"est", "en", "er"} {
+		if hasSuffix(w, suffix) {
+			if inRegion(w, len(suffix), r1) {
+				return w[:len(w)-len(suffix)]
+			}
+			return w
+		}
+	}
+	if hasSuffix(w, "st") && inRegion(w, 2, r1) && len(w) >= 6 && strings.ContainsRune("bdfghklmnt", w[len(w)-3]) {
+		return w[:len(w)-2]
+	}
+	return w
+}
+
+func stemStep3(w []rune, r1, r2 int) []rune {
+	cut := func(n int) []rune { return w[:len(w)-n] }
+	switch {
+	case hasSuffix(w, "end") || hasSuffix(w, "ung"):
+		if !inRegion(w, 3, r2) {
+			return w
+		}
+		w = cut(3)
+		if hasSuffix(w, "ig") && inRegion(w, 2, r2) && !hasSuffix(w[:len(w)-2], "e") {
+			w = w[:len(w)-2]
+		}
+	case hasSuffix(w, "isch"):
+		if inRegion(w, 4, r2) && !hasSuffix(w[:len(w)-4], "e") {
+			w = cut(4)
+		}
+	case hasSuffix(w, "ig") || hasSuffix(w, "ik"):
+		if inRegion(w, 2, r2) && !hasSuffix(w[:len(w)-2], "e") {
+			w = cut(2)
+		}
+	case hasSuffix(w, "lich") || hasSuffix(w, "heit"):
+		if !inRegion(w, 4, r2) {
+			return w
+		}
+		w = cut(4)
+		if (hasSuffix(w, "er") || hasSuffix(w, "en")) && inRegion(w, 2, r1) {
+			w = w[:len(w)-2]
+		}
+	case hasSuffix(w, "keit"):
+		if !inRegion(w, 4, r2) {
+			return w
+		}
+		w = cut(4)
+		if hasSuffix(w, "lich") && inRegion(w, 4, r2) {
+			w = w[:len(w)-4]
+		} else if hasSuffix(w, "ig") && inRegion(w, 2, r2) {
+			w = w[:len(w)-2]
+		}
+	}
+	return w
+}
@@ -0,0 +1,34 @@
+package search
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	cases := map[string]string{
+		"häuser":         "haus",
+		"hauses":         "haus",
+		"haus":           "haus",
+		"kinder":         "kind",
+		"laufen":         "lauf",
+		"brunnen":        "brunn",
+		"brunnens":       "brunn",
+		"straße":         "strass",
+		"strasse":        "strass",
+		"trippstadter":   "trippstadt",
+		"königstraße":    "konigstrass",
+		"koenigstrasse":  "konigstrass",
+		"quelle":         "quell",
+		"ergebnisse":     "ergebnis",
+		"schönheit":      "schonheit",
+		"freundlichkeit": "freundlich",
+		"wasser":         "wass",
+		"aufeinander":    "aufeinand",
+		"kategorisch":    "kategor",
+		"a":              "a",
+		"":               "",
+	}
+	for word, want := range cases {
+		if got := Stem(word); got != want {
+			t.Errorf("Stem(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
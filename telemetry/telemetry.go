@@ -0,0 +1,161 @@
+// Package telemetry records the heartbeats and measurements the devices of the smart
+// stations report, over HTTP or MQTT, and keeps the active state of the stations in
+// line with the heartbeats: a station whose devices stop reporting is deactivated and
+// activated again when a heartbeat arrives.
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+)
+
+// Limits of the reported times
+const (
+	// MaxReportAge is how long a device may buffer a measurement
+	MaxReportAge = 7 * 24 * time.Hour
+	// MaxClockSkew is how far the clock of a device may run ahead
+	MaxClockSkew = 5 * time.Minute
+)
+
+// ErrInvalidReport is returned for a report with measurements out of range or an implausible time
+var ErrInvalidReport = errors.New("invalid telemetry report")
+
+// Service records the reports of the station devices and monitors their heartbeats
+type Service struct {
+	stations  repository.RefillStationRepository
+	devices   repository.DeviceRepository
+	telemetry repository.TelemetryRepository
+}
+
+func NewService(repos repository.Repositories) *Service {
+	return &Service{stations: repos.Stations, devices: repos.Devices, telemetry: repos.Telemetry}
+}
+
+// Record takes the report of the device as a heartbeat at now and stores its measurements,
+// if it has any, for the station of the device. The recording time defaults to now. A station
+// the monitor deactivated is activated again. stored reports whether measurements were stored.
+func (s *Service) Record(ctx context.Context, device *database.Device, report *database.Telemetry, now time.Time) (stored bool, err error) {
+	if err := validate(report, now); err != nil {
+		return false, err
+	}
+	if err := s.devices.Touch(ctx, device.ID, now); err != nil {
+		return false, err
+	}
+	if report.HasMeasurements() {
+		report.ID = 0
+		report.StationID = device.StationID
+		report.DeviceID = device.ID
+		if err := s.telemetry.Create(ctx, report); err != nil {
+			return false, err
+		}
+	}
+
+	online, err := s.stations.MarkOnline(ctx, &database.StationStatusChange{
+		StationID: device.StationID,
+		Active:    true,
+		Source:    database.StatusSourceTelemetry,
+		Reason:    "Heartbeats resumed",
+		DeviceID:  &device.ID,
+		ChangedAt: now,
+	})
+	if err != nil {
+		return false, err
+	}
+	if online {
+		slog.Info("Refill station is back online", "station", device.StationID, "device", device.ID)
+	}
+	return report.HasMeasurements(), nil
+}
+
+// HandleMessage records the JSON report of an MQTT telemetry message, an empty
+// message is a heartbeat. It is the telemetry handler of the ingest subscriber.
+func (s *Service) HandleMessage(ctx context.Context, device *database.Device, payload []byte) error {
+	var report database.Telemetry
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &report); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+		}
+	}
+	_, err := s.Record(ctx, device, &report, time.Now())
+	return err
+}
+
+// CheckHeartbeats deactivates the stations whose devices sent no heartbeat within the
+// timeout before now, as of their last heartbeat. Stations without a device that ever
+// sent one are left alone.
+func (s *Service) CheckHeartbeats(ctx context.Context, now time.Time, timeout time.Duration) error {
+	devices, err := s.devices.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	// lastSeen holds the device of each station that sent the last heartbeat
+	lastSeen := map[uint]database.Device{}
+	for _, device := range devices {
+		if device.LastSeenAt == nil {
+			continue
+		}
+		if latest, ok := lastSeen[device.StationID]; !ok || device.LastSeenAt.After(*latest.LastSeenAt) {
+			lastSeen[device.StationID] = device
+		}
+	}
+
+	for stationID, device := range lastSeen {
+		if now.Sub(*device.LastSeenAt) <= timeout {
+			continue
+		}
+		offline, err := s.stations.MarkOffline(ctx, &database.StationStatusChange{
+			StationID: stationID,
+			Active:    false,
+			Source:    database.StatusSourceTelemetry,
+			Reason:    fmt.Sprintf("No heartbeat for %s", timeout),
+			DeviceID:  &device.ID,
+			ChangedAt: *device.LastSeenAt,
+		})
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return err
+		}
+		if offline {
+			slog.Warn("Refill station stopped sending heartbeats", "station", stationID, "last_seen", *device.LastSeenAt)
+		}
+	}
+	return nil
+}
+
+// Monitor checks the heartbeats every interval until the context is done
+func (s *Service) Monitor(ctx context.Context, timeout, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := s.CheckHeartbeats(ctx, now, timeout); err != nil {
+				slog.Error("Heartbeat check failed", "error", err)
+			}
+		}
+	}
+}
+
+// validate checks the measurements and the recording time of the report
+func validate(report *database.Telemetry, now time.Time) error {
+	if err := report.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	switch {
+	case report.RecordedAt.IsZero():
+		report.RecordedAt = now
+	case report.RecordedAt.After(now.Add(MaxClockSkew)):
+		return fmt.Errorf("%w: recorded_at is in the future", ErrInvalidReport)
+	case report.RecordedAt.Before(now.Add(-MaxReportAge)):
+		return fmt.Errorf("%w: recorded_at is older than %d days", ErrInvalidReport, int(MaxReportAge.Hours()/24))
+	}
+	return nil
+}
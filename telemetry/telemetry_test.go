@@ -0,0 +1,167 @@
+package telemetry_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/PoseidonPSE2/code_backend/database"
+	"github.com/PoseidonPSE2/code_backend/repository"
+	"github.com/PoseidonPSE2/code_backend/telemetry"
+)
+
+const timeout = 10 * time.Minute
+
+// seededRepositories creates an active smart station with a device
+func seededRepositories(t *testing.T) (repository.Repositories, *database.Device) {
+	t.Helper()
+	ctx := context.Background()
+	repos := repository.NewMemoryRepositories()
+	station := database.RefillStation{Name: "Test", Latitude: 49.44, Longitude: 7.77, Type: "smart", OfferedWaterTypes: "tap", WaterSource: "test"}
+	must(t, repos.Stations.Create(ctx, &station))
+	device := database.Device{StationID: station.ID, Name: "controller"}
+	must(t, repos.Devices.Create(ctx, &device, &database.DeviceKey{KeyHash: "hash", Prefix: "pdk_test"}))
+	return repos, &device
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func stationState(t *testing.T, repos repository.Repositories, id uint) *database.RefillStation {
+	t.Helper()
+	station, err := repos.Stations.Get(context.Background(), id)
+	must(t, err)
+	return station
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	repos, device := seededRepositories(t)
+	service := telemetry.NewService(repos)
+	now := time.Now()
+
+	level, status := 80.0, "OK"
+	report := database.Telemetry{TankLevel: &level, FilterStatus: &status}
+	stored, err := service.Record(ctx, device, &report, now)
+	if err != nil || !stored {
+		t.Fatalf("expected the report to be stored, got %v %v", stored, err)
+	}
+	if report.StationID != device.StationID || report.DeviceID != device.ID || !report.RecordedAt.Equal(now) || *report.FilterStatus != "ok" {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	if stored, err := service.Record(ctx, device, &database.Telemetry{}, now.Add(time.Minute)); err != nil || stored {
+		t.Errorf("expected a heartbeat not to be stored, got %v %v", stored, err)
+	}
+	reports, err := repos.Telemetry.ListByStation(ctx, device.StationID, now.Add(-time.Hour), now.Add(time.Hour), 10)
+	must(t, err)
+	if len(reports) != 1 {
+		t.Errorf("expected one stored report, got %d", len(reports))
+	}
+	storedDevice, err := repos.Devices.Get(ctx, device.ID)
+	must(t, err)
+	if storedDevice.LastSeenAt == nil || !storedDevice.LastSeenAt.Equal(now.Add(time.Minute)) {
+		t.Errorf("expected the heartbeat to update the device, got %v", storedDevice.LastSeenAt)
+	}
+
+	invalid := []database.Telemetry{
+		{TankLevel: ptr(120.0)},
+		{FlowRate: ptr(-1.0)},
+		{FilterStatus: ptr("broken")},
+		{RecordedAt: now.Add(time.Hour)},
+		{RecordedAt: now.Add(-telemetry.MaxReportAge - time.Hour)},
+	}
+	for _, report := range invalid {
+		if _, err := service.Record(ctx, device, &report, now); !errors.Is(err, telemetry.ErrInvalidReport) {
+			t.Errorf("expected %+v to be invalid, got %v", report, err)
+		}
+	}
+	if err := service.HandleMessage(ctx, device, []byte("not json")); !errors.Is(err, telemetry.ErrInvalidReport) {
+		t.Errorf("expected a malformed message to be invalid, got %v", err)
+	}
+}
+
+func TestCheckHeartbeats(t *testing.T) {
+	ctx := context.Background()
+	repos, device := seededRepositories(t)
+	service := telemetry.NewService(repos)
+	other := database.RefillStation{Name: "Without heartbeats", Latitude: 49.44, Longitude: 7.77, Type: "smart", OfferedWaterTypes: "tap", WaterSource: "test"}
+	must(t, repos.Stations.Create(ctx, &other))
+	start := time.Now()
+
+	_, err := service.Record(ctx, device, &database.Telemetry{}, start)
+	must(t, err)
+	must(t, service.CheckHeartbeats(ctx, start.Add(timeout), timeout))
+	if station := stationState(t, repos, device.StationID); !station.Active.Bool || station.OfflineSince != nil {
+		t.Fatalf("expected the station to stay active within the timeout, got %+v", station)
+	}
+
+	must(t, service.CheckHeartbeats(ctx, start.Add(timeout+time.Minute), timeout))
+	station := stationState(t, repos, device.StationID)
+	if station.Active.Bool || station.OfflineSince == nil || !station.OfflineSince.Equal(start) {
+		t.Fatalf("expected the station to be offline since the last heartbeat, got %+v", station)
+	}
+	if station := stationState(t, repos, other.ID); !station.Active.Bool {
+		t.Errorf("expected a station without heartbeats to be left alone")
+	}
+
+	_, err = service.Record(ctx, device, &database.Telemetry{}, start.Add(time.Hour))
+	must(t, err)
+	if station := stationState(t, repos, device.StationID); !station.Active.Bool || station.OfflineSince != nil {
+		t.Errorf("expected the heartbeat to activate the station again, got %+v", station)
+	}
+
+	changes, err := repos.Stations.ListStatusChanges(ctx, device.StationID, start.Add(-time.Hour), start.Add(2*time.Hour))
+	must(t, err)
+	if len(changes) != 2 {
+		t.Fatalf("expected the deactivation and activation to be recorded, got %+v", changes)
+	}
+	offline, online := changes[0], changes[1]
+	if offline.Active || offline.Source != database.StatusSourceTelemetry || !offline.ChangedAt.Equal(start) || *offline.DeviceID != device.ID {
+		t.Errorf("unexpected deactivation %+v", offline)
+	}
+	if !online.Active || online.Source != database.StatusSourceTelemetry || !online.ChangedAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("unexpected activation %+v", online)
+	}
+}
+
+func TestCheckHeartbeatsKeepsManualState(t *testing.T) {
+	ctx := context.Background()
+	repos, device := seededRepositories(t)
+	service := telemetry.NewService(repos)
+	start := time.Now()
+
+	_, err := service.Record(ctx, device, &database.Telemetry{}, start)
+	must(t, err)
+	must(t, service.CheckHeartbeats(ctx, start.Add(time.Hour), timeout))
+	manual := database.StationStatusChange{StationID: device.StationID, Active: false, Source: database.StatusSourceManual, Reason: "Maintenance", ChangedAt: start.Add(2 * time.Hour)}
+	changed, err := repos.Stations.SetActive(ctx, &manual)
+	must(t, err)
+	if changed {
+		t.Errorf("expected the offline station to be inactive already")
+	}
+	if station := stationState(t, repos, device.StationID); station.OfflineSince != nil {
+		t.Errorf("expected the operator to take over the station, got %+v", station)
+	}
+
+	_, err = service.Record(ctx, device, &database.Telemetry{}, start.Add(3*time.Hour))
+	must(t, err)
+	if station := stationState(t, repos, device.StationID); station.Active.Bool {
+		t.Errorf("expected a heartbeat not to activate a station deactivated by its operator")
+	}
+	must(t, service.CheckHeartbeats(ctx, start.Add(4*time.Hour), timeout))
+	changes, err := repos.Stations.ListStatusChanges(ctx, device.StationID, start, start.Add(5*time.Hour))
+	must(t, err)
+	if len(changes) != 1 {
+		t.Errorf("expected only the deactivation by the monitor to be recorded, got %+v", changes)
+	}
+}
+
+func ptr[T any](value T) *T {
+	return &value
+}